@@ -0,0 +1,4766 @@
+// Package generator sends random S3 operations to a MinIO server. Programs
+// embedding it can register their own operations with RegisterOperation
+// before calling Execute.
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"math/big"
+	mathrand "math/rand"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/dustin/go-humanize"
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+	"github.com/minio/minio-go/v7/pkg/tags"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/time/rate"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the run settings. The yaml keys match the flag names so a
+// --config file can set any flag.
+type Config struct {
+	Endpoint       string        `yaml:"endpoint"`
+	AccessKey      string        `yaml:"access-key"`
+	SecretKey      string        `yaml:"secret-key"`
+	Buckets        string        `yaml:"buckets"`
+	UseSSL         bool          `yaml:"ssl"`
+	MCAlias        string        `yaml:"alias"`
+	Duration       time.Duration `yaml:"duration"`
+	OperationDelay time.Duration `yaml:"delay"`
+	ObjectPrefix   string        `yaml:"prefix"`
+	Concurrency    int           `yaml:"concurrency"`
+	Rate           float64       `yaml:"rate"`
+	Operations     string        `yaml:"operations"`
+	Verify         bool          `yaml:"verify"`
+	// Ramp is the time over which the rate, or the number of active workers
+	// without --rate, grows linearly to the target at start
+	Ramp time.Duration `yaml:"ramp"`
+	// EndpointStats breaks the final statistics down per --endpoint entry
+	EndpointStats bool `yaml:"endpoint-stats"`
+	// DiscardReads streams downloaded bodies to io.Discard instead of holding them in memory
+	DiscardReads bool `yaml:"discard-reads"`
+	// ConsistencyCheck reads every written object back and compares it to what was uploaded
+	ConsistencyCheck bool `yaml:"consistency-check"`
+	// MultipartSize is the total size of objects uploaded by the multipart operation
+	MultipartSize ByteSize `yaml:"multipart-size"`
+	// MultipartPartSize is the part size used by the multipart operation
+	MultipartPartSize ByteSize `yaml:"multipart-part-size"`
+	Output            string   `yaml:"output"`
+	// BucketWeights skews bucket selection for new objects, e.g. "hot=80,cold=20"
+	BucketWeights string `yaml:"bucket-weights"`
+	// OperationWeights overrides the registered weights of the selected
+	// operations, e.g. "write=3,read=1"
+	OperationWeights string `yaml:"operation-weights"`
+	// PrefixDepthMin and PrefixDepthMax bound the number of directory levels
+	// of generated prefixes
+	PrefixDepthMin int `yaml:"prefix-depth-min"`
+	PrefixDepthMax int `yaml:"prefix-depth-max"`
+	// PrefixWords is a file with the words of each prefix level, one level per line
+	PrefixWords string `yaml:"prefix-words"`
+	// ListPrefix scopes the listings of the list operation, the whole bucket when empty
+	ListPrefix string `yaml:"list-prefix"`
+	// SourceDir is a directory whose files are uploaded instead of generated content
+	SourceDir string `yaml:"source-dir"`
+	// MaxErrorRate stops the run with a non-zero exit code once the fraction of
+	// failed operations exceeds it, 0 disables the check
+	MaxErrorRate float64 `yaml:"max-error-rate"`
+	// MinSamples is how many operations must finish before MaxErrorRate applies
+	MinSamples int64 `yaml:"min-samples"`
+	// MaxRetries is how many times an operation failing with a transient
+	// error is retried before it counts as an error
+	MaxRetries int `yaml:"max-retries"`
+	// OpTimeout bounds each attempt of an operation; 0 means no limit
+	OpTimeout time.Duration `yaml:"op-timeout"`
+	// CACert is a PEM file of CA certificates trusted in addition to the system roots
+	CACert string `yaml:"ca-cert"`
+	// Insecure skips TLS certificate verification, for self-signed test setups
+	Insecure bool `yaml:"insecure"`
+	// Proxy is the URL of the proxy for all requests, overriding the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables
+	Proxy string `yaml:"proxy"`
+	// STSEndpoint is the URL of an STS service to get temporary credentials
+	// from, using the access and secret keys as the AssumeRole caller
+	STSEndpoint string `yaml:"sts-endpoint"`
+	// RoleARN is the role to assume, needed by AWS STS but not by MinIO
+	RoleARN string `yaml:"role-arn"`
+	// AbandonMultipart runs the abandonmultipart operation, which leaves
+	// incomplete multipart uploads behind for abortmultipart to clean up
+	AbandonMultipart bool `yaml:"abandon-multipart"`
+	// PresignExpiry is the validity of the URLs used by the presign operations
+	PresignExpiry time.Duration `yaml:"presign-expiry"`
+	// BatchDelete makes prefix deletes use multi-object delete requests
+	BatchDelete bool `yaml:"batch-delete"`
+	// LogCSV is a file that gets a CSV row appended per S3 call
+	LogCSV string `yaml:"log-csv"`
+	// Manifest is a file that gets a JSON summary of the run written at exit
+	Manifest string `yaml:"manifest"`
+	// MaxObjects caps the objects created by the run, net of deletes; 0 means no cap
+	MaxObjects int64 `yaml:"max-objects"`
+	// Versioned enables versioning on created buckets and the versiondelete operation
+	Versioned bool `yaml:"versioned"`
+	// ObjectLock creates buckets with object locking and enables the retention
+	// and legal hold operations
+	ObjectLock bool `yaml:"object-lock"`
+	// BucketObjectLock creates buckets with object locking without running the
+	// retention and legal hold operations
+	BucketObjectLock bool `yaml:"bucket-object-lock"`
+	// LifecycleExpiryDays installs a lifecycle rule expiring the objects of
+	// created buckets after this many days, 0 to install none
+	LifecycleExpiryDays int `yaml:"lifecycle-expiry-days"`
+	// Region is the region buckets are created in and requests are signed for
+	Region string `yaml:"region"`
+	// PathStyle puts the bucket in the URL path instead of the host name
+	PathStyle bool `yaml:"path-style"`
+	// StorageClass is sent as the storage class of uploads, the server default when empty
+	StorageClass string `yaml:"storage-class"`
+	// ContentType is sent as the Content-Type of every upload, guessed from
+	// the --source-dir file or left to the client default when empty
+	ContentType string `yaml:"content-type"`
+	// MetadataCount is the number of random user metadata entries added to each upload
+	MetadataCount int `yaml:"metadata-count"`
+	// SSE selects server-side encryption for uploads: "", "s3" or "c"
+	SSE string `yaml:"sse"`
+	// SSECKey is the base64-encoded 32-byte SSE-C key, generated when empty
+	SSECKey string `yaml:"sse-c-key"`
+	// MetricsAddr is the listen address of the Prometheus endpoint, empty to disable it
+	MetricsAddr string `yaml:"metrics-addr"`
+	// Seed makes object naming, content and operation selection reproducible; 0 uses crypto/rand
+	Seed int64 `yaml:"seed"`
+	// SkipHealthCheck starts without probing the endpoint first
+	SkipHealthCheck bool `yaml:"skip-healthcheck"`
+	// LogLevel is the lowest level logged: debug, info, warn or error
+	LogLevel string `yaml:"log-level"`
+	// LogFormat selects text or json log records
+	LogFormat string `yaml:"log-format"`
+	// Quiet drops the record of each operation, keeping failures and summaries
+	Quiet bool `yaml:"quiet"`
+	// DryRun logs the selected operations and counts them without sending requests
+	DryRun bool `yaml:"dry-run"`
+	// RunID is stored as run-id metadata on every object the run creates,
+	// generated at start when empty
+	RunID string `yaml:"run-id"`
+	// CleanupRun deletes the objects of the given run ID instead of running
+	// the workload
+	CleanupRun string `yaml:"cleanup-run"`
+	// Cleanup deletes every object with the prefix instead of running the workload
+	Cleanup bool `yaml:"cleanup"`
+	// CleanupBuckets removes the buckets left empty by a cleanup
+	CleanupBuckets bool `yaml:"cleanup-buckets"`
+	// ConfigFile is the --config file the other fields were loaded from
+	ConfigFile string `yaml:"-"`
+}
+
+// ByteSize is a size in bytes that accepts human-readable flag values such as 70MiB
+type ByteSize int64
+
+// UnmarshalYAML accepts sizes in a --config file, either as a number of bytes or as 70MiB
+func (b *ByteSize) UnmarshalYAML(node *yaml.Node) error {
+	return b.Set(node.Value)
+}
+
+// MarshalYAML writes the size in the same human-readable form the flag shows
+func (b ByteSize) MarshalYAML() (interface{}, error) {
+	return b.String(), nil
+}
+
+func (b *ByteSize) Set(value string) error {
+	size, err := humanize.ParseBytes(value)
+	if err != nil {
+		return err
+	}
+	*b = ByteSize(size)
+	return nil
+}
+
+// String shows the size in human-readable form when that parses back to the
+// same size, and as an exact byte count otherwise, so the value survives
+// loadConfigFile restoring the flags and the config saved in the manifest
+func (b ByteSize) String() string {
+	if human := humanize.IBytes(uint64(b)); parsesTo(human, b) {
+		return human
+	}
+	return strconv.FormatUint(uint64(b), 10)
+}
+
+// parsesTo reports whether value parses to size
+func parsesTo(value string, size ByteSize) bool {
+	parsed, err := humanize.ParseBytes(value)
+	return err == nil && ByteSize(parsed) == size
+}
+
+func (b *ByteSize) Type() string {
+	return "size"
+}
+
+// maxPresignExpiry is the longest validity S3 accepts for a presigned URL
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// S3 multipart upload limits
+const (
+	minPartSize = 5 * 1024 * 1024
+	maxPartSize = 5 * 1024 * 1024 * 1024
+	maxParts    = 10000
+)
+
+type MinioClient struct {
+	client    *minio.Client
+	config    Config
+	stats     *Stats
+	startTime time.Time
+	// clients holds one client per --endpoint entry and endpoints their
+	// addresses; client is the first one, used to set up the buckets
+	clients   []*minio.Client
+	endpoints []string
+	// nextEndpoint counts the attempts to pick the clients round-robin
+	nextEndpoint atomic.Uint64
+	// endpointStats counts the attempts per endpoint with --endpoint-stats,
+	// nil otherwise
+	endpointStats []endpointCounters
+	// sse encrypts uploads when --sse is set, nil otherwise
+	sse encrypt.ServerSide
+	// latency records the duration of the S3 call behind each operation
+	latency *latencyRecorder
+	// outcomes counts the successes and failures of each scheduled operation
+	outcomes *outcomeRecorder
+	// buckets remembers the bucket checks already answered by each endpoint
+	buckets *bucketCache
+	// operationLog receives a row per S3 call with --log-csv, nil otherwise
+	operationLog *operationLog
+	// prefixWords is the vocabulary loaded from --prefix-words, nil to use
+	// defaultPrefixWords
+	prefixWords [][]string
+	// sourceFiles are the files found in --source-dir, empty to generate content
+	sourceFiles []sourceFile
+	// random is the seeded source used with --seed, nil to use crypto/rand
+	random io.Reader
+	// nameSequence numbers the object names generated with --seed
+	nameSequence atomic.Int64
+	// weights holds the weight of each selected operation, nil to use the
+	// registered weights
+	weights map[string]int64
+	// stopRun cancels the context of the running workers
+	stopRun context.CancelFunc
+	// aborted is set when the run was stopped by --max-error-rate
+	aborted atomic.Bool
+	// netObjects is the number of objects created minus deleted by this run,
+	// updated atomically and compared against --max-objects
+	netObjects int64
+}
+
+// Output formats for the statistics
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// logOutput receives the log records. It is switched to stderr with
+// --output json so stdout only carries the JSON statistics.
+var logOutput io.Writer = os.Stdout
+
+// Log formats
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// logLevels maps the --log-level values to slog levels. Successful operations
+// log at debug, failures at warn and startup and periodic summaries at info.
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// logger writes the log records, set up from --log-level and --log-format by setupLogging
+var logger = slog.New(slog.NewTextHandler(logOutput, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+// operationLogger writes the per-operation records. It discards them with
+// --quiet, which at high rates would otherwise make logging the bottleneck.
+var operationLogger = logger
+
+// setupLogging points logger at logOutput with the configured level and format
+func setupLogging(cfg Config) {
+	if cfg.Output == outputJSON {
+		logOutput = os.Stderr
+	}
+	options := &slog.HandlerOptions{Level: logLevels[cfg.LogLevel]}
+	if cfg.LogFormat == logFormatJSON {
+		logger = slog.New(slog.NewJSONHandler(logOutput, options))
+	} else {
+		logger = slog.New(slog.NewTextHandler(logOutput, options))
+	}
+	operationLogger = logger
+	if cfg.Quiet {
+		operationLogger = slog.New(slog.DiscardHandler)
+	}
+}
+
+// fatal logs msg with err at error level and exits
+func fatal(msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// LogSuccess logs a successful operation on bucket/key at debug level, with
+// attrs describing what it did
+func LogSuccess(op, bucket, key string, attrs ...any) {
+	operationLogger.Debug("operation succeeded", append([]any{"op", op, "bucket", bucket, "key", key}, attrs...)...)
+}
+
+// httpClient sends the requests made outside the minio client, the transfers
+// over presigned URLs. It shares the proxy, --ca-cert and --insecure settings.
+var httpClient = http.DefaultClient
+
+// Buckets returns the comma-separated --buckets as a list
+func (m *MinioClient) Buckets() []string {
+	return parseList(m.config.Buckets)
+}
+
+// parseList splits a comma-separated value, trimming spaces and dropping empty entries
+func parseList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+
+	items := strings.Split(value, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+
+	// Remove empty strings
+	var result []string
+	for _, item := range items {
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+// getRandomBucket returns a random bucket from the configured buckets
+func (m *MinioClient) getRandomBucket() (string, error) {
+	buckets := m.Buckets()
+	if len(buckets) == 0 {
+		return "", fmt.Errorf("no buckets configured")
+	}
+
+	if len(buckets) == 1 {
+		return buckets[0], nil
+	}
+
+	if m.config.BucketWeights != "" {
+		return m.getWeightedBucket(buckets)
+	}
+
+	index, err := rand.Int(m.randomSource(), big.NewInt(int64(len(buckets))))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate random bucket selection: %v", err)
+	}
+
+	return buckets[index.Int64()], nil
+}
+
+// getWeightedBucket picks a bucket with probability proportional to its
+// --bucket-weights entry. Buckets without a weight are never picked.
+func (m *MinioClient) getWeightedBucket(buckets []string) (string, error) {
+	weights, err := parseBucketWeights(m.config.BucketWeights, buckets)
+	if err != nil {
+		return "", err
+	}
+
+	var total int64
+	for _, bucket := range buckets {
+		total += weights[bucket]
+	}
+
+	pick, err := rand.Int(m.randomSource(), big.NewInt(total))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate random bucket selection: %v", err)
+	}
+
+	remaining := pick.Int64()
+	for _, bucket := range buckets {
+		if remaining < weights[bucket] {
+			return bucket, nil
+		}
+		remaining -= weights[bucket]
+	}
+	return buckets[len(buckets)-1], nil
+}
+
+// parseBucketWeights parses --bucket-weights such as "hot=80,cold=20". Every
+// name must be one of buckets and at least one weight must be positive.
+func parseBucketWeights(value string, buckets []string) (map[string]int64, error) {
+	known := make(map[string]bool, len(buckets))
+	for _, bucket := range buckets {
+		known[bucket] = true
+	}
+
+	weights := make(map[string]int64)
+	var total int64
+	for _, entry := range parseList(value) {
+		name, weightValue, found := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !found {
+			return nil, fmt.Errorf("invalid bucket weight '%s', expected bucket=weight", entry)
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("bucket '%s' in --bucket-weights is not listed in --buckets", name)
+		}
+		if _, duplicate := weights[name]; duplicate {
+			return nil, fmt.Errorf("bucket '%s' is weighted more than once", name)
+		}
+		weight, err := strconv.ParseInt(strings.TrimSpace(weightValue), 10, 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight '%s' for bucket '%s', expected a non-negative integer", weightValue, name)
+		}
+		weights[name] = weight
+		total += weight
+	}
+
+	if total == 0 {
+		return nil, fmt.Errorf("--bucket-weights must give at least one bucket a positive weight")
+	}
+	return weights, nil
+}
+
+// Stats holds the operation counters. Fields are updated concurrently, so they
+// must only be accessed through the sync/atomic functions or Snapshot.
+type Stats struct {
+	ReadOps         int64 `json:"readOps"`
+	RangeReadOps    int64 `json:"rangeReadOps"`
+	WriteOps        int64 `json:"writeOps"`
+	OverwriteOps    int64 `json:"overwriteOps"`
+	DeleteOps       int64 `json:"deleteOps"`
+	PrefixDeleteOps int64 `json:"prefixDeleteOps"`
+	MultipartOps    int64 `json:"multipartOps"`
+	TagOps          int64 `json:"tagOps"`
+	CopyOps         int64 `json:"copyOps"`
+	// ComposeOps counts objects concatenated server-side from uploaded parts
+	ComposeOps int64 `json:"composeOps"`
+	StatOps    int64 `json:"statOps"`
+	// ListOps counts recursive listings run as an operation of their own,
+	// not the ones other operations use to pick an object
+	ListOps int64 `json:"listOps"`
+	// PresignPutOps and PresignGetOps count transfers over presigned URLs
+	PresignPutOps int64 `json:"presignPutOps"`
+	PresignGetOps int64 `json:"presignGetOps"`
+	// AbortMultipartOps counts incomplete multipart uploads that were aborted,
+	// AbandonMultipartOps the ones deliberately left incomplete
+	AbortMultipartOps   int64 `json:"abortMultipartOps"`
+	AbandonMultipartOps int64 `json:"abandonMultipartOps"`
+	// VersionDeleteOps counts removals of a specific object version
+	VersionDeleteOps int64 `json:"versionDeleteOps"`
+	RetentionOps     int64 `json:"retentionOps"`
+	LegalHoldOps     int64 `json:"legalHoldOps"`
+	// RetriedOps counts operations that succeeded after at least one retry.
+	// They are also counted by their own operation counter.
+	RetriedOps int64 `json:"retriedOps"`
+	// LockedOps counts operations refused because the object is locked, which
+	// is expected with --object-lock and not counted in ErrorOps
+	LockedOps int64 `json:"lockedOps"`
+	ErrorOps  int64 `json:"errorOps"`
+	// TimeoutOps counts the ErrorOps whose last attempt ran into --op-timeout
+	TimeoutOps int64 `json:"timeoutOps"`
+	// VerifyFailureOps counts reads whose content didn't match the stored checksum
+	VerifyFailureOps int64 `json:"verifyFailureOps"`
+	// ConsistencyFailures counts written objects that were missing or
+	// different when read back right away with --consistency-check
+	ConsistencyFailures int64 `json:"consistencyFailures"`
+	// BytesWritten and BytesRead count the object payload of successful
+	// uploads and downloads
+	BytesWritten int64 `json:"bytesWritten"`
+	BytesRead    int64 `json:"bytesRead"`
+}
+
+// Total returns the number of successful operations
+func (s Stats) Total() int64 {
+	return s.ReadOps + s.RangeReadOps + s.WriteOps + s.OverwriteOps + s.DeleteOps + s.PrefixDeleteOps +
+		s.MultipartOps + s.TagOps + s.CopyOps + s.ComposeOps + s.StatOps + s.ListOps + s.PresignPutOps + s.PresignGetOps +
+		s.AbortMultipartOps + s.AbandonMultipartOps + s.VersionDeleteOps + s.RetentionOps + s.LegalHoldOps
+}
+
+// Snapshot returns a consistent-per-field copy of the counters
+func (s *Stats) Snapshot() Stats {
+	return Stats{
+		ReadOps:             atomic.LoadInt64(&s.ReadOps),
+		RangeReadOps:        atomic.LoadInt64(&s.RangeReadOps),
+		WriteOps:            atomic.LoadInt64(&s.WriteOps),
+		OverwriteOps:        atomic.LoadInt64(&s.OverwriteOps),
+		DeleteOps:           atomic.LoadInt64(&s.DeleteOps),
+		PrefixDeleteOps:     atomic.LoadInt64(&s.PrefixDeleteOps),
+		MultipartOps:        atomic.LoadInt64(&s.MultipartOps),
+		TagOps:              atomic.LoadInt64(&s.TagOps),
+		CopyOps:             atomic.LoadInt64(&s.CopyOps),
+		ComposeOps:          atomic.LoadInt64(&s.ComposeOps),
+		StatOps:             atomic.LoadInt64(&s.StatOps),
+		ListOps:             atomic.LoadInt64(&s.ListOps),
+		PresignPutOps:       atomic.LoadInt64(&s.PresignPutOps),
+		PresignGetOps:       atomic.LoadInt64(&s.PresignGetOps),
+		AbortMultipartOps:   atomic.LoadInt64(&s.AbortMultipartOps),
+		AbandonMultipartOps: atomic.LoadInt64(&s.AbandonMultipartOps),
+		VersionDeleteOps:    atomic.LoadInt64(&s.VersionDeleteOps),
+		RetentionOps:        atomic.LoadInt64(&s.RetentionOps),
+		LegalHoldOps:        atomic.LoadInt64(&s.LegalHoldOps),
+		RetriedOps:          atomic.LoadInt64(&s.RetriedOps),
+		LockedOps:           atomic.LoadInt64(&s.LockedOps),
+		ErrorOps:            atomic.LoadInt64(&s.ErrorOps),
+		TimeoutOps:          atomic.LoadInt64(&s.TimeoutOps),
+		VerifyFailureOps:    atomic.LoadInt64(&s.VerifyFailureOps),
+		ConsistencyFailures: atomic.LoadInt64(&s.ConsistencyFailures),
+		BytesWritten:        atomic.LoadInt64(&s.BytesWritten),
+		BytesRead:           atomic.LoadInt64(&s.BytesRead),
+	}
+}
+
+// throughput returns the bytes written and read per second over elapsed, in MiB/s
+func (s Stats) throughput(elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(s.BytesWritten+s.BytesRead) / (1024 * 1024) / elapsed.Seconds()
+}
+
+var (
+	config  Config
+	rootCmd = &cobra.Command{
+		Use:   "generate-s3-data",
+		Short: "A tool that generates S3 data by performing random operations",
+		Long: `A tool that generates S3 data by sending random operations (read, write, overwrite, delete, prefix delete, multipart upload) 
+to a MinIO server. Can be used for testing and audit purposes.`,
+		// Execute returns the error for main to print
+		SilenceErrors: true,
+		PreRunE:       prepareRun,
+		Run:           runClient,
+	}
+	benchmarkCmd = &cobra.Command{
+		Use:   "benchmark",
+		Short: "Measure throughput for a fixed time and print a compact summary",
+		Long: `Runs the operations at a fixed concurrency for a fixed duration, without pacing or
+per-operation logs, and prints only the total operations, operations and bytes per second
+and the p50/p99 latency, as text or JSON.`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			applyBenchmarkDefaults(cmd)
+			if err := prepareRun(cmd, args); err != nil {
+				return err
+			}
+			return validateBenchmarkConfig(config)
+		},
+		Run: runBenchmark,
+	}
+)
+
+// prepareRun loads the config file, validates the settings and sets up logging
+// before a command runs
+func prepareRun(cmd *cobra.Command, args []string) error {
+	if config.ConfigFile != "" {
+		if err := loadConfigFile(cmd, config.ConfigFile, &config); err != nil {
+			return err
+		}
+	}
+	if cmd.Flags().Changed("storage-class") && config.StorageClass == "" {
+		return fmt.Errorf("--storage-class must not be empty, omit it to use the server default")
+	}
+	if err := validateConfig(config); err != nil {
+		return err
+	}
+	setupLogging(config)
+	if rateOverridesDelay(cmd, config) {
+		logger.Warn("both --rate and --delay are set; --rate takes precedence and --delay is ignored")
+	}
+	return nil
+}
+
+// rateOverridesDelay reports whether cfg paces with --rate while also setting
+// --delay, on the command line or in the config file
+func rateOverridesDelay(cmd *cobra.Command, cfg Config) bool {
+	if cfg.Rate < 0 {
+		return false
+	}
+	if cmd.Flags().Changed("delay") {
+		return true
+	}
+	defaultDelay, err := time.ParseDuration(cmd.Flags().Lookup("delay").DefValue)
+	return err == nil && cfg.OperationDelay != defaultDelay
+}
+
+// registerFlags binds the command line flags to cfg and declares which
+// flag combinations contradict each other
+func registerFlags(cmd *cobra.Command, cfg *Config) {
+	cmd.Flags().StringVar(&cfg.ConfigFile, "config", "", "Load settings from a YAML or JSON file; command line flags override it")
+	cmd.Flags().StringVarP(&cfg.Endpoint, "endpoint", "e", "localhost:9000", "MinIO server endpoint, or a comma-separated list to spread operations round-robin")
+	cmd.Flags().StringVarP(&cfg.AccessKey, "access-key", "a", "", "MinIO access key")
+	cmd.Flags().StringVarP(&cfg.SecretKey, "secret-key", "s", "", "MinIO secret key")
+	cmd.Flags().StringVarP(&cfg.Buckets, "buckets", "b", "test-bucket", "MinIO bucket names (comma-separated)")
+	cmd.Flags().BoolVar(&cfg.UseSSL, "ssl", false, "Use SSL connection")
+	cmd.Flags().StringVar(&cfg.MCAlias, "alias", "", "Use MC alias instead of access/secret keys")
+	cmd.Flags().StringVar(&cfg.CACert, "ca-cert", "", "PEM file with CA certificates to trust for the server, in addition to the system roots")
+	cmd.Flags().BoolVar(&cfg.Insecure, "insecure", false, "Skip TLS certificate verification (self-signed test setups only)")
+	cmd.Flags().StringVar(&cfg.Proxy, "proxy", "", "Proxy URL (http://, https:// or socks5://) for all requests, overriding HTTP_PROXY, HTTPS_PROXY and NO_PROXY")
+	cmd.Flags().StringVar(&cfg.STSEndpoint, "sts-endpoint", "", "STS endpoint URL to assume a role with the access/secret keys as the caller credentials")
+	cmd.Flags().StringVar(&cfg.RoleARN, "role-arn", "", "ARN of the role to assume via --sts-endpoint")
+	cmd.Flags().DurationVarP(&cfg.Duration, "duration", "d", 0, "Duration to run (0 for infinite)")
+	cmd.Flags().DurationVar(&cfg.OperationDelay, "delay", 1*time.Second, "Delay between operations")
+	cmd.Flags().StringVarP(&cfg.ObjectPrefix, "prefix", "p", "test-object", "Object name prefix")
+	cmd.Flags().IntVar(&cfg.PrefixDepthMin, "prefix-depth-min", defaultPrefixDepthMin, "Minimum number of directory levels in generated prefixes")
+	cmd.Flags().IntVar(&cfg.PrefixDepthMax, "prefix-depth-max", defaultPrefixDepthMax, "Maximum number of directory levels in generated prefixes")
+	cmd.Flags().StringVar(&cfg.PrefixWords, "prefix-words", "", "File with the words of each prefix level, one comma- or space-separated level per line")
+	cmd.Flags().StringVar(&cfg.BucketWeights, "bucket-weights", "", "Relative weights for picking the bucket of new objects, e.g. hot=80,cold=20 (default uniform)")
+	cmd.Flags().StringVar(&cfg.OperationWeights, "operation-weights", "", "Relative weights for picking the operations, e.g. write=3,read=1 (default the registered weight, 1 for the built-in operations)")
+	cmd.Flags().IntVarP(&cfg.Concurrency, "concurrency", "c", 1, "Number of workers running operations in parallel")
+	cmd.Flags().StringVar(&cfg.Operations, "operations", "", operationsUsage())
+	cfg.MultipartSize = 70 * 1024 * 1024
+	cmd.Flags().Var(&cfg.MultipartSize, "multipart-size", "Total object size for multipart uploads (e.g. 70MiB)")
+	cfg.MultipartPartSize = minPartSize
+	cmd.Flags().Var(&cfg.MultipartPartSize, "multipart-part-size", "Part size for multipart uploads, at least 5MiB")
+	cmd.Flags().StringVar(&cfg.ListPrefix, "list-prefix", "", "Prefix listed by the list operation (default the whole bucket)")
+	cmd.Flags().StringVar(&cfg.SourceDir, "source-dir", "", "Upload random files from this directory instead of generated content")
+	cmd.Flags().BoolVar(&cfg.AbandonMultipart, "abandon-multipart", false, "Start multipart uploads and leave them incomplete, for abortmultipart to clean up")
+	cmd.Flags().DurationVar(&cfg.PresignExpiry, "presign-expiry", 15*time.Minute, "Validity of the URLs used by the presignput and presignget operations (1s-7d)")
+	cmd.Flags().BoolVar(&cfg.BatchDelete, "batch-delete", false, "Delete the objects of a prefix delete with multi-object delete requests instead of one by one")
+	cmd.Flags().StringVar(&cfg.Manifest, "manifest", "", "Write a JSON manifest with the effective config, run ID, times and statistics to this file at exit")
+	cmd.Flags().StringVar(&cfg.LogCSV, "log-csv", "", "Append a CSV row per operation (timestamp, operation, bucket, key, size, duration, error) to this file")
+	cmd.Flags().StringVarP(&cfg.Output, "output", "o", outputText, "Statistics output format: text or json")
+	cmd.Flags().StringVar(&cfg.LogLevel, "log-level", "debug", "Lowest level logged: debug (every operation), info (summaries), warn (failures) or error")
+	cmd.Flags().StringVar(&cfg.LogFormat, "log-format", logFormatText, "Log record format: text or json")
+	cmd.Flags().BoolVarP(&cfg.Quiet, "quiet", "q", false, "Don't log each operation, only failures, periodic stats and the final summary")
+	cmd.Flags().StringVar(&cfg.MetricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this address (e.g. :9100), disabled when empty")
+	cmd.Flags().Int64Var(&cfg.MaxObjects, "max-objects", 0, "Stop creating objects once this many exist from this run, net of deletes (0 = no limit)")
+	cmd.Flags().BoolVar(&cfg.Versioned, "versioned", false, "Enable versioning on created buckets and delete specific object versions")
+	cmd.Flags().BoolVar(&cfg.ObjectLock, "object-lock", false, "Create buckets with object locking and run retention and legal hold operations")
+	cmd.Flags().BoolVar(&cfg.BucketObjectLock, "bucket-object-lock", false, "Create buckets with object locking, without running the retention and legal hold operations")
+	cmd.Flags().IntVar(&cfg.LifecycleExpiryDays, "lifecycle-expiry-days", 0, "Install a lifecycle rule expiring objects after this many days on the buckets the tool creates (0 = none)")
+	cmd.Flags().StringVar(&cfg.Region, "region", "", "Region to create buckets in and sign requests for (server default when empty)")
+	cmd.Flags().BoolVar(&cfg.PathStyle, "path-style", false, "Always address buckets in the URL path (http://host/bucket/key) instead of choosing virtual-host style automatically")
+	cmd.Flags().StringVar(&cfg.StorageClass, "storage-class", "", "Storage class of uploaded objects, e.g. REDUCED_REDUNDANCY (server default when empty)")
+	cmd.Flags().StringVar(&cfg.ContentType, "content-type", "", "Content-Type of uploaded objects (guessed from the --source-dir file extension, application/octet-stream otherwise)")
+	cmd.Flags().IntVar(&cfg.MetadataCount, "metadata-count", 0, fmt.Sprintf("Number of random x-amz-meta-* entries attached to each uploaded object (0-%d)", maxMetadataCount))
+	cmd.Flags().StringVar(&cfg.SSE, "sse", "", "Server-side encryption for uploads: s3 (SSE-S3) or c (SSE-C)")
+	cmd.Flags().StringVar(&cfg.SSECKey, "sse-c-key", "", "Base64-encoded 32-byte key for --sse c (random per run when empty)")
+	cmd.Flags().BoolVar(&cfg.SkipHealthCheck, "skip-healthcheck", false, "Start without checking that the endpoint answers S3 requests")
+	cmd.Flags().StringVar(&cfg.RunID, "run-id", "", "ID stored as x-amz-meta-run-id on every object this run creates (default a generated UUID)")
+	cmd.Flags().StringVar(&cfg.CleanupRun, "cleanup-run", "", "Delete the objects whose x-amz-meta-run-id is this ID from the buckets, then exit")
+	cmd.Flags().BoolVar(&cfg.Cleanup, "cleanup", false, "Delete every object with --prefix in its key from the buckets (all versions with --versioned), then exit")
+	cmd.Flags().BoolVar(&cfg.CleanupBuckets, "cleanup-buckets", false, "Remove the buckets that are empty after --cleanup or --cleanup-run")
+	cmd.Flags().BoolVar(&cfg.DryRun, "dry-run", false, "Log the operations that would run without sending any S3 requests")
+	cmd.Flags().BoolVar(&cfg.EndpointStats, "endpoint-stats", false, "Print the operations and errors of each --endpoint entry in the final statistics")
+	cmd.Flags().BoolVar(&cfg.Verify, "verify", false, "Verify the checksum stored by this tool when reading objects")
+	cmd.Flags().BoolVar(&cfg.DiscardReads, "discard-reads", false, "Stream read and range read bodies to a discard sink instead of buffering them, for large-object throughput tests")
+	cmd.Flags().BoolVar(&cfg.ConsistencyCheck, "consistency-check", false, "Read every written object back right away and count missing or different content (doubles the requests)")
+	cmd.Flags().Int64Var(&cfg.Seed, "seed", 0, "Seed for reproducible object names, content and operation order (0 = random)")
+	cmd.Flags().Float64Var(&cfg.MaxErrorRate, "max-error-rate", 0, "Stop with exit code 1 once more than this fraction of operations failed, e.g. 0.5 (0 = never)")
+	cmd.Flags().Int64Var(&cfg.MinSamples, "min-samples", 100, "Operations that must finish before --max-error-rate applies")
+	cmd.Flags().DurationVar(&cfg.OpTimeout, "op-timeout", 0, "Fail an operation attempt that takes longer than this, so a hung request can't stall a worker (0 = no limit)")
+	cmd.Flags().IntVar(&cfg.MaxRetries, "max-retries", 0, "Retry operations failing with network, 5xx or SlowDown errors this many times with exponential backoff")
+	cmd.Flags().Float64Var(&cfg.Rate, "rate", -1, "Operations per second across all workers, overrides --delay (0 = unlimited, negative = pace with --delay)")
+	cmd.Flags().DurationVar(&cfg.Ramp, "ramp", 0, "Grow the --rate, or the number of active workers without one, linearly from zero over this time at start (0 = start at full load)")
+
+	// The alias supplies the endpoint, TLS setting and keys, so explicit values would be silently dropped
+	for _, name := range []string{"endpoint", "access-key", "secret-key", "ssl"} {
+		cmd.MarkFlagsMutuallyExclusive("alias", name)
+	}
+	cmd.MarkFlagsRequiredTogether("access-key", "secret-key")
+	// A custom CA only matters when certificates are verified
+	cmd.MarkFlagsMutuallyExclusive("ca-cert", "insecure")
+	// A cleanup deletes objects of an earlier run and writes none itself
+	for _, name := range []string{"cleanup-run", "cleanup"} {
+		cmd.MarkFlagsMutuallyExclusive(name, "run-id")
+		cmd.MarkFlagsMutuallyExclusive(name, "dry-run")
+	}
+	cmd.MarkFlagsMutuallyExclusive("cleanup-run", "cleanup")
+}
+
+// loadConfigFile merges the YAML or JSON file at path into cfg. Flags given on
+// the command line keep their values, and unknown keys are rejected.
+func loadConfigFile(cmd *cobra.Command, path string, cfg *Config) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+		return fmt.Errorf("unsupported config file %s, expected a .yaml, .yml or .json extension", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	// Remember the command line values so they can be restored over the file values
+	overrides := make(map[string]string)
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		overrides[f.Name] = f.Value.String()
+	})
+
+	// JSON is valid YAML, so one decoder handles both formats and durations
+	// and sizes can be written as "5m" and "70MiB" in either
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+		return fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+
+	for name, value := range overrides {
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return fmt.Errorf("failed to apply --%s over config file: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// redactConfig returns cfg with the secret key and SSE-C key replaced, for
+// showing or archiving the settings
+func redactConfig(cfg Config) Config {
+	for _, secret := range []*string{&cfg.SecretKey, &cfg.SSECKey} {
+		if *secret != "" {
+			*secret = "REDACTED"
+		}
+	}
+	return cfg
+}
+
+// printEffectiveConfig shows the merged file and flag settings with secrets redacted
+func printEffectiveConfig(cfg Config) {
+	cfg = redactConfig(cfg)
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		logger.Error("failed to encode the effective configuration", "error", err)
+		return
+	}
+	logger.Info("effective configuration", "file", cfg.ConfigFile, "config", string(data))
+}
+
+// validateConfig rejects flag values that can't produce a sensible run
+func validateConfig(cfg Config) error {
+	if cfg.Duration < 0 {
+		return fmt.Errorf("--duration must not be negative, got %v", cfg.Duration)
+	}
+	if cfg.OperationDelay <= 0 {
+		return fmt.Errorf("--delay must be greater than zero, got %v", cfg.OperationDelay)
+	}
+	if cfg.Concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1, got %d", cfg.Concurrency)
+	}
+	if cfg.Ramp < 0 {
+		return fmt.Errorf("--ramp must not be negative, got %v", cfg.Ramp)
+	}
+	if cfg.Ramp > 0 && cfg.Duration > 0 && cfg.Ramp >= cfg.Duration {
+		return fmt.Errorf("--ramp %v must be shorter than --duration %v", cfg.Ramp, cfg.Duration)
+	}
+	if cfg.Ramp > 0 && cfg.Rate <= 0 && cfg.Concurrency == 1 {
+		return fmt.Errorf("--ramp needs --rate or a --concurrency above 1, a single worker without a rate has nothing to ramp")
+	}
+	if strings.TrimSpace(cfg.ObjectPrefix) == "" {
+		return fmt.Errorf("--prefix must not be empty")
+	}
+	if cfg.PrefixDepthMin < 1 || cfg.PrefixDepthMin > cfg.PrefixDepthMax || cfg.PrefixDepthMax > maxPrefixDepth {
+		return fmt.Errorf("--prefix-depth-min (%d) and --prefix-depth-max (%d) must satisfy 1 <= min <= max <= %d", cfg.PrefixDepthMin, cfg.PrefixDepthMax, maxPrefixDepth)
+	}
+	if cfg.PrefixWords != "" {
+		if _, err := loadPrefixWords(cfg.PrefixWords); err != nil {
+			return fmt.Errorf("invalid --prefix-words: %v", err)
+		}
+	}
+	if len((&MinioClient{config: cfg}).Buckets()) == 0 {
+		return fmt.Errorf("--buckets must name at least one bucket")
+	}
+	if cfg.MCAlias == "" && len(parseList(cfg.Endpoint)) == 0 {
+		return fmt.Errorf("--endpoint must name at least one endpoint")
+	}
+	if cfg.STSEndpoint != "" {
+		if u, err := url.Parse(cfg.STSEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("--sts-endpoint must be an http:// or https:// URL, got %q", cfg.STSEndpoint)
+		}
+	}
+	if cfg.Proxy != "" {
+		if u, err := url.Parse(cfg.Proxy); err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+			return fmt.Errorf("--proxy must be an http://, https:// or socks5:// URL, got %q", cfg.Proxy)
+		}
+	}
+	if cfg.RoleARN != "" && cfg.STSEndpoint == "" {
+		return fmt.Errorf("--role-arn requires --sts-endpoint")
+	}
+	if cfg.BucketWeights != "" {
+		if _, err := parseBucketWeights(cfg.BucketWeights, parseList(cfg.Buckets)); err != nil {
+			return fmt.Errorf("invalid --bucket-weights: %v", err)
+		}
+	}
+	if cfg.MultipartPartSize < minPartSize || cfg.MultipartPartSize > maxPartSize {
+		return fmt.Errorf("--multipart-part-size must be between %s and %s, got %s", ByteSize(minPartSize), ByteSize(maxPartSize), cfg.MultipartPartSize)
+	}
+	if cfg.MultipartSize <= cfg.MultipartPartSize {
+		return fmt.Errorf("--multipart-size (%s) must be larger than --multipart-part-size (%s) to upload more than one part", cfg.MultipartSize, cfg.MultipartPartSize)
+	}
+	if parts := (cfg.MultipartSize + cfg.MultipartPartSize - 1) / cfg.MultipartPartSize; parts > maxParts {
+		return fmt.Errorf("--multipart-size %s with --multipart-part-size %s needs %d parts, more than the %d part limit", cfg.MultipartSize, cfg.MultipartPartSize, parts, maxParts)
+	}
+	if cfg.DryRun && cfg.Verify {
+		return fmt.Errorf("--dry-run and --verify cannot be used together, a dry run reads nothing to verify")
+	}
+	if cfg.DryRun && cfg.ConsistencyCheck {
+		return fmt.Errorf("--dry-run and --consistency-check cannot be used together, a dry run writes nothing to read back")
+	}
+	if cfg.SourceDir != "" {
+		if info, err := os.Stat(cfg.SourceDir); err != nil {
+			return fmt.Errorf("invalid --source-dir: %v", err)
+		} else if !info.IsDir() {
+			return fmt.Errorf("--source-dir %s is not a directory", cfg.SourceDir)
+		}
+	}
+	if cfg.PresignExpiry < time.Second || cfg.PresignExpiry > maxPresignExpiry {
+		return fmt.Errorf("--presign-expiry must be between 1s and %v, got %v", maxPresignExpiry, cfg.PresignExpiry)
+	}
+	if cfg.MaxErrorRate < 0 || cfg.MaxErrorRate >= 1 {
+		return fmt.Errorf("--max-error-rate must be at least 0 and below 1, got %v", cfg.MaxErrorRate)
+	}
+	if cfg.MinSamples < 1 {
+		return fmt.Errorf("--min-samples must be at least 1, got %d", cfg.MinSamples)
+	}
+	if cfg.Manifest != "" {
+		// Fail now rather than after a long run
+		if info, err := os.Stat(filepath.Dir(cfg.Manifest)); err != nil || !info.IsDir() {
+			return fmt.Errorf("--manifest %s must be in an existing directory", cfg.Manifest)
+		}
+	}
+	if cfg.OpTimeout < 0 {
+		return fmt.Errorf("--op-timeout must not be negative, got %v", cfg.OpTimeout)
+	}
+	if cfg.MaxRetries < 0 {
+		return fmt.Errorf("--max-retries must not be negative, got %d", cfg.MaxRetries)
+	}
+	if cfg.MaxObjects < 0 {
+		return fmt.Errorf("--max-objects must not be negative, got %d", cfg.MaxObjects)
+	}
+	if cfg.StorageClass != "" && strings.TrimSpace(cfg.StorageClass) != cfg.StorageClass {
+		return fmt.Errorf("--storage-class must not have leading or trailing spaces, got %q", cfg.StorageClass)
+	}
+	if cfg.ContentType != "" {
+		if _, _, err := mime.ParseMediaType(cfg.ContentType); err != nil {
+			return fmt.Errorf("invalid --content-type %q: %v", cfg.ContentType, err)
+		}
+	}
+	for _, id := range []struct{ flag, value string }{{"--run-id", cfg.RunID}, {"--cleanup-run", cfg.CleanupRun}} {
+		if id.value != "" && !validRunID(id.value) {
+			return fmt.Errorf("%s must be at most %d letters, digits, '.', '_' or '-', got %q", id.flag, maxRunIDLength, id.value)
+		}
+	}
+	if cfg.CleanupBuckets && !cfg.Cleanup && cfg.CleanupRun == "" {
+		return fmt.Errorf("--cleanup-buckets requires --cleanup or --cleanup-run")
+	}
+	if cfg.LifecycleExpiryDays < 0 {
+		return fmt.Errorf("--lifecycle-expiry-days must not be negative, got %d", cfg.LifecycleExpiryDays)
+	}
+	if cfg.MetadataCount < 0 || cfg.MetadataCount > maxMetadataCount {
+		return fmt.Errorf("--metadata-count must be between 0 and %d, got %d", maxMetadataCount, cfg.MetadataCount)
+	}
+	if cfg.SSE != "" && cfg.SSE != sseS3 && cfg.SSE != sseC {
+		return fmt.Errorf("--sse must be %s or %s, got %q", sseS3, sseC, cfg.SSE)
+	}
+	if cfg.SSECKey != "" {
+		if cfg.SSE != sseC {
+			return fmt.Errorf("--sse-c-key requires --sse %s", sseC)
+		}
+		if _, err := decodeSSECKey(cfg.SSECKey); err != nil {
+			return err
+		}
+	}
+	if cfg.Output != outputText && cfg.Output != outputJSON {
+		return fmt.Errorf("--output must be %s or %s, got %q", outputText, outputJSON, cfg.Output)
+	}
+	if _, ok := logLevels[cfg.LogLevel]; !ok {
+		return fmt.Errorf("--log-level must be debug, info, warn or error, got %q", cfg.LogLevel)
+	}
+	if cfg.LogFormat != logFormatText && cfg.LogFormat != logFormatJSON {
+		return fmt.Errorf("--log-format must be %s or %s, got %q", logFormatText, logFormatJSON, cfg.LogFormat)
+	}
+	operations, err := selectOperations(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid --operations: %v", err)
+	}
+	if _, err := parseOperationWeights(cfg.OperationWeights, operations); err != nil {
+		return fmt.Errorf("invalid --operation-weights: %v", err)
+	}
+	return nil
+}
+
+// Execute parses the command line and runs the requested command
+func Execute() error {
+	// Operations registered by an embedding program come after the flags
+	for _, cmd := range []*cobra.Command{rootCmd, benchmarkCmd} {
+		cmd.Flags().Lookup("operations").Usage = operationsUsage()
+	}
+	return rootCmd.Execute()
+}
+
+// setupMinioClient creates the client for the run described by config and
+// loads everything the operations need, exiting on failure
+func setupMinioClient() *MinioClient {
+	clients, err := initializeMinioClient()
+	if err != nil {
+		fatal("failed to initialize MinIO client", err)
+	}
+	if config.RunID == "" && config.CleanupRun == "" && !config.Cleanup {
+		config.RunID = uuid.NewString()
+	}
+
+	minioClient := &MinioClient{
+		client:    clients[0],
+		clients:   clients,
+		endpoints: parseList(config.Endpoint),
+		config:    config,
+		stats:     &Stats{},
+		latency:   newLatencyRecorder(),
+		outcomes:  newOutcomeRecorder(),
+		buckets:   newBucketCache(),
+	}
+	if config.EndpointStats {
+		minioClient.endpointStats = make([]endpointCounters, len(clients))
+	}
+	if config.Seed != 0 {
+		minioClient.random = newSeededReader(config.Seed)
+	}
+	if config.PrefixWords != "" {
+		words, err := loadPrefixWords(config.PrefixWords)
+		if err != nil {
+			fatal("failed to load --prefix-words", err)
+		}
+		minioClient.prefixWords = words
+	}
+	if config.SourceDir != "" {
+		files, err := loadSourceFiles(config.SourceDir)
+		if err != nil {
+			fatal("failed to load --source-dir", err)
+		}
+		minioClient.sourceFiles = files
+		logger.Info("loaded source files", "count", len(files), "dir", config.SourceDir)
+	}
+
+	if config.SSE != "" {
+		sse, key, err := newServerSideEncryption(config)
+		if err != nil {
+			fatal("failed to set up server-side encryption", err)
+		}
+		minioClient.sse = sse
+		if config.SSE == sseC && config.SSECKey == "" {
+			// Print the generated key so the objects can be read back later
+			logger.Info("generated SSE-C key", "key", base64.StdEncoding.EncodeToString(key))
+		}
+	}
+
+	if config.LogCSV != "" {
+		operationLog, err := openOperationLog(config.LogCSV)
+		if err != nil {
+			fatal("failed to open --log-csv", err)
+		}
+		minioClient.operationLog = operationLog
+	}
+	return minioClient
+}
+
+// interruptContext returns a context cancelled by the first SIGINT or SIGTERM,
+// so the workers stop and the final statistics still print; a second signal
+// force-exits. stop releases the signal handling.
+func interruptContext() (ctx context.Context, stop func()) {
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-signalCtx.Done():
+			logger.Info("shutting down, waiting for in-flight operations (press Ctrl+C again to force exit)")
+			// Restore default signal handling so the next signal terminates the process
+			stopSignals()
+		case <-finished:
+		}
+	}()
+	return signalCtx, func() {
+		close(finished)
+		stopSignals()
+	}
+}
+
+func runClient(cmd *cobra.Command, args []string) {
+	minioClient := setupMinioClient()
+
+	if !config.DryRun && !config.SkipHealthCheck {
+		if err := minioClient.healthCheck(); err != nil {
+			fatal("health check failed", err)
+		}
+	}
+
+	if config.CleanupRun != "" || config.Cleanup {
+		minioClient.runCleanup()
+		return
+	}
+
+	// Ensure bucket exists
+	if config.DryRun {
+		logger.Info("dry run, would create any of these buckets that are missing", "buckets", strings.Join(minioClient.Buckets(), ","))
+	} else if err := minioClient.ensureBucket(); err != nil {
+		fatal("failed to ensure bucket exists", err)
+	}
+
+	if config.ConfigFile != "" {
+		printEffectiveConfig(config)
+	}
+	attrs := []any{"endpoint", config.Endpoint, "buckets", config.Buckets, "runId", config.RunID}
+	if config.Region != "" {
+		attrs = append(attrs, "region", config.Region)
+	}
+	if config.BucketWeights != "" {
+		attrs = append(attrs, "bucketWeights", config.BucketWeights)
+	}
+	if config.OperationWeights != "" {
+		attrs = append(attrs, "operationWeights", config.OperationWeights)
+	}
+	if config.Duration > 0 {
+		attrs = append(attrs, "duration", config.Duration.String())
+	} else {
+		attrs = append(attrs, "duration", "infinite")
+	}
+	switch {
+	case config.Rate > 0:
+		attrs = append(attrs, "rate", config.Rate)
+	case config.Rate == 0:
+		attrs = append(attrs, "rate", "unlimited")
+	default:
+		attrs = append(attrs, "delay", config.OperationDelay.String())
+	}
+	attrs = append(attrs, "concurrency", config.Concurrency)
+	if config.Ramp > 0 {
+		attrs = append(attrs, "ramp", config.Ramp.String())
+	}
+	if config.MaxObjects > 0 {
+		attrs = append(attrs, "maxObjects", config.MaxObjects)
+	}
+	if config.Seed != 0 {
+		attrs = append(attrs, "seed", config.Seed)
+	}
+	if config.DryRun {
+		attrs = append(attrs, "dryRun", true)
+	}
+	if config.SSE != "" {
+		attrs = append(attrs, "encryption", "SSE-"+strings.ToUpper(config.SSE))
+	}
+	if config.LogCSV != "" {
+		attrs = append(attrs, "operationLog", config.LogCSV)
+	}
+	logger.Info("starting S3 data generator, press Ctrl+C to stop", attrs...)
+
+	ctx, stop := interruptContext()
+	defer stop()
+	if config.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Duration)
+		defer cancel()
+	}
+
+	var metricsDone <-chan struct{}
+	if config.MetricsAddr != "" {
+		addr, done, err := minioClient.startMetricsServer(ctx, config.MetricsAddr)
+		if err != nil {
+			fatal("failed to start metrics server", err)
+		}
+		logger.Info("serving metrics", "url", fmt.Sprintf("http://%s/metrics", addr))
+		metricsDone = done
+	}
+
+	// Start stats printer in background
+	minioClient.startTime = time.Now()
+	go minioClient.printStats(ctx)
+
+	// Run operations
+	minioClient.runOperations(ctx)
+
+	// The metrics server stops with ctx; wait for it before exiting
+	if metricsDone != nil {
+		<-metricsDone
+	}
+
+	if minioClient.operationLog != nil {
+		if err := minioClient.operationLog.Close(); err != nil {
+			logger.Error("failed to write --log-csv", "error", err)
+		}
+	}
+
+	// Print final stats
+	minioClient.printFinalStats()
+	if config.Manifest != "" {
+		if err := minioClient.writeManifest(config.Manifest, time.Now()); err != nil {
+			logger.Error("failed to write --manifest", "error", err)
+		} else {
+			logger.Info("wrote run manifest", "file", config.Manifest)
+		}
+	}
+
+	// A non-zero exit lets CI fail the job on an unhealthy deployment
+	if minioClient.aborted.Load() {
+		fmt.Fprintf(os.Stderr, "Error: stopped early, error rate exceeded --max-error-rate %v\n", config.MaxErrorRate)
+		os.Exit(1)
+	}
+}
+
+// benchmarkDefaults replace the defaults of these flags for the benchmark
+// command, which measures throughput instead of generating a dataset
+var benchmarkDefaults = map[string]string{
+	"duration":    "30s",
+	"rate":        "0",
+	"concurrency": "8",
+	"quiet":       "true",
+}
+
+// applyBenchmarkDefaults sets the benchmark defaults of the flags not given on
+// the command line, before a config file is merged over them
+func applyBenchmarkDefaults(cmd *cobra.Command) {
+	for name, value := range benchmarkDefaults {
+		if f := cmd.Flags().Lookup(name); f != nil && !f.Changed {
+			// Set on the value directly so the flag still counts as unset
+			if err := f.Value.Set(value); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// validateBenchmarkConfig rejects the settings the benchmark command can't
+// honor on top of validateConfig
+func validateBenchmarkConfig(cfg Config) error {
+	if cfg.Duration <= 0 {
+		return fmt.Errorf("benchmark needs a --duration greater than zero, got %v", cfg.Duration)
+	}
+	for _, flag := range []struct {
+		name string
+		set  bool
+	}{
+		{"--dry-run", cfg.DryRun},
+		{"--cleanup", cfg.Cleanup},
+		{"--cleanup-run", cfg.CleanupRun != ""},
+		{"--metrics-addr", cfg.MetricsAddr != ""},
+		{"--manifest", cfg.Manifest != ""},
+	} {
+		if flag.set {
+			return fmt.Errorf("%s can't be used with benchmark", flag.name)
+		}
+	}
+	return nil
+}
+
+// runBenchmark runs the operations for --duration and prints the benchmark
+// summary instead of the periodic and final statistics
+func runBenchmark(cmd *cobra.Command, args []string) {
+	minioClient := setupMinioClient()
+	if !config.SkipHealthCheck {
+		if err := minioClient.healthCheck(); err != nil {
+			fatal("health check failed", err)
+		}
+	}
+	if err := minioClient.ensureBucket(); err != nil {
+		fatal("failed to ensure bucket exists", err)
+	}
+
+	logger.Info("starting benchmark", "endpoint", config.Endpoint, "buckets", config.Buckets, "runId", config.RunID,
+		"duration", config.Duration.String(), "concurrency", config.Concurrency)
+	ctx, stop := interruptContext()
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, config.Duration)
+	defer cancel()
+
+	minioClient.startTime = time.Now()
+	minioClient.runOperations(ctx)
+	elapsed := time.Since(minioClient.startTime)
+
+	if minioClient.operationLog != nil {
+		if err := minioClient.operationLog.Close(); err != nil {
+			logger.Error("failed to write --log-csv", "error", err)
+		}
+	}
+	minioClient.benchmarkReport(elapsed).print(config.Output)
+}
+
+// credentialEnvVars are the access/secret key variable pairs checked, in order,
+// when no keys are given as flags
+var credentialEnvVars = [][2]string{
+	{"MINIO_ACCESS_KEY", "MINIO_SECRET_KEY"},
+	{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"},
+}
+
+// credentialsFromEnv returns the first complete key pair found in the
+// environment and the name of its access key variable
+func credentialsFromEnv() (accessKey, secretKey, source string) {
+	for _, vars := range credentialEnvVars {
+		accessKey, secretKey = os.Getenv(vars[0]), os.Getenv(vars[1])
+		if accessKey != "" && secretKey != "" {
+			return accessKey, secretKey, vars[0]
+		}
+	}
+	return "", "", ""
+}
+
+// initializeMinioClient returns one client per --endpoint entry, in order,
+// sharing the credentials and transport. An alias names a single endpoint.
+func initializeMinioClient() ([]*minio.Client, error) {
+	var creds *credentials.Credentials
+
+	// Keys come from the flags, then the environment, then the alias
+	if config.AccessKey == "" && config.SecretKey == "" {
+		if accessKey, secretKey, source := credentialsFromEnv(); source != "" {
+			config.AccessKey = accessKey
+			config.SecretKey = secretKey
+			logger.Info("using credentials from the environment", "source", source)
+		}
+	}
+
+	if config.MCAlias != "" {
+		// Try to use MC alias (read from ~/.mc/config.json)
+		mcConfig, err := readMCConfig(config.MCAlias)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MC alias '%s': %v", config.MCAlias, err)
+		}
+		config.Endpoint = mcConfig.URL
+		if config.AccessKey == "" && config.SecretKey == "" {
+			config.AccessKey = mcConfig.AccessKey
+			config.SecretKey = mcConfig.SecretKey
+		}
+		config.UseSSL = strings.HasPrefix(mcConfig.URL, "https://")
+
+		// Remove protocol from endpoint
+		config.Endpoint = strings.TrimPrefix(config.Endpoint, "http://")
+		config.Endpoint = strings.TrimPrefix(config.Endpoint, "https://")
+	}
+
+	if config.AccessKey == "" || config.SecretKey == "" {
+		return nil, fmt.Errorf("either provide access-key and secret-key, set MINIO_ACCESS_KEY and MINIO_SECRET_KEY, or use alias")
+	}
+
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	httpClient = &http.Client{Transport: transport}
+
+	if config.STSEndpoint != "" {
+		// The keys identify the caller of AssumeRole, the requests use the
+		// temporary credentials it returns
+		creds = credentials.New(&credentials.STSAssumeRole{
+			Client:      httpClient,
+			STSEndpoint: config.STSEndpoint,
+			Options: credentials.STSAssumeRoleOptions{
+				AccessKey:       config.AccessKey,
+				SecretKey:       config.SecretKey,
+				RoleARN:         config.RoleARN,
+				RoleSessionName: "generate-s3-data",
+				Location:        config.Region,
+			},
+		})
+		if !config.DryRun {
+			if _, err := creds.Get(); err != nil {
+				return nil, fmt.Errorf("failed to assume role via %s: %v", config.STSEndpoint, err)
+			}
+		}
+		logger.Info("using temporary credentials from STS", "endpoint", config.STSEndpoint)
+	} else {
+		creds = credentials.NewStaticV4(config.AccessKey, config.SecretKey, "")
+	}
+
+	options := &minio.Options{
+		Creds:     creds,
+		Secure:    config.UseSSL,
+		Region:    config.Region,
+		Transport: transport,
+	}
+	if config.PathStyle {
+		options.BucketLookup = minio.BucketLookupPath
+	}
+	var clients []*minio.Client
+	for _, endpoint := range parseList(config.Endpoint) {
+		client, err := minio.New(endpoint, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create MinIO client for %s: %v", endpoint, err)
+		}
+		clients = append(clients, client)
+	}
+
+	return clients, nil
+}
+
+// newTransport returns the HTTP transport shared by all requests: the minio
+// defaults with the proxy from --proxy or the environment, verifying
+// certificates against the system roots plus --ca-cert unless --insecure
+func newTransport(cfg Config) (*http.Transport, error) {
+	// Start from the minio defaults so only the proxy and certificate checks change
+	transport, err := minio.DefaultTransport(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP transport: %v", err)
+	}
+	transport.Proxy = http.ProxyFromEnvironment
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --proxy: %v", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CACert == "" && !cfg.Insecure {
+		return transport, nil
+	}
+	if cfg.Insecure {
+		transport.TLSClientConfig.InsecureSkipVerify = true
+		return transport, nil
+	}
+
+	pem, err := os.ReadFile(cfg.CACert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --ca-cert: %v", err)
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("--ca-cert %s contains no PEM certificates", cfg.CACert)
+	}
+	transport.TLSClientConfig.RootCAs = roots
+	return transport, nil
+}
+
+type MCConfig struct {
+	URL       string `json:"url"`
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+	API       string `json:"api"`
+	Path      string `json:"path"`
+}
+
+type MCConfigFile struct {
+	Version string               `json:"version"`
+	Aliases map[string]*MCConfig `json:"aliases"`
+}
+
+func readMCConfig(alias string) (*MCConfig, error) {
+	// Get user's home directory
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %v", err)
+	}
+
+	// Path to MC config file
+	mcConfigPath := filepath.Join(homeDir, ".mc", "config.json")
+
+	// Check if config file exists
+	if _, err := os.Stat(mcConfigPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("MC config file not found at %s. Run 'mc alias set %s <url> <access-key> <secret-key>' first", mcConfigPath, alias)
+	}
+
+	// Read the config file
+	configData, err := os.ReadFile(mcConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MC config file: %v", err)
+	}
+
+	// Parse JSON
+	var mcConfigFile MCConfigFile
+	if err := json.Unmarshal(configData, &mcConfigFile); err != nil {
+		return nil, fmt.Errorf("failed to parse MC config JSON: %v", err)
+	}
+
+	// Find the alias
+	aliasConfig, exists := mcConfigFile.Aliases[alias]
+	if !exists {
+		return nil, fmt.Errorf("alias '%s' not found in MC config. Available aliases: %v", alias, getAvailableAliases(mcConfigFile.Aliases))
+	}
+
+	// Validate required fields
+	if aliasConfig.URL == "" || aliasConfig.AccessKey == "" || aliasConfig.SecretKey == "" {
+		return nil, fmt.Errorf("alias '%s' has incomplete configuration (missing URL, access key, or secret key)", alias)
+	}
+
+	return aliasConfig, nil
+}
+
+func getAvailableAliases(aliases map[string]*MCConfig) []string {
+	var keys []string
+	for k := range aliases {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// bucketObjectLock reports whether created buckets get object locking
+func (m *MinioClient) bucketObjectLock() bool {
+	return m.config.ObjectLock || m.config.BucketObjectLock
+}
+
+// healthCheckTimeout bounds the pre-flight probe, which the client would
+// otherwise retry for a long time against an unreachable endpoint
+const healthCheckTimeout = 10 * time.Second
+
+// healthCheck checks that every endpoint answers an authenticated S3 request,
+// so a wrong endpoint, TLS setting or key fails before any operation runs. A
+// missing bucket is fine, ensureBucket creates it.
+func (m *MinioClient) healthCheck() error {
+	buckets := m.Buckets()
+	if len(buckets) == 0 {
+		return fmt.Errorf("no buckets configured")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	for i := range m.clients {
+		if _, err := m.bucketExists(ctx, i, buckets[0]); err != nil {
+			tls := "disabled"
+			if m.config.UseSSL {
+				tls = "enabled"
+			}
+			return fmt.Errorf("endpoint %s (TLS %s) did not answer a bucket check on '%s': %w", m.endpoints[i], tls, buckets[0], err)
+		}
+	}
+	return nil
+}
+
+// runCleanup deletes the objects selected by --cleanup-run or --cleanup, then
+// the empty buckets with --cleanup-buckets, and exits with code 1 when
+// something could not be deleted. Ctrl+C stops it.
+func (m *MinioClient) runCleanup() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	attrs := []any{"buckets", strings.Join(m.Buckets(), ","), "prefix", m.config.ObjectPrefix}
+	var removed, failed int64
+	var err error
+	if m.config.CleanupRun != "" {
+		logger.Info("deleting the objects of a run", append(attrs, "runId", m.config.CleanupRun)...)
+		removed, failed, err = m.cleanupRun(ctx, m.config.CleanupRun)
+	} else {
+		logger.Info("deleting the objects of the prefix", append(attrs, "versions", m.versionedBuckets())...)
+		removed, failed, err = m.cleanupPrefix(ctx)
+	}
+	logger.Info("cleanup finished", "removed", removed, "failed", failed)
+	if err != nil {
+		fatal("cleanup stopped", err)
+	}
+
+	if m.config.CleanupBuckets {
+		failed += m.removeEmptyBuckets(ctx)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// versionedBuckets reports whether the buckets keep object versions, so
+// deleting an object leaves its versions behind
+func (m *MinioClient) versionedBuckets() bool {
+	return m.config.Versioned || m.config.ObjectLock || m.config.BucketObjectLock
+}
+
+// cleanupPrefix deletes every object with --prefix in its key from the
+// configured buckets with multi-object delete requests, every version and
+// delete marker of them for versioned buckets, and aborts their incomplete
+// multipart uploads. Objects without the prefix are never touched.
+func (m *MinioClient) cleanupPrefix(ctx context.Context) (removed, failed int64, err error) {
+	var objects []ObjectInfo
+	if m.versionedBuckets() {
+		objects, err = m.listObjectVersions(ctx)
+	} else {
+		objects, err = m.listObjects(ctx)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	byBucket := make(map[string][]ObjectInfo)
+	for _, object := range objects {
+		byBucket[object.Bucket] = append(byBucket[object.Bucket], object)
+	}
+	for _, bucket := range m.Buckets() {
+		objectsCh := make(chan minio.ObjectInfo)
+		go func() {
+			defer close(objectsCh)
+			for _, object := range byBucket[bucket] {
+				select {
+				case objectsCh <- minio.ObjectInfo{Key: object.Key, VersionID: object.VersionID}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		bucketFailed := 0
+		for removeErr := range m.client.RemoveObjects(ctx, bucket, objectsCh, minio.RemoveObjectsOptions{}) {
+			bucketFailed++
+			logger.Warn("failed to delete object", "bucket", bucket, "key", removeErr.ObjectName, "versionId", removeErr.VersionID, "error", removeErr.Err)
+		}
+		if ctx.Err() != nil {
+			return removed, failed, ctx.Err()
+		}
+		removed += int64(len(byBucket[bucket]) - bucketFailed)
+		failed += int64(bucketFailed)
+		operationLogger.Debug("deleted objects", "bucket", bucket, "count", len(byBucket[bucket])-bucketFailed)
+
+		uploads, err := m.listIncompleteUploads(ctx, bucket)
+		if err != nil {
+			return removed, failed, fmt.Errorf("failed to list incomplete uploads in '%s': %w", bucket, err)
+		}
+		for _, upload := range uploads {
+			if err := m.client.RemoveIncompleteUpload(ctx, bucket, upload.Key); err != nil {
+				logger.Warn("failed to abort incomplete upload", "bucket", bucket, "key", upload.Key, "error", err)
+				failed++
+				continue
+			}
+			removed++
+			operationLogger.Debug("aborted incomplete upload", "bucket", bucket, "key", upload.Key)
+		}
+	}
+	return removed, failed, nil
+}
+
+// removeEmptyBuckets removes the configured buckets and returns how many
+// could not be removed for another reason than holding objects, which keeps
+// them on purpose
+func (m *MinioClient) removeEmptyBuckets(ctx context.Context) (failed int64) {
+	for _, bucket := range m.Buckets() {
+		err := m.client.RemoveBucket(ctx, bucket)
+		m.buckets.Invalidate(bucket)
+		switch {
+		case err == nil:
+			logger.Info("removed bucket", "bucket", bucket)
+		case minio.ToErrorResponse(err).Code == "BucketNotEmpty":
+			logger.Info("kept bucket that still holds objects", "bucket", bucket)
+		case minio.ToErrorResponse(err).Code == "NoSuchBucket":
+		default:
+			logger.Warn("failed to remove bucket", "bucket", bucket, "error", err)
+			failed++
+		}
+	}
+	return failed
+}
+
+// cleanupRun deletes every version of the objects in the configured buckets
+// whose run-id metadata is id. Listings don't carry user metadata, so each
+// version with the --prefix in its key is checked with a HEAD request first.
+// Delete markers carry no metadata and are left alone.
+func (m *MinioClient) cleanupRun(ctx context.Context, id string) (removed, failed int64, err error) {
+	for _, bucket := range m.Buckets() {
+		for object := range m.client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Recursive: true, WithVersions: true}) {
+			if object.Err != nil {
+				return removed, failed, fmt.Errorf("failed to list bucket '%s': %w", bucket, object.Err)
+			}
+			if object.IsDeleteMarker || !strings.Contains(object.Key, m.config.ObjectPrefix) {
+				continue
+			}
+
+			info, err := m.client.StatObject(ctx, bucket, object.Key, minio.StatObjectOptions{
+				VersionID:            object.VersionID,
+				ServerSideEncryption: m.readEncryption(),
+			})
+			if err != nil {
+				if ctx.Err() != nil {
+					return removed, failed, ctx.Err()
+				}
+				logger.Warn("failed to check the run ID", "bucket", bucket, "key", object.Key, "versionId", object.VersionID, "error", err)
+				failed++
+				continue
+			}
+			if runIDOf(info.UserMetadata) != id {
+				continue
+			}
+
+			if err := m.client.RemoveObject(ctx, bucket, object.Key, minio.RemoveObjectOptions{VersionID: object.VersionID}); err != nil {
+				if ctx.Err() != nil {
+					return removed, failed, ctx.Err()
+				}
+				logger.Warn("failed to delete object", "bucket", bucket, "key", object.Key, "versionId", object.VersionID, "error", err)
+				failed++
+				continue
+			}
+			removed++
+			operationLogger.Debug("deleted object", "bucket", bucket, "key", object.Key, "versionId", object.VersionID)
+		}
+	}
+	return removed, failed, nil
+}
+
+// endpointCounters counts the operation attempts sent to one endpoint, updated
+// atomically
+type endpointCounters struct {
+	Ops    int64
+	Errors int64
+}
+
+// endpointKey is the context key holding the index of the client chosen for
+// an operation attempt
+type endpointKey struct{}
+
+// withNextEndpoint binds ctx to the next client in round-robin order and
+// returns its index. With a single endpoint ctx is returned as is and the
+// index is 0.
+func (m *MinioClient) withNextEndpoint(ctx context.Context) (context.Context, int) {
+	if len(m.clients) < 2 {
+		return ctx, 0
+	}
+	i := int((m.nextEndpoint.Add(1) - 1) % uint64(len(m.clients)))
+	return context.WithValue(ctx, endpointKey{}, i), i
+}
+
+// ClientFor returns the client chosen for the operation running with ctx, or
+// the first endpoint's client when none was chosen
+func (m *MinioClient) ClientFor(ctx context.Context) *minio.Client {
+	if i, ok := ctx.Value(endpointKey{}).(int); ok {
+		return m.clients[i]
+	}
+	return m.client
+}
+
+// countEndpointAttempt records the outcome of an attempt on endpoint i with
+// --endpoint-stats. Attempts cut short by shutdown are not counted.
+func (m *MinioClient) countEndpointAttempt(ctx context.Context, i int, err error) {
+	if m.endpointStats == nil || ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		atomic.AddInt64(&m.endpointStats[i].Errors, 1)
+	} else {
+		atomic.AddInt64(&m.endpointStats[i].Ops, 1)
+	}
+}
+
+// bucketCacheKey identifies a bucket as seen by one endpoint
+type bucketCacheKey struct {
+	endpoint string
+	bucket   string
+}
+
+// bucketCache remembers whether each endpoint reported a bucket as existing,
+// so repeated checks skip the HEAD bucket request. It is safe for concurrent
+// use and a nil cache remembers nothing.
+type bucketCache struct {
+	mu     sync.Mutex
+	exists map[bucketCacheKey]bool
+}
+
+func newBucketCache() *bucketCache {
+	return &bucketCache{exists: make(map[bucketCacheKey]bool)}
+}
+
+// Lookup returns the cached answer for bucket on endpoint, ok is false when
+// it was never checked
+func (c *bucketCache) Lookup(endpoint, bucket string) (exists, ok bool) {
+	if c == nil {
+		return false, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	exists, ok = c.exists[bucketCacheKey{endpoint, bucket}]
+	return exists, ok
+}
+
+// Store caches the answer of endpoint for bucket
+func (c *bucketCache) Store(endpoint, bucket string, exists bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exists[bucketCacheKey{endpoint, bucket}] = exists
+}
+
+// Invalidate forgets the answers of every endpoint for bucket, after it was
+// created or removed
+func (c *bucketCache) Invalidate(bucket string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.exists {
+		if key.bucket == bucket {
+			delete(c.exists, key)
+		}
+	}
+}
+
+// bucketExists reports whether bucket exists according to the i-th endpoint,
+// answering from the cache when that endpoint was already asked
+func (m *MinioClient) bucketExists(ctx context.Context, i int, bucket string) (bool, error) {
+	client, endpoint := m.client, m.config.Endpoint
+	if i < len(m.clients) {
+		client = m.clients[i]
+	}
+	if i < len(m.endpoints) {
+		endpoint = m.endpoints[i]
+	}
+	if exists, ok := m.buckets.Lookup(endpoint, bucket); ok {
+		return exists, nil
+	}
+	exists, err := client.BucketExists(ctx, bucket)
+	if err != nil {
+		return false, err
+	}
+	m.buckets.Store(endpoint, bucket, exists)
+	return exists, nil
+}
+
+func (m *MinioClient) ensureBucket() error {
+	ctx := context.Background()
+	buckets := m.Buckets()
+
+	if len(buckets) == 0 {
+		return fmt.Errorf("no buckets configured")
+	}
+
+	for _, bucket := range buckets {
+		exists, err := m.bucketExists(ctx, 0, bucket)
+		if err != nil {
+			return fmt.Errorf("failed to check if bucket '%s' exists: %v", bucket, err)
+		}
+
+		// Existing buckets are used as they are
+		if !exists {
+			err = m.client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{
+				Region:        m.config.Region,
+				ObjectLocking: m.bucketObjectLock(),
+			})
+			m.buckets.Invalidate(bucket)
+			// The missing answer may come from the cache, and another
+			// generator may have created the bucket since
+			if minio.ToErrorResponse(err).Code == "BucketAlreadyOwnedByYou" {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to create bucket '%s': %v", bucket, err)
+			}
+			attrs := []any{"bucket", bucket}
+			if m.config.Region != "" {
+				attrs = append(attrs, "region", m.config.Region)
+			}
+			if m.bucketObjectLock() {
+				attrs = append(attrs, "objectLock", true)
+			}
+			logger.Info("created bucket", attrs...)
+
+			if m.config.Versioned {
+				if err := m.client.EnableVersioning(ctx, bucket); err != nil {
+					return fmt.Errorf("failed to enable versioning on bucket '%s': %v", bucket, err)
+				}
+				logger.Info("enabled versioning", "bucket", bucket)
+			}
+
+			if m.config.LifecycleExpiryDays > 0 {
+				if err := m.client.SetBucketLifecycle(ctx, bucket, expiryLifecycle(m.config.LifecycleExpiryDays)); err != nil {
+					return fmt.Errorf("failed to set the lifecycle of bucket '%s': %v", bucket, err)
+				}
+				logger.Info("installed lifecycle expiry rule", "bucket", bucket, "expiryDays", m.config.LifecycleExpiryDays)
+			}
+		}
+	}
+
+	return nil
+}
+
+// expiryLifecycleRuleID names the rule installed by --lifecycle-expiry-days
+const expiryLifecycleRuleID = "generate-s3-data-expiry"
+
+// expiryLifecycle returns a lifecycle configuration expiring every object
+// after days. The --prefix is not a key prefix, generated keys start with the
+// random directories, so the rule covers the whole bucket, which only holds
+// generated data as it is only installed on buckets the tool created.
+func expiryLifecycle(days int) *lifecycle.Configuration {
+	config := lifecycle.NewConfiguration()
+	config.Rules = []lifecycle.Rule{{
+		ID:         expiryLifecycleRuleID,
+		Status:     "Enabled",
+		Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(days)},
+	}}
+	return config
+}
+
+// Operation performs a single S3 operation using the client. Implementations
+// update their own success counter in m.stats; a returned error is counted in
+// ErrorOps and logged by the operation loop.
+type Operation func(ctx context.Context, m *MinioClient) error
+
+var (
+	// operationRegistry maps operation names to their implementation
+	operationRegistry = map[string]Operation{}
+	// operationWeights holds the weight each operation was registered with
+	operationWeights = map[string]int64{}
+	// operationNames keeps the registration order for stable listings
+	operationNames []string
+)
+
+func init() {
+	RegisterOperation("write", method((*MinioClient).writeOperation))
+	RegisterOperation("read", method((*MinioClient).readOperation))
+	RegisterOperation("rangeread", method((*MinioClient).rangeReadOperation))
+	RegisterOperation("overwrite", method((*MinioClient).overwriteOperation))
+	RegisterOperation("delete", method((*MinioClient).deleteOperation))
+	RegisterOperation("prefixdelete", method((*MinioClient).prefixDeleteOperation))
+	RegisterOperation("multipart", method((*MinioClient).multipartWriteOperation))
+	RegisterOperation("tag", method((*MinioClient).tagOperation))
+	RegisterOperation("copy", method((*MinioClient).copyOperation))
+	RegisterOperation("compose", method((*MinioClient).composeOperation))
+	RegisterOperation("stat", method((*MinioClient).statOperation))
+	RegisterOperation("list", method((*MinioClient).listOperation))
+	RegisterOperation("presignput", method((*MinioClient).presignPutOperation))
+	RegisterOperation("presignget", method((*MinioClient).presignGetOperation))
+	RegisterOperation("abortmultipart", method((*MinioClient).abortMultipartOperation))
+	RegisterOperation("abandonmultipart", method((*MinioClient).abandonMultipartOperation))
+	RegisterOperation("versiondelete", method((*MinioClient).versionDeleteOperation))
+	RegisterOperation("retention", method((*MinioClient).retentionOperation))
+	RegisterOperation("legalhold", method((*MinioClient).legalHoldOperation))
+	// Object lock buckets are always versioned
+	requireFlag("versiondelete", "--versioned, --object-lock or --bucket-object-lock", func(cfg Config) bool {
+		return cfg.Versioned || cfg.ObjectLock || cfg.BucketObjectLock
+	})
+	requireFlag("retention", "--object-lock", func(cfg Config) bool { return cfg.ObjectLock })
+	requireFlag("legalhold", "--object-lock", func(cfg Config) bool { return cfg.ObjectLock })
+	requireFlag("abandonmultipart", "--abandon-multipart", func(cfg Config) bool { return cfg.AbandonMultipart })
+}
+
+// Flags are registered after the built-in operations so the --operations help can list them
+func init() {
+	registerFlags(rootCmd, &config)
+	// Both commands bind the same config, their flags only differ in the defaults shown
+	registerFlags(benchmarkCmd, &config)
+	for name, value := range benchmarkDefaults {
+		benchmarkCmd.Flags().Lookup(name).DefValue = value
+	}
+	rootCmd.AddCommand(benchmarkCmd)
+}
+
+// method adapts a MinioClient method expression, which takes the client
+// first, to an Operation
+func method(op func(*MinioClient, context.Context) error) Operation {
+	return func(ctx context.Context, m *MinioClient) error { return op(m, ctx) }
+}
+
+// RegisterOperation adds an operation with weight 1, the weight of the
+// built-in operations, to the set the operation loop picks from. It must be
+// called before the client starts and panics if the name is already registered.
+func RegisterOperation(name string, op Operation) {
+	RegisterWeightedOperation(name, 1, op)
+}
+
+// RegisterWeightedOperation adds an operation picked with probability
+// proportional to weight, unless --operation-weights overrides it. It panics
+// if the name is already registered or the weight is not positive.
+func RegisterWeightedOperation(name string, weight int64, op Operation) {
+	if _, exists := operationRegistry[name]; exists {
+		panic(fmt.Sprintf("operation %q is already registered", name))
+	}
+	if weight < 1 {
+		panic(fmt.Sprintf("operation %q must have a positive weight, got %d", name, weight))
+	}
+	operationRegistry[name] = op
+	operationWeights[name] = weight
+	operationNames = append(operationNames, name)
+}
+
+// operationRequirement records the flag an operation depends on
+type operationRequirement struct {
+	flag    string
+	enabled func(cfg Config) bool
+}
+
+// operationRequirements holds operations that only work with a particular
+// bucket setup. They are left out of the default selection unless enabled.
+var operationRequirements = map[string]operationRequirement{}
+
+// requireFlag marks a registered operation as depending on flag
+func requireFlag(name, flag string, enabled func(cfg Config) bool) {
+	operationRequirements[name] = operationRequirement{flag: flag, enabled: enabled}
+}
+
+// registeredOperations returns the names of all registered operations in registration order
+func registeredOperations() []string {
+	return append([]string(nil), operationNames...)
+}
+
+// operationsUsage returns the --operations help listing the registered operations
+func operationsUsage() string {
+	return "Operations to run (comma-separated, default all): " + strings.Join(registeredOperations(), ",")
+}
+
+// selectOperations resolves the comma-separated --operations allowlist. An
+// empty allowlist selects every registered operation whose required flag, if
+// any, is set.
+func selectOperations(cfg Config) ([]string, error) {
+	names := parseList(cfg.Operations)
+	if len(names) == 0 {
+		for _, name := range registeredOperations() {
+			if req, gated := operationRequirements[name]; !gated || req.enabled(cfg) {
+				names = append(names, name)
+			}
+		}
+		return names, nil
+	}
+
+	for _, name := range names {
+		if _, exists := operationRegistry[name]; !exists {
+			return nil, fmt.Errorf("unknown operation '%s', valid operations: %s", name, strings.Join(registeredOperations(), ","))
+		}
+		if req, gated := operationRequirements[name]; gated && !req.enabled(cfg) {
+			return nil, fmt.Errorf("operation '%s' requires %s", name, req.flag)
+		}
+	}
+	return names, nil
+}
+
+// parseOperationWeights returns the weight of each of operations: its
+// --operation-weights entry such as "write=3,read=1", or the weight it was
+// registered with. Every weighted name must be one of operations and at least
+// one weight must be positive.
+func parseOperationWeights(value string, operations []string) (map[string]int64, error) {
+	weights := make(map[string]int64, len(operations))
+	for _, name := range operations {
+		weights[name] = operationWeights[name]
+	}
+
+	weighted := make(map[string]bool)
+	for _, entry := range parseList(value) {
+		name, weightValue, found := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !found {
+			return nil, fmt.Errorf("invalid operation weight '%s', expected operation=weight", entry)
+		}
+		if _, selected := weights[name]; !selected {
+			return nil, fmt.Errorf("operation '%s' in --operation-weights is not selected, selected operations: %s", name, strings.Join(operations, ","))
+		}
+		if weighted[name] {
+			return nil, fmt.Errorf("operation '%s' is weighted more than once", name)
+		}
+		weight, err := strconv.ParseInt(strings.TrimSpace(weightValue), 10, 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight '%s' for operation '%s', expected a non-negative integer", weightValue, name)
+		}
+		weights[name] = weight
+		weighted[name] = true
+	}
+
+	var total int64
+	for _, weight := range weights {
+		total += weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("--operation-weights must give at least one operation a positive weight")
+	}
+	return weights, nil
+}
+
+// seededReader is the deterministic random source used with --seed. math/rand
+// sources are not safe for concurrent use, so reads are serialized.
+type seededReader struct {
+	mu  sync.Mutex
+	rng *mathrand.Rand
+}
+
+func newSeededReader(seed int64) *seededReader {
+	return &seededReader{rng: mathrand.New(mathrand.NewSource(seed))}
+}
+
+func (r *seededReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Read(p)
+}
+
+// randomSource returns the reader all random choices are drawn from
+func (m *MinioClient) randomSource() io.Reader {
+	if m.random != nil {
+		return m.random
+	}
+	return rand.Reader
+}
+
+// dryRunCounters maps the built-in operations to the counter --dry-run
+// increments in place of running them
+var dryRunCounters = map[string]func(s *Stats) *int64{
+	"write":            func(s *Stats) *int64 { return &s.WriteOps },
+	"read":             func(s *Stats) *int64 { return &s.ReadOps },
+	"rangeread":        func(s *Stats) *int64 { return &s.RangeReadOps },
+	"stat":             func(s *Stats) *int64 { return &s.StatOps },
+	"list":             func(s *Stats) *int64 { return &s.ListOps },
+	"presignput":       func(s *Stats) *int64 { return &s.PresignPutOps },
+	"presignget":       func(s *Stats) *int64 { return &s.PresignGetOps },
+	"abortmultipart":   func(s *Stats) *int64 { return &s.AbortMultipartOps },
+	"abandonmultipart": func(s *Stats) *int64 { return &s.AbandonMultipartOps },
+	"overwrite":        func(s *Stats) *int64 { return &s.OverwriteOps },
+	"delete":           func(s *Stats) *int64 { return &s.DeleteOps },
+	"prefixdelete":     func(s *Stats) *int64 { return &s.PrefixDeleteOps },
+	"multipart":        func(s *Stats) *int64 { return &s.MultipartOps },
+	"tag":              func(s *Stats) *int64 { return &s.TagOps },
+	"copy":             func(s *Stats) *int64 { return &s.CopyOps },
+	"compose":          func(s *Stats) *int64 { return &s.ComposeOps },
+	"versiondelete":    func(s *Stats) *int64 { return &s.VersionDeleteOps },
+	"retention":        func(s *Stats) *int64 { return &s.RetentionOps },
+	"legalhold":        func(s *Stats) *int64 { return &s.LegalHoldOps },
+}
+
+// dryRunOperation logs what the named operation would do and counts it as a
+// success, without sending any request. Operations on existing objects would pick
+// one from a listing, so only their bucket is shown.
+func (m *MinioClient) dryRunOperation(name string) {
+	bucket, err := m.getRandomBucket()
+	if err != nil {
+		logger.Error("failed to select a bucket", "error", err)
+		return
+	}
+
+	key := "<existing object>"
+	switch name {
+	case "write", "copy", "compose", "presignput":
+		key = m.generateObjectName()
+	case "multipart", "abandonmultipart":
+		key = m.generateMultipartObjectName()
+	case "abortmultipart":
+		key = "<incomplete upload>"
+	case "prefixdelete":
+		key = "<existing prefix>"
+	case "list":
+		key = m.config.ListPrefix
+	}
+
+	if counter, ok := dryRunCounters[name]; ok {
+		atomic.AddInt64(counter(m.stats), 1)
+	}
+	// The per-operation outcomes also cover the operations registered outside
+	// the package, which have no counter in Stats
+	m.recordOutcome(name, nil)
+	m.logOperation(operationRecord{Start: time.Now(), Operation: name, Bucket: bucket, Key: key})
+	operationLogger.Debug("dry run", "op", name, "bucket", bucket, "key", key)
+}
+
+// creatingOperations are the built-in operations that add objects or
+// versions. They are replaced by reads and deletes once --max-objects is reached.
+var creatingOperations = map[string]bool{
+	"write":      true,
+	"overwrite":  true,
+	"multipart":  true,
+	"copy":       true,
+	"compose":    true,
+	"presignput": true,
+}
+
+// atObjectLimit reports whether this run has created --max-objects objects
+// net of deletes. Concurrent workers check it independently, so the count can
+// overshoot the limit by up to the number of workers.
+func (m *MinioClient) atObjectLimit() bool {
+	return m.config.MaxObjects > 0 && atomic.LoadInt64(&m.netObjects) >= m.config.MaxObjects
+}
+
+// runOperations starts the configured number of workers and blocks until
+// all of them have stopped
+func (m *MinioClient) runOperations(ctx context.Context) {
+	operations, err := selectOperations(m.config)
+	if err != nil {
+		logger.Error("failed to select operations", "error", err)
+		return
+	}
+	if m.weights, err = parseOperationWeights(m.config.OperationWeights, operations); err != nil {
+		logger.Error("failed to weight operations", "error", err)
+		return
+	}
+
+	// Exceeding --max-error-rate stops all workers early
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	m.stopRun = cancel
+
+	concurrency := m.config.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// A single limiter is shared so the rate applies across all workers
+	var limiter *rate.Limiter
+	if m.config.Rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(m.config.Rate), 1)
+		if m.config.Ramp > 0 {
+			limiter.SetLimit(rate.Limit(m.config.Rate / rampSteps))
+			go rampLimiter(ctx, limiter, m.config.Rate, m.config.Ramp)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Without a limiter to ramp, the workers join one by one
+			if limiter == nil && m.config.Ramp > 0 {
+				select {
+				case <-time.After(workerStartDelay(i, concurrency, m.config.Ramp)):
+				case <-ctx.Done():
+					return
+				}
+				if i == concurrency-1 {
+					logger.Info("ramp-up finished", "workers", concurrency)
+				}
+			}
+			if m.config.Rate >= 0 {
+				m.runRateWorker(ctx, operations, limiter)
+			} else {
+				m.runWorker(ctx, operations)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// rampSteps is the number of increments by which --ramp raises the rate
+const rampSteps = 100
+
+// rampLimiter raises the limit of limiter linearly from target/rampSteps to
+// target over ramp, unless ctx is done first
+func rampLimiter(ctx context.Context, limiter *rate.Limiter, target float64, ramp time.Duration) {
+	// Tickers need a positive interval, a ramp this short barely ramps anyway
+	ticker := time.NewTicker(max(ramp/rampSteps, time.Millisecond))
+	defer ticker.Stop()
+
+	for step := 2; step <= rampSteps; step++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			limiter.SetLimit(rate.Limit(target * float64(step) / rampSteps))
+		}
+	}
+	logger.Info("ramp-up finished", "rate", target)
+}
+
+// workerStartDelay returns when worker i of concurrency starts during ramp,
+// spreading the starts evenly so the first worker starts right away
+func workerStartDelay(i, concurrency int, ramp time.Duration) time.Duration {
+	return ramp * time.Duration(i) / time.Duration(concurrency)
+}
+
+// runWorker runs a random operation every OperationDelay until ctx is done
+func (m *MinioClient) runWorker(ctx context.Context, operations []string) {
+	ticker := time.NewTicker(m.config.OperationDelay)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.runRandomOperation(ctx, operations)
+		}
+	}
+}
+
+// runRateWorker runs random operations as fast as the limiter allows until
+// ctx is done. A nil limiter means unlimited.
+func (m *MinioClient) runRateWorker(ctx context.Context, operations []string, limiter *rate.Limiter) {
+	for {
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return
+			}
+		} else if ctx.Err() != nil {
+			return
+		}
+		m.runRandomOperation(ctx, operations)
+	}
+}
+
+// errOperationTimeout wraps the error of an attempt cut short by --op-timeout
+var errOperationTimeout = errors.New("operation timed out")
+
+// operationWeight returns the weight the named operation is picked with
+func (m *MinioClient) operationWeight(name string) int64 {
+	if weight, ok := m.weights[name]; ok {
+		return weight
+	}
+	return operationWeights[name]
+}
+
+// pickOperation picks one of operations with probability proportional to its
+// weight. The weights must not all be 0.
+func (m *MinioClient) pickOperation(operations []string) (string, error) {
+	var total int64
+	for _, name := range operations {
+		total += m.operationWeight(name)
+	}
+
+	pick, err := rand.Int(m.randomSource(), big.NewInt(total))
+	if err != nil {
+		return "", err
+	}
+
+	remaining := pick.Int64()
+	for _, name := range operations {
+		if remaining < m.operationWeight(name) {
+			return name, nil
+		}
+		remaining -= m.operationWeight(name)
+	}
+	return operations[len(operations)-1], nil
+}
+
+// objectLimitPause is how long a worker waits at --max-objects when none of
+// its operations can run without creating objects
+const objectLimitPause = 100 * time.Millisecond
+
+// nonCreatingOperations returns the operations that do not add objects and
+// have a positive weight, the ones that can run at --max-objects
+func (m *MinioClient) nonCreatingOperations(operations []string) []string {
+	var others []string
+	for _, name := range operations {
+		if !creatingOperations[name] && m.operationWeight(name) > 0 {
+			others = append(others, name)
+		}
+	}
+	return others
+}
+
+// runRandomOperation picks one of the given operations at random and runs it,
+// retrying it up to --max-retries times while it fails with a retriable error
+func (m *MinioClient) runRandomOperation(ctx context.Context, operations []string) {
+	name, err := m.pickOperation(operations)
+	if err != nil {
+		logger.Error("failed to generate a random number", "error", err)
+		return
+	}
+
+	if creatingOperations[name] && m.atObjectLimit() {
+		// Swap creations for another selected operation so the dataset stops growing
+		others := m.nonCreatingOperations(operations)
+		if len(others) == 0 {
+			// Only a delete elsewhere can lift the cap, wait for it
+			select {
+			case <-time.After(objectLimitPause):
+			case <-ctx.Done():
+			}
+			return
+		}
+		if name, err = m.pickOperation(others); err != nil {
+			logger.Error("failed to generate a random number", "error", err)
+			return
+		}
+	}
+
+	if m.config.DryRun {
+		m.dryRunOperation(name)
+		return
+	}
+
+	// Each attempt goes to the next endpoint, so a retry fails over to
+	// another server
+	run := func() error {
+		attemptCtx, endpoint := m.withNextEndpoint(ctx)
+		if m.config.OpTimeout > 0 {
+			var cancel context.CancelFunc
+			attemptCtx, cancel = context.WithTimeout(attemptCtx, m.config.OpTimeout)
+			defer cancel()
+		}
+		err := operationRegistry[name](attemptCtx, m)
+		if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("%w after %v: %w", errOperationTimeout, m.config.OpTimeout, err)
+		}
+		m.countEndpointAttempt(ctx, endpoint, err)
+		return err
+	}
+	start := time.Now()
+	err = run()
+	for attempt := 1; err != nil && attempt <= m.config.MaxRetries && isRetriableError(err); attempt++ {
+		delay := m.retryBackoff(attempt)
+		logger.Warn("retrying operation", "op", name, "attempt", attempt, "maxRetries", m.config.MaxRetries, "delay", delay.Round(time.Millisecond).String(), "error", err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			// Shutting down, count the last failure as is
+			break
+		}
+		if err = run(); err == nil {
+			atomic.AddInt64(&m.stats.RetriedOps, 1)
+		}
+	}
+	if err == nil {
+		m.recordOutcome(name, nil)
+	} else {
+		// Successful S3 calls log their own rows with the object they touched;
+		// a failure is logged once for the whole operation
+		m.logOperation(operationRecord{
+			Start:     start,
+			Operation: name,
+			Duration:  time.Since(start),
+			Err:       err,
+		})
+		if ctx.Err() != nil {
+			// Aborted by Ctrl+C or the end of --duration, not a server failure
+			operationLogger.Info("operation interrupted by shutdown", "op", name)
+			return
+		}
+		m.recordOutcome(name, err)
+		atomic.AddInt64(&m.stats.ErrorOps, 1)
+		if errors.Is(err, errOperationTimeout) {
+			atomic.AddInt64(&m.stats.TimeoutOps, 1)
+		}
+		logger.Warn("operation failed", "op", name, "error", err)
+		m.checkErrorRate()
+	}
+}
+
+// errorRate returns the fraction of finished operations that failed and how
+// many operations finished
+func (s Stats) errorRate() (fraction float64, samples int64) {
+	samples = s.Total() + s.ErrorOps
+	if samples == 0 {
+		return 0, 0
+	}
+	return float64(s.ErrorOps) / float64(samples), samples
+}
+
+// checkErrorRate stops the run once at least --min-samples operations have
+// finished and more than --max-error-rate of them failed
+func (m *MinioClient) checkErrorRate() {
+	if m.config.MaxErrorRate <= 0 {
+		return
+	}
+	errorRate, samples := m.stats.Snapshot().errorRate()
+	if samples < m.config.MinSamples || errorRate <= m.config.MaxErrorRate {
+		return
+	}
+	// Concurrent workers may all cross the threshold, only the first one reports it
+	if m.aborted.CompareAndSwap(false, true) {
+		logger.Error("error rate exceeds --max-error-rate, stopping",
+			"errorRate", errorRate, "operations", samples, "maxErrorRate", m.config.MaxErrorRate)
+		if m.stopRun != nil {
+			m.stopRun()
+		}
+	}
+}
+
+// Retry backoff bounds: the first retry waits up to retryBaseDelay, each
+// following one up to twice as long, capped at retryMaxDelay
+const (
+	retryBaseDelay = 100 * time.Millisecond
+	retryMaxDelay  = 10 * time.Second
+)
+
+// retryBackoff returns the delay before the given retry attempt (1-based):
+// exponential backoff with full jitter, so concurrent workers hitting the
+// same overloaded server spread their retries
+func (m *MinioClient) retryBackoff(attempt int) time.Duration {
+	backoff := retryMaxDelay
+	if shift := attempt - 1; shift < 20 {
+		backoff = min(retryBaseDelay<<shift, retryMaxDelay)
+	}
+	jitter, err := rand.Int(m.randomSource(), big.NewInt(int64(backoff)))
+	if err != nil {
+		return backoff
+	}
+	return time.Duration(jitter.Int64()) + 1
+}
+
+// retriableErrorCodes are the S3 error codes worth retrying whatever the
+// HTTP status
+var retriableErrorCodes = map[string]bool{
+	"SlowDown":           true,
+	"ServiceUnavailable": true,
+	"InternalError":      true,
+	"RequestTimeout":     true,
+}
+
+// isRetriableError reports whether err is a transient failure: a network
+// error, a timeout, a 5xx response or S3 asking the client to slow down
+func isRetriableError(err error) bool {
+	if errors.Is(err, errOperationTimeout) {
+		return true
+	}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if resp := minio.ToErrorResponse(e); resp.Code != "" || resp.StatusCode != 0 {
+			return retriableErrorCodes[resp.Code] || resp.StatusCode >= 500
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (m *MinioClient) writeOperation(ctx context.Context) error {
+	bucket, err := m.getRandomBucket()
+	if err != nil {
+		return fmt.Errorf("failed to get random bucket: %v", err)
+	}
+
+	objectName := m.generateObjectName()
+	content := m.generateRandomContent()
+	source, err := m.pickSourceFile(0)
+	if err != nil {
+		return err
+	}
+	if source != nil {
+		if content, err = source.read(); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	_, err = m.ClientFor(ctx).PutObject(ctx, bucket, objectName,
+		strings.NewReader(content), int64(len(content)), minio.PutObjectOptions{
+			ContentType:          m.contentType(source),
+			UserMetadata:         m.objectMetadata(content),
+			ServerSideEncryption: m.sse,
+			StorageClass:         m.config.StorageClass,
+		})
+
+	if err != nil {
+		return fmt.Errorf("write operation failed: %w", m.explainEncryptionError(err))
+	}
+
+	m.recordCall("write", bucket, objectName, int64(len(content)), start)
+	atomic.AddInt64(&m.stats.WriteOps, 1)
+	atomic.AddInt64(&m.stats.BytesWritten, int64(len(content)))
+	atomic.AddInt64(&m.netObjects, 1)
+	LogSuccess("write", bucket, objectName, append([]any{"size", len(content)}, source.logAttrs()...)...)
+	m.checkConsistency(ctx, "write", bucket, objectName, content)
+	return nil
+}
+
+// checkConsistency reads bucket/key back right after op wrote content to it,
+// when running with --consistency-check. A missing object or different content
+// counts as a consistency failure; other read errors are only logged, since
+// they say nothing about what the server stored.
+func (m *MinioClient) checkConsistency(ctx context.Context, op, bucket, key, content string) {
+	if !m.config.ConsistencyCheck {
+		return
+	}
+
+	obj, err := m.ClientFor(ctx).GetObject(ctx, bucket, key, minio.GetObjectOptions{
+		ServerSideEncryption: m.readEncryption(),
+	})
+	var data []byte
+	if err == nil {
+		data, err = io.ReadAll(obj)
+		obj.Close()
+	}
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// Shutting down, the write itself succeeded
+	case err != nil && minio.ToErrorResponse(err).Code == "NoSuchKey":
+		atomic.AddInt64(&m.stats.ConsistencyFailures, 1)
+		logger.Warn("consistency check failed, written object not found", "op", op, "bucket", bucket, "key", key)
+	case err != nil:
+		logger.Warn("consistency check could not read the written object", "op", op, "bucket", bucket, "key", key, "error", err)
+	case string(data) != content:
+		atomic.AddInt64(&m.stats.ConsistencyFailures, 1)
+		logger.Warn("consistency check failed, read back different content", "op", op, "bucket", bucket, "key", key,
+			"written", len(content), "read", len(data))
+	default:
+		atomic.AddInt64(&m.stats.BytesRead, int64(len(data)))
+	}
+}
+
+func (m *MinioClient) readOperation(ctx context.Context) error {
+	// List objects and pick one randomly
+	objects, err := m.listObjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(objects) == 0 {
+		// No objects to read, create one first
+		return m.writeOperation(ctx)
+	}
+
+	// Pick random object
+	index, err := rand.Int(m.randomSource(), big.NewInt(int64(len(objects))))
+	if err != nil {
+		return err
+	}
+
+	objectInfo := objects[index.Int64()]
+
+	start := time.Now()
+	obj, err := m.ClientFor(ctx).GetObject(ctx, objectInfo.Bucket, objectInfo.Key, minio.GetObjectOptions{
+		ServerSideEncryption: m.readEncryption(),
+	})
+	if err != nil {
+		return fmt.Errorf("read operation failed: %w", m.explainEncryptionError(err))
+	}
+	defer obj.Close()
+
+	// Read the content
+	size, checksum, err := m.readBody(obj)
+	if err != nil {
+		return fmt.Errorf("read operation failed to read content: %w", m.explainEncryptionError(err))
+	}
+
+	m.recordCall("read", objectInfo.Bucket, objectInfo.Key, size, start)
+	if m.config.Verify {
+		if err := m.verifyChecksum(obj, checksum); err != nil {
+			atomic.AddInt64(&m.stats.VerifyFailureOps, 1)
+			return fmt.Errorf("read operation failed verification for %s/%s: %w", objectInfo.Bucket, objectInfo.Key, err)
+		}
+	}
+
+	atomic.AddInt64(&m.stats.ReadOps, 1)
+	atomic.AddInt64(&m.stats.BytesRead, size)
+	LogSuccess("read", objectInfo.Bucket, objectInfo.Key, "size", size)
+	return nil
+}
+
+// readBody reads a downloaded object body and returns its size, plus its
+// CRC32C when --verify needs it. With --discard-reads the body is streamed
+// instead of being held in memory, so large objects don't need large buffers.
+func (m *MinioClient) readBody(body io.Reader) (size int64, checksum string, err error) {
+	if !m.config.DiscardReads {
+		content, err := io.ReadAll(body)
+		if err != nil || !m.config.Verify {
+			return int64(len(content)), "", err
+		}
+		return int64(len(content)), contentChecksum(content), nil
+	}
+
+	var sink io.Writer = io.Discard
+	hash := crc32.New(crc32cTable)
+	if m.config.Verify {
+		sink = hash
+	}
+	size, err = io.Copy(sink, body)
+	if err != nil || !m.config.Verify {
+		return size, "", err
+	}
+	return size, fmt.Sprintf("%08x", hash.Sum32()), nil
+}
+
+// rangeReadOperation reads a random byte range of a random object and checks
+// that exactly the requested bytes came back
+func (m *MinioClient) rangeReadOperation(ctx context.Context) error {
+	// List objects and pick one randomly
+	objects, err := m.listObjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(objects) == 0 {
+		// No objects to read, create one first
+		return m.writeOperation(ctx)
+	}
+
+	// Pick random object
+	index, err := rand.Int(m.randomSource(), big.NewInt(int64(len(objects))))
+	if err != nil {
+		return err
+	}
+
+	objectInfo := objects[index.Int64()]
+	if objectInfo.Size == 0 {
+		// An empty object has no range to read
+		return m.readOperation(ctx)
+	}
+
+	// Pick a random inclusive range [first, last] within the object
+	first, err := rand.Int(m.randomSource(), big.NewInt(objectInfo.Size))
+	if err != nil {
+		return err
+	}
+	length, err := rand.Int(m.randomSource(), big.NewInt(objectInfo.Size-first.Int64()))
+	if err != nil {
+		return err
+	}
+	rangeStart := first.Int64()
+	rangeEnd := rangeStart + length.Int64()
+
+	opts := minio.GetObjectOptions{ServerSideEncryption: m.readEncryption()}
+	if err := opts.SetRange(rangeStart, rangeEnd); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	obj, err := m.ClientFor(ctx).GetObject(ctx, objectInfo.Bucket, objectInfo.Key, opts)
+	if err != nil {
+		return fmt.Errorf("range read operation failed: %w", m.explainEncryptionError(err))
+	}
+	defer obj.Close()
+
+	size, _, err := m.readBody(obj)
+	if err != nil {
+		return fmt.Errorf("range read operation failed to read content: %w", m.explainEncryptionError(err))
+	}
+
+	m.recordCall("rangeread", objectInfo.Bucket, objectInfo.Key, size, start)
+	if want := rangeEnd - rangeStart + 1; size != want {
+		return fmt.Errorf("range read operation returned %d bytes for range %d-%d of %s/%s, expected %d",
+			size, rangeStart, rangeEnd, objectInfo.Bucket, objectInfo.Key, want)
+	}
+
+	atomic.AddInt64(&m.stats.RangeReadOps, 1)
+	atomic.AddInt64(&m.stats.BytesRead, size)
+	LogSuccess("rangeread", objectInfo.Bucket, objectInfo.Key, "range", fmt.Sprintf("%d-%d", rangeStart, rangeEnd), "size", objectInfo.Size)
+	return nil
+}
+
+func (m *MinioClient) statOperation(ctx context.Context) error {
+	// List objects and pick one randomly
+	objects, err := m.listObjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(objects) == 0 {
+		// No objects to stat, create one first
+		return m.writeOperation(ctx)
+	}
+
+	// Pick random object
+	index, err := rand.Int(m.randomSource(), big.NewInt(int64(len(objects))))
+	if err != nil {
+		return err
+	}
+
+	objectInfo := objects[index.Int64()]
+
+	start := time.Now()
+	info, err := m.ClientFor(ctx).StatObject(ctx, objectInfo.Bucket, objectInfo.Key, minio.StatObjectOptions{
+		ServerSideEncryption: m.readEncryption(),
+	})
+	if err != nil {
+		return fmt.Errorf("stat operation failed: %w", m.explainEncryptionError(err))
+	}
+	m.recordCall("stat", objectInfo.Bucket, objectInfo.Key, info.Size, start)
+
+	if info.ETag == "" {
+		return fmt.Errorf("stat operation returned no ETag for %s/%s", objectInfo.Bucket, objectInfo.Key)
+	}
+
+	atomic.AddInt64(&m.stats.StatOps, 1)
+	LogSuccess("stat", objectInfo.Bucket, objectInfo.Key, "size", info.Size, "etag", info.ETag)
+	return nil
+}
+
+// listOperation lists a random bucket recursively under --list-prefix and
+// counts the objects it sees
+func (m *MinioClient) listOperation(ctx context.Context) error {
+	bucket, err := m.getRandomBucket()
+	if err != nil {
+		return fmt.Errorf("failed to get random bucket: %v", err)
+	}
+	prefix := m.config.ListPrefix
+
+	start := time.Now()
+	var count, size int64
+	for object := range m.ClientFor(ctx).ListObjects(ctx, bucket, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if object.Err != nil {
+			return fmt.Errorf("list operation failed for %s/%s: %w", bucket, prefix, object.Err)
+		}
+		count++
+		size += object.Size
+	}
+
+	m.recordCall("list", bucket, prefix, size, start)
+	atomic.AddInt64(&m.stats.ListOps, 1)
+	LogSuccess("list", bucket, prefix, "objects", count, "size", size)
+	return nil
+}
+
+func (m *MinioClient) overwriteOperation(ctx context.Context) error {
+	// List objects and pick one randomly
+	objects, err := m.listObjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(objects) == 0 {
+		// No objects to overwrite, create one first
+		return m.writeOperation(ctx)
+	}
+
+	// Pick random object
+	index, err := rand.Int(m.randomSource(), big.NewInt(int64(len(objects))))
+	if err != nil {
+		return err
+	}
+
+	objectInfo := objects[index.Int64()]
+	content := m.generateRandomContent()
+
+	start := time.Now()
+	_, err = m.ClientFor(ctx).PutObject(ctx, objectInfo.Bucket, objectInfo.Key,
+		strings.NewReader(content), int64(len(content)), minio.PutObjectOptions{
+			ContentType:          m.contentType(nil),
+			UserMetadata:         m.objectMetadata(content),
+			ServerSideEncryption: m.sse,
+			StorageClass:         m.config.StorageClass,
+		})
+
+	if err != nil {
+		return fmt.Errorf("overwrite operation failed: %w", m.explainEncryptionError(err))
+	}
+
+	m.recordCall("overwrite", objectInfo.Bucket, objectInfo.Key, int64(len(content)), start)
+	atomic.AddInt64(&m.stats.OverwriteOps, 1)
+	atomic.AddInt64(&m.stats.BytesWritten, int64(len(content)))
+	LogSuccess("overwrite", objectInfo.Bucket, objectInfo.Key, "size", len(content))
+	m.checkConsistency(ctx, "overwrite", objectInfo.Bucket, objectInfo.Key, content)
+	return nil
+}
+
+func (m *MinioClient) deleteOperation(ctx context.Context) error {
+	// List objects and pick one randomly
+	objects, err := m.listObjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(objects) == 0 {
+		// No objects to delete, create one first then delete it
+		if err := m.writeOperation(ctx); err != nil {
+			return err
+		}
+		// Refresh objects list
+		objects, err = m.listObjects(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Pick random object
+	index, err := rand.Int(m.randomSource(), big.NewInt(int64(len(objects))))
+	if err != nil {
+		return err
+	}
+
+	objectInfo := objects[index.Int64()]
+
+	start := time.Now()
+	err = m.ClientFor(ctx).RemoveObject(ctx, objectInfo.Bucket, objectInfo.Key, minio.RemoveObjectOptions{})
+	if err != nil {
+		if m.recordLocked("delete", objectInfo, start, err) {
+			return nil
+		}
+		return fmt.Errorf("delete operation failed: %w", err)
+	}
+
+	m.recordCall("delete", objectInfo.Bucket, objectInfo.Key, 0, start)
+	atomic.AddInt64(&m.stats.DeleteOps, 1)
+	atomic.AddInt64(&m.netObjects, -1)
+	LogSuccess("delete", objectInfo.Bucket, objectInfo.Key)
+	return nil
+}
+
+func (m *MinioClient) versionDeleteOperation(ctx context.Context) error {
+	// List object versions and pick one randomly
+	versions, err := m.listObjectVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(versions) == 0 {
+		// No versions to delete, create one first
+		return m.writeOperation(ctx)
+	}
+
+	// Pick random version
+	index, err := rand.Int(m.randomSource(), big.NewInt(int64(len(versions))))
+	if err != nil {
+		return err
+	}
+
+	version := versions[index.Int64()]
+
+	start := time.Now()
+	err = m.ClientFor(ctx).RemoveObject(ctx, version.Bucket, version.Key, minio.RemoveObjectOptions{
+		VersionID: version.VersionID,
+	})
+	if err != nil {
+		if m.recordLocked("versiondelete", version, start, err) {
+			return nil
+		}
+		return fmt.Errorf("version delete operation failed: %w", err)
+	}
+
+	m.recordCall("versiondelete", version.Bucket, version.Key, 0, start)
+	kind := "version"
+	if version.IsDeleteMarker {
+		kind = "delete marker"
+	}
+	atomic.AddInt64(&m.stats.VersionDeleteOps, 1)
+	LogSuccess("versiondelete", version.Bucket, version.Key, "kind", kind, "versionID", version.VersionID)
+	return nil
+}
+
+func (m *MinioClient) prefixDeleteOperation(ctx context.Context) error {
+	// Get all objects across all buckets
+	objects, err := m.listObjects(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list objects for prefix deletion: %w", err)
+	}
+
+	if len(objects) == 0 {
+		// No objects to delete, create some first
+		return m.writeOperation(ctx)
+	}
+
+	// Group objects by their prefix (first 2-3 levels of directory structure) within each bucket
+	prefixGroups := make(map[string][]ObjectInfo)
+	for _, objectInfo := range objects {
+		// Extract prefix (up to 2nd or 3rd slash)
+		parts := strings.Split(objectInfo.Key, "/")
+		if len(parts) >= 2 {
+			// Use bucket and first 2 levels as prefix for deletion
+			prefix := objectInfo.Bucket + ":" + strings.Join(parts[:2], "/") + "/"
+			prefixGroups[prefix] = append(prefixGroups[prefix], objectInfo)
+		}
+	}
+
+	if len(prefixGroups) == 0 {
+		return fmt.Errorf("no valid prefixes found for deletion")
+	}
+
+	// Select a random prefix that has multiple objects (for better demo)
+	var selectedPrefix string
+	var objectsToDelete []ObjectInfo
+	maxObjects := 0
+
+	for prefix, prefixObjects := range prefixGroups {
+		if len(prefixObjects) > maxObjects {
+			maxObjects = len(prefixObjects)
+			selectedPrefix = prefix
+			objectsToDelete = prefixObjects
+		}
+	}
+
+	// If no prefix has multiple objects, just pick any prefix
+	if selectedPrefix == "" {
+		for prefix, prefixObjects := range prefixGroups {
+			selectedPrefix = prefix
+			objectsToDelete = prefixObjects
+			break
+		}
+	}
+
+	// Delete all objects under the selected prefix
+	var deletedCount int
+	if m.config.BatchDelete {
+		deletedCount = m.deleteObjectsBatch(ctx, objectsToDelete)
+	} else {
+		deletedCount = m.deleteObjects(ctx, objectsToDelete)
+	}
+
+	atomic.AddInt64(&m.stats.PrefixDeleteOps, 1)
+	atomic.AddInt64(&m.netObjects, -int64(deletedCount))
+	bucket, prefix, _ := strings.Cut(selectedPrefix, ":")
+	LogSuccess("prefixdelete", bucket, prefix, "deleted", deletedCount)
+	return nil
+}
+
+// deleteObjects removes objects one request at a time and returns how many
+// were deleted. Failures are logged and skipped.
+func (m *MinioClient) deleteObjects(ctx context.Context, objects []ObjectInfo) int {
+	deletedCount := 0
+	for _, objectInfo := range objects {
+		start := time.Now()
+		err := m.ClientFor(ctx).RemoveObject(ctx, objectInfo.Bucket, objectInfo.Key, minio.RemoveObjectOptions{})
+		if err != nil {
+			m.recordDeleteFailure(objectInfo, start, err)
+			continue
+		}
+		m.recordCall("prefixdelete", objectInfo.Bucket, objectInfo.Key, 0, start)
+		deletedCount++
+	}
+	return deletedCount
+}
+
+// deleteObjectsBatch removes objects, which must all be in the same bucket,
+// with multi-object delete requests of up to 1000 keys each and returns how
+// many were deleted. Failures are logged and skipped, and the keys left unsent
+// once ctx is cancelled don't count as deleted.
+func (m *MinioClient) deleteObjectsBatch(ctx context.Context, objects []ObjectInfo) int {
+	if len(objects) == 0 {
+		return 0
+	}
+	bucket := objects[0].Bucket
+
+	objectsCh := make(chan minio.ObjectInfo)
+	sentCh := make(chan int, 1)
+	go func() {
+		sent := 0
+		defer func() {
+			close(objectsCh)
+			sentCh <- sent
+		}()
+		for _, objectInfo := range objects {
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case objectsCh <- minio.ObjectInfo{Key: objectInfo.Key}:
+				sent++
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	start := time.Now()
+	failed := 0
+	for removeErr := range m.ClientFor(ctx).RemoveObjects(ctx, bucket, objectsCh, minio.RemoveObjectsOptions{}) {
+		failed++
+		m.recordDeleteFailure(ObjectInfo{Bucket: bucket, Key: removeErr.ObjectName}, start, removeErr.Err)
+	}
+
+	deletedCount := <-sentCh - failed
+	m.recordCall("prefixdelete", bucket, fmt.Sprintf("<%d objects>", deletedCount), 0, start)
+	return deletedCount
+}
+
+// recordDeleteFailure logs an object that a prefix delete failed to remove
+func (m *MinioClient) recordDeleteFailure(objectInfo ObjectInfo, start time.Time, err error) {
+	if m.recordLocked("prefixdelete", objectInfo, start, err) {
+		return
+	}
+	m.logOperation(operationRecord{
+		Start:     start,
+		Operation: "prefixdelete",
+		Bucket:    objectInfo.Bucket,
+		Key:       objectInfo.Key,
+		Duration:  time.Since(start),
+		Err:       err,
+	})
+	logger.Warn("failed to delete object of prefix", "op", "prefixdelete", "bucket", objectInfo.Bucket, "key", objectInfo.Key, "error", err)
+}
+
+func (m *MinioClient) multipartWriteOperation(ctx context.Context) error {
+	bucket, err := m.getRandomBucket()
+	if err != nil {
+		return fmt.Errorf("failed to get random bucket: %v", err)
+	}
+
+	objectName := m.generateMultipartObjectName()
+
+	// Content is larger than the part size (validated at startup), so the upload is always multipart
+	content := m.generateVeryLargeContent()
+	partSize := uint64(m.config.MultipartPartSize)
+
+	// Only source files larger than one part still produce a multipart upload
+	source, err := m.pickSourceFile(int64(partSize) + 1)
+	if err != nil {
+		return err
+	}
+	if source != nil {
+		if content, err = source.read(); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	_, err = m.ClientFor(ctx).PutObject(ctx, bucket, objectName,
+		strings.NewReader(content), int64(len(content)),
+		minio.PutObjectOptions{
+			PartSize:             partSize,
+			ContentType:          m.contentType(source),
+			UserMetadata:         m.objectMetadata(content),
+			ServerSideEncryption: m.sse,
+			StorageClass:         m.config.StorageClass,
+		})
+
+	if err != nil {
+		return fmt.Errorf("multipart write operation failed: %w", m.explainEncryptionError(err))
+	}
+
+	m.recordCall("multipart", bucket, objectName, int64(len(content)), start)
+	parts := (uint64(len(content)) + partSize - 1) / partSize
+	atomic.AddInt64(&m.stats.MultipartOps, 1)
+	atomic.AddInt64(&m.stats.BytesWritten, int64(len(content)))
+	atomic.AddInt64(&m.netObjects, 1)
+	LogSuccess("multipart", bucket, objectName, append([]any{"size", len(content), "parts", parts, "partSize", partSize}, source.logAttrs()...)...)
+	m.checkConsistency(ctx, "multipart", bucket, objectName, content)
+	return nil
+}
+
+// abortMultipartOperation aborts the incomplete multipart uploads of a random
+// object in a random bucket
+func (m *MinioClient) abortMultipartOperation(ctx context.Context) error {
+	bucket, err := m.getRandomBucket()
+	if err != nil {
+		return fmt.Errorf("failed to get random bucket: %v", err)
+	}
+
+	uploads, err := m.listIncompleteUploads(ctx, bucket)
+	if err != nil {
+		return err
+	}
+
+	if len(uploads) == 0 {
+		// Nothing to abort, leave an upload behind for a later run of this
+		// operation when allowed, create an object otherwise
+		if m.config.AbandonMultipart {
+			return m.abandonMultipartOperation(ctx)
+		}
+		return m.writeOperation(ctx)
+	}
+
+	// Pick random upload
+	index, err := rand.Int(m.randomSource(), big.NewInt(int64(len(uploads))))
+	if err != nil {
+		return err
+	}
+
+	upload := uploads[index.Int64()]
+
+	// RemoveIncompleteUpload aborts every incomplete upload of the object
+	start := time.Now()
+	err = m.ClientFor(ctx).RemoveIncompleteUpload(ctx, upload.Bucket, upload.Key)
+	if err != nil {
+		return fmt.Errorf("abort multipart operation failed: %w", err)
+	}
+
+	m.recordCall("abortmultipart", upload.Bucket, upload.Key, 0, start)
+	atomic.AddInt64(&m.stats.AbortMultipartOps, 1)
+	LogSuccess("abortmultipart", upload.Bucket, upload.Key)
+	return nil
+}
+
+// abandonMultipartOperation starts a multipart upload, uploads one small part
+// and never completes it, like an interrupted client would
+func (m *MinioClient) abandonMultipartOperation(ctx context.Context) error {
+	bucket, err := m.getRandomBucket()
+	if err != nil {
+		return fmt.Errorf("failed to get random bucket: %v", err)
+	}
+
+	objectName := m.generateMultipartObjectName()
+	content := m.generateRandomContent()
+
+	core := minio.Core{Client: m.ClientFor(ctx)}
+	start := time.Now()
+	uploadID, err := core.NewMultipartUpload(ctx, bucket, objectName, minio.PutObjectOptions{
+		ContentType:          m.contentType(nil),
+		UserMetadata:         m.runMetadata(),
+		ServerSideEncryption: m.sse,
+		StorageClass:         m.config.StorageClass,
+	})
+	if err != nil {
+		return fmt.Errorf("abandon multipart operation failed to start upload: %w", m.explainEncryptionError(err))
+	}
+
+	// Parts only carry the encryption headers for SSE-C
+	_, err = core.PutObjectPart(ctx, bucket, objectName, uploadID, 1,
+		strings.NewReader(content), int64(len(content)), minio.PutObjectPartOptions{
+			SSE: m.readEncryption(),
+		})
+	if err != nil {
+		return fmt.Errorf("abandon multipart operation failed to upload part: %w", m.explainEncryptionError(err))
+	}
+
+	m.recordCall("abandonmultipart", bucket, objectName, int64(len(content)), start)
+	atomic.AddInt64(&m.stats.AbandonMultipartOps, 1)
+	LogSuccess("abandonmultipart", bucket, objectName, "uploadID", uploadID)
+	return nil
+}
+
+func (m *MinioClient) tagOperation(ctx context.Context) error {
+	// List objects and pick one randomly
+	objects, err := m.listObjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(objects) == 0 {
+		// No objects to tag, create one first
+		return m.writeOperation(ctx)
+	}
+
+	// Pick random object
+	index, err := rand.Int(m.randomSource(), big.NewInt(int64(len(objects))))
+	if err != nil {
+		return err
+	}
+
+	objectInfo := objects[index.Int64()]
+	objectTags, err := tags.NewTags(m.generateRandomTags(), true)
+	if err != nil {
+		return fmt.Errorf("tag operation failed: %w", err)
+	}
+
+	start := time.Now()
+	err = m.ClientFor(ctx).PutObjectTagging(ctx, objectInfo.Bucket, objectInfo.Key, objectTags, minio.PutObjectTaggingOptions{})
+	if err != nil {
+		return fmt.Errorf("tag operation failed: %w", err)
+	}
+
+	m.recordCall("tag", objectInfo.Bucket, objectInfo.Key, 0, start)
+	atomic.AddInt64(&m.stats.TagOps, 1)
+	LogSuccess("tag", objectInfo.Bucket, objectInfo.Key, "tags", objectTags.String())
+	return nil
+}
+
+func (m *MinioClient) copyOperation(ctx context.Context) error {
+	// List objects and pick one randomly
+	objects, err := m.listObjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(objects) == 0 {
+		// No objects to copy, create one first
+		return m.writeOperation(ctx)
+	}
+
+	// Pick random source object
+	index, err := rand.Int(m.randomSource(), big.NewInt(int64(len(objects))))
+	if err != nil {
+		return err
+	}
+
+	source := objects[index.Int64()]
+	bucket, err := m.getRandomBucket()
+	if err != nil {
+		return fmt.Errorf("failed to get random bucket: %v", err)
+	}
+	objectName := m.generateObjectName()
+
+	// The copy keeps the source metadata, including the checksum. The run ID
+	// must be this run's, so with one set the metadata is read from the
+	// source and replaced.
+	dest := minio.CopyDestOptions{
+		Bucket:     bucket,
+		Object:     objectName,
+		Encryption: m.sse,
+	}
+	if runMetadata := m.runMetadata(); runMetadata != nil {
+		info, err := m.ClientFor(ctx).StatObject(ctx, source.Bucket, source.Key, minio.StatObjectOptions{ServerSideEncryption: m.readEncryption()})
+		if err != nil {
+			return fmt.Errorf("copy operation failed to stat %s/%s: %w", source.Bucket, source.Key, m.explainEncryptionError(err))
+		}
+		metadata := make(map[string]string, len(info.UserMetadata)+1)
+		for key, value := range info.UserMetadata {
+			if !strings.EqualFold(key, runIDMetaKey) {
+				metadata[key] = value
+			}
+		}
+		maps.Copy(metadata, runMetadata)
+		dest.UserMetadata = metadata
+		dest.ReplaceMetadata = true
+	}
+
+	start := time.Now()
+	_, err = m.ClientFor(ctx).CopyObject(ctx, dest, minio.CopySrcOptions{
+		Bucket:     source.Bucket,
+		Object:     source.Key,
+		Encryption: m.readEncryption(),
+	})
+	if err != nil {
+		return fmt.Errorf("copy operation failed: %w", m.explainEncryptionError(err))
+	}
+
+	m.recordCall("copy", bucket, objectName, 0, start)
+	atomic.AddInt64(&m.stats.CopyOps, 1)
+	atomic.AddInt64(&m.netObjects, 1)
+	LogSuccess("copy", bucket, objectName, "source", source.Bucket+"/"+source.Key)
+	return nil
+}
+
+// composeOperation uploads 2-3 parts and concatenates them into a new object
+// with a server-side compose, then reads the result back to check its size.
+// S3 only composes sources of at least 5MiB except the last one, so all parts
+// but the last are 5MiB. The parts are removed afterwards.
+func (m *MinioClient) composeOperation(ctx context.Context) error {
+	bucket, err := m.getRandomBucket()
+	if err != nil {
+		return fmt.Errorf("failed to get random bucket: %v", err)
+	}
+	objectName := m.generateObjectName()
+
+	count, err := rand.Int(m.randomSource(), big.NewInt(2))
+	if err != nil {
+		return err
+	}
+	parts := make([]string, count.Int64()+2)
+	for i := range parts[:len(parts)-1] {
+		parts[i] = patternContent(minPartSize)
+	}
+	parts[len(parts)-1] = m.generateRandomContent()
+
+	sources := make([]minio.CopySrcOptions, len(parts))
+	for i, part := range parts {
+		partName := fmt.Sprintf("%s.part-%d", objectName, i+1)
+		_, err := m.ClientFor(ctx).PutObject(ctx, bucket, partName, strings.NewReader(part), int64(len(part)), minio.PutObjectOptions{
+			UserMetadata:         m.runMetadata(),
+			ServerSideEncryption: m.sse,
+			StorageClass:         m.config.StorageClass,
+		})
+		if err != nil {
+			m.removeComposeParts(ctx, sources[:i])
+			return fmt.Errorf("compose operation failed to upload part %d: %w", i+1, m.explainEncryptionError(err))
+		}
+		atomic.AddInt64(&m.stats.BytesWritten, int64(len(part)))
+		sources[i] = minio.CopySrcOptions{Bucket: bucket, Object: partName, Encryption: m.readEncryption()}
+	}
+	defer m.removeComposeParts(ctx, sources)
+
+	// The composed object gets the checksum of the whole content so --verify works on it
+	content := strings.Join(parts, "")
+	metadata := m.objectMetadata(content)
+	if contentType := m.contentType(nil); contentType != "" {
+		metadata["Content-Type"] = contentType
+	}
+	start := time.Now()
+	_, err = m.ClientFor(ctx).ComposeObject(ctx, minio.CopyDestOptions{
+		Bucket:          bucket,
+		Object:          objectName,
+		Encryption:      m.sse,
+		UserMetadata:    metadata,
+		ReplaceMetadata: true,
+	}, sources...)
+	if err != nil {
+		return fmt.Errorf("compose operation failed: %w", m.explainEncryptionError(err))
+	}
+	m.recordCall("compose", bucket, objectName, int64(len(content)), start)
+	atomic.AddInt64(&m.netObjects, 1)
+
+	obj, err := m.ClientFor(ctx).GetObject(ctx, bucket, objectName, minio.GetObjectOptions{
+		ServerSideEncryption: m.readEncryption(),
+	})
+	if err != nil {
+		return fmt.Errorf("compose operation failed to read back %s/%s: %w", bucket, objectName, err)
+	}
+	defer obj.Close()
+	size, err := io.Copy(io.Discard, obj)
+	if err != nil {
+		return fmt.Errorf("compose operation failed to read back %s/%s: %w", bucket, objectName, err)
+	}
+	atomic.AddInt64(&m.stats.BytesRead, size)
+	if size != int64(len(content)) {
+		return fmt.Errorf("compose operation produced %d bytes for %s/%s, expected %d from %d parts",
+			size, bucket, objectName, len(content), len(parts))
+	}
+
+	atomic.AddInt64(&m.stats.ComposeOps, 1)
+	LogSuccess("compose", bucket, objectName, "size", size, "parts", len(parts))
+	return nil
+}
+
+// removeComposeParts deletes the uploaded parts of a compose operation. It
+// ignores the cancellation of ctx, so parts are removed on shutdown too.
+func (m *MinioClient) removeComposeParts(ctx context.Context, sources []minio.CopySrcOptions) {
+	ctx = context.WithoutCancel(ctx)
+	for _, source := range sources {
+		if err := m.ClientFor(ctx).RemoveObject(ctx, source.Bucket, source.Object, minio.RemoveObjectOptions{}); err != nil {
+			logger.Warn("failed to remove compose part", "op", "compose", "bucket", source.Bucket, "key", source.Object, "error", err)
+		}
+	}
+}
+
+// presignPutOperation uploads a new object with a plain HTTP PUT to a
+// presigned URL. Only the host and the run ID header are signed, so the
+// checksum metadata is left out; SSE headers are sent alongside the URL as S3
+// requires.
+func (m *MinioClient) presignPutOperation(ctx context.Context) error {
+	bucket, err := m.getRandomBucket()
+	if err != nil {
+		return fmt.Errorf("failed to get random bucket: %v", err)
+	}
+	objectName := m.generateObjectName()
+	content := m.generateRandomContent()
+
+	start := time.Now()
+	metadataHeader := make(http.Header)
+	for key, value := range m.runMetadata() {
+		metadataHeader.Set("X-Amz-Meta-"+key, value)
+	}
+	presignedURL, err := m.ClientFor(ctx).PresignHeader(ctx, http.MethodPut, bucket, objectName, m.config.PresignExpiry, nil, metadataHeader)
+	if err != nil {
+		return fmt.Errorf("presigned put operation failed to presign: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, presignedURL.String(), strings.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("presigned put operation failed: %w", err)
+	}
+	contentType := m.contentType(nil)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+	maps.Copy(req.Header, metadataHeader)
+	if m.sse != nil {
+		m.sse.Marshal(req.Header)
+	}
+	if err := doPresignedRequest(req, io.Discard); err != nil {
+		return fmt.Errorf("presigned put operation failed for %s/%s: %w", bucket, objectName, err)
+	}
+
+	m.recordCall("presignput", bucket, objectName, int64(len(content)), start)
+	atomic.AddInt64(&m.stats.PresignPutOps, 1)
+	atomic.AddInt64(&m.stats.BytesWritten, int64(len(content)))
+	atomic.AddInt64(&m.netObjects, 1)
+	LogSuccess("presignput", bucket, objectName, "size", len(content))
+	m.checkConsistency(ctx, "presignput", bucket, objectName, content)
+	return nil
+}
+
+// presignGetOperation downloads a random object with a plain HTTP GET from a
+// presigned URL
+func (m *MinioClient) presignGetOperation(ctx context.Context) error {
+	// List objects and pick one randomly
+	objects, err := m.listObjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(objects) == 0 {
+		// No objects to download, create one first
+		return m.writeOperation(ctx)
+	}
+
+	// Pick random object
+	index, err := rand.Int(m.randomSource(), big.NewInt(int64(len(objects))))
+	if err != nil {
+		return err
+	}
+
+	objectInfo := objects[index.Int64()]
+
+	start := time.Now()
+	presignedURL, err := m.ClientFor(ctx).PresignedGetObject(ctx, objectInfo.Bucket, objectInfo.Key, m.config.PresignExpiry, nil)
+	if err != nil {
+		return fmt.Errorf("presigned get operation failed to presign: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, presignedURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("presigned get operation failed: %w", err)
+	}
+	if sse := m.readEncryption(); sse != nil {
+		sse.Marshal(req.Header)
+	}
+	var content bytes.Buffer
+	if err := doPresignedRequest(req, &content); err != nil {
+		return fmt.Errorf("presigned get operation failed for %s/%s: %w", objectInfo.Bucket, objectInfo.Key, err)
+	}
+
+	m.recordCall("presignget", objectInfo.Bucket, objectInfo.Key, int64(content.Len()), start)
+	atomic.AddInt64(&m.stats.PresignGetOps, 1)
+	atomic.AddInt64(&m.stats.BytesRead, int64(content.Len()))
+	LogSuccess("presignget", objectInfo.Bucket, objectInfo.Key, "size", content.Len())
+	return nil
+}
+
+// doPresignedRequest sends req and copies the response body to body. A non-2xx
+// status is returned as an error carrying the S3 error message.
+func doPresignedRequest(req *http.Request, body io.Writer) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errResp := minio.ErrorResponse{}
+		if data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024)); err == nil {
+			xml.Unmarshal(data, &errResp)
+		}
+		// Keep the status so retries can tell server errors from client errors
+		errResp.StatusCode = resp.StatusCode
+		if errResp.Message == "" {
+			errResp.Message = "unexpected status " + resp.Status
+		}
+		return errResp
+	}
+	_, err = io.Copy(body, resp.Body)
+	return err
+}
+
+func (m *MinioClient) retentionOperation(ctx context.Context) error {
+	// List objects and pick one randomly
+	objects, err := m.listObjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(objects) == 0 {
+		// No objects to protect, create one first
+		return m.writeOperation(ctx)
+	}
+
+	// Pick random object
+	index, err := rand.Int(m.randomSource(), big.NewInt(int64(len(objects))))
+	if err != nil {
+		return err
+	}
+
+	objectInfo := objects[index.Int64()]
+	mode, retainUntil := m.generateRetention()
+
+	start := time.Now()
+	err = m.ClientFor(ctx).PutObjectRetention(ctx, objectInfo.Bucket, objectInfo.Key, minio.PutObjectRetentionOptions{
+		Mode:            &mode,
+		RetainUntilDate: &retainUntil,
+	})
+	if err != nil {
+		// Shortening an existing COMPLIANCE retention is refused by design
+		if m.recordLocked("retention", objectInfo, start, err) {
+			return nil
+		}
+		return fmt.Errorf("retention operation failed: %w", err)
+	}
+
+	m.recordCall("retention", objectInfo.Bucket, objectInfo.Key, 0, start)
+	atomic.AddInt64(&m.stats.RetentionOps, 1)
+	LogSuccess("retention", objectInfo.Bucket, objectInfo.Key, "mode", mode.String(), "retainUntil", retainUntil)
+	return nil
+}
+
+func (m *MinioClient) legalHoldOperation(ctx context.Context) error {
+	// List objects and pick one randomly
+	objects, err := m.listObjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(objects) == 0 {
+		// No objects to hold, create one first
+		return m.writeOperation(ctx)
+	}
+
+	// Pick random object
+	index, err := rand.Int(m.randomSource(), big.NewInt(int64(len(objects))))
+	if err != nil {
+		return err
+	}
+
+	objectInfo := objects[index.Int64()]
+
+	// Toggle the current status; objects that never had a legal hold report an error here
+	status := minio.LegalHoldEnabled
+	current, err := m.ClientFor(ctx).GetObjectLegalHold(ctx, objectInfo.Bucket, objectInfo.Key, minio.GetObjectLegalHoldOptions{})
+	if err == nil && current != nil && *current == minio.LegalHoldEnabled {
+		status = minio.LegalHoldDisabled
+	}
+
+	start := time.Now()
+	err = m.ClientFor(ctx).PutObjectLegalHold(ctx, objectInfo.Bucket, objectInfo.Key, minio.PutObjectLegalHoldOptions{
+		Status: &status,
+	})
+	if err != nil {
+		return fmt.Errorf("legal hold operation failed: %w", err)
+	}
+
+	m.recordCall("legalhold", objectInfo.Bucket, objectInfo.Key, 0, start)
+	atomic.AddInt64(&m.stats.LegalHoldOps, 1)
+	LogSuccess("legalhold", objectInfo.Bucket, objectInfo.Key, "status", status.String())
+	return nil
+}
+
+// recordLocked counts err from the S3 call of operation started at start as
+// an expected lock refusal when running with --object-lock. It reports
+// whether err was handled.
+func (m *MinioClient) recordLocked(operation string, objectInfo ObjectInfo, start time.Time, err error) bool {
+	if !m.config.ObjectLock || !isObjectLockedError(err) {
+		return false
+	}
+	atomic.AddInt64(&m.stats.LockedOps, 1)
+	m.logOperation(operationRecord{
+		Start:     start,
+		Operation: operation,
+		Bucket:    objectInfo.Bucket,
+		Key:       objectInfo.Key,
+		Duration:  time.Since(start),
+		Err:       err,
+	})
+	logger.Info("object is protected by object lock", "op", operation, "bucket", objectInfo.Bucket, "key", objectInfo.Key)
+	return true
+}
+
+// isObjectLockedError reports whether err is the server refusing to modify a
+// locked object. MinIO describes these as WORM protected, AWS as access denied
+// because of object lock.
+func isObjectLockedError(err error) bool {
+	resp := minio.ToErrorResponse(err)
+	message := strings.ToLower(resp.Message)
+	return strings.Contains(message, "worm protected") ||
+		(resp.Code == "AccessDenied" && strings.Contains(message, "object lock"))
+}
+
+func (m *MinioClient) listObjects(ctx context.Context) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	buckets := m.Buckets()
+
+	// List all objects across all buckets
+	for _, bucket := range buckets {
+		objectCh := m.ClientFor(ctx).ListObjects(ctx, bucket, minio.ListObjectsOptions{
+			Recursive: true,
+		})
+
+		for object := range objectCh {
+			if object.Err != nil {
+				return nil, object.Err
+			}
+			// Filter objects that contain our base prefix anywhere in the path
+			if strings.Contains(object.Key, m.config.ObjectPrefix) {
+				objects = append(objects, ObjectInfo{
+					Bucket: bucket,
+					Key:    object.Key,
+					Size:   object.Size,
+				})
+			}
+		}
+	}
+
+	return objects, nil
+}
+
+// listIncompleteUploads lists the incomplete multipart uploads in bucket whose
+// key contains the object prefix
+func (m *MinioClient) listIncompleteUploads(ctx context.Context, bucket string) ([]ObjectInfo, error) {
+	var uploads []ObjectInfo
+
+	for upload := range m.ClientFor(ctx).ListIncompleteUploads(ctx, bucket, "", true) {
+		if upload.Err != nil {
+			return nil, upload.Err
+		}
+		if strings.Contains(upload.Key, m.config.ObjectPrefix) {
+			uploads = append(uploads, ObjectInfo{
+				Bucket: bucket,
+				Key:    upload.Key,
+			})
+		}
+	}
+
+	return uploads, nil
+}
+
+// listObjectVersions lists every version and delete marker of our objects across all buckets
+func (m *MinioClient) listObjectVersions(ctx context.Context) ([]ObjectInfo, error) {
+	var versions []ObjectInfo
+
+	for _, bucket := range m.Buckets() {
+		objectCh := m.ClientFor(ctx).ListObjects(ctx, bucket, minio.ListObjectsOptions{
+			Recursive:    true,
+			WithVersions: true,
+		})
+
+		for object := range objectCh {
+			if object.Err != nil {
+				return nil, object.Err
+			}
+			if strings.Contains(object.Key, m.config.ObjectPrefix) {
+				versions = append(versions, ObjectInfo{
+					Bucket:         bucket,
+					Key:            object.Key,
+					VersionID:      object.VersionID,
+					IsDeleteMarker: object.IsDeleteMarker,
+				})
+			}
+		}
+	}
+
+	return versions, nil
+}
+
+// ObjectInfo represents an object with its bucket information
+type ObjectInfo struct {
+	Bucket string
+	Key    string
+	// Size is only set by listObjects
+	Size int64
+	// VersionID and IsDeleteMarker are only set by listObjectVersions
+	VersionID      string
+	IsDeleteMarker bool
+}
+
+// checksumMetaKey is the user metadata key (x-amz-meta-checksum) holding the
+// CRC32C of the content written by this tool
+const checksumMetaKey = "checksum"
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// contentChecksum returns the hex encoded CRC32C of the content
+func contentChecksum(content []byte) string {
+	return fmt.Sprintf("%08x", crc32.Checksum(content, crc32cTable))
+}
+
+// checksumMetadata returns the user metadata stamped on every object this tool writes
+func checksumMetadata(content string) map[string]string {
+	return map[string]string{checksumMetaKey: contentChecksum([]byte(content))}
+}
+
+// Server-side encryption modes accepted by --sse
+const (
+	sseS3 = "s3"
+	sseC  = "c"
+)
+
+// decodeSSECKey decodes a base64 SSE-C key and checks it is 32 bytes long
+func decodeSSECKey(value string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("--sse-c-key must be base64-encoded: %v", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("--sse-c-key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// newServerSideEncryption builds the encryption for cfg.SSE. For SSE-C it also
+// returns the key, which is generated when cfg.SSECKey is empty.
+func newServerSideEncryption(cfg Config) (encrypt.ServerSide, []byte, error) {
+	switch cfg.SSE {
+	case sseS3:
+		return encrypt.NewSSE(), nil, nil
+	case sseC:
+		key := make([]byte, 32)
+		if cfg.SSECKey != "" {
+			var err error
+			if key, err = decodeSSECKey(cfg.SSECKey); err != nil {
+				return nil, nil, err
+			}
+		} else if _, err := rand.Read(key); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate SSE-C key: %v", err)
+		}
+		sse, err := encrypt.NewSSEC(key)
+		return sse, key, err
+	}
+	return nil, nil, nil
+}
+
+// readEncryption returns the encryption to send with GET requests. Only SSE-C
+// objects need the key on reads; SSE-S3 is transparent.
+func (m *MinioClient) readEncryption() encrypt.ServerSide {
+	if m.sse != nil && m.sse.Type() == encrypt.SSEC {
+		return m.sse
+	}
+	return nil
+}
+
+// explainEncryptionError adds a hint when the server rejected the requested
+// encryption, e.g. SSE-S3 without a KMS or SSE-C over plain HTTP
+func (m *MinioClient) explainEncryptionError(err error) error {
+	if m.sse == nil {
+		return err
+	}
+	switch minio.ToErrorResponse(err).Code {
+	case "NotImplemented", "InsecureSSECustomerRequest", "InvalidEncryptionAlgorithmError", "KMSNotConfigured":
+		return fmt.Errorf("%w (server rejected --sse %s; check that the server supports this encryption)", err, m.config.SSE)
+	}
+	return err
+}
+
+// maxMetadataCount keeps the random entries well below the 2KiB S3 limit on
+// user metadata
+const maxMetadataCount = 50
+
+// objectMetadata returns the checksum and run ID metadata plus
+// --metadata-count random entries for an upload of content
+func (m *MinioClient) objectMetadata(content string) map[string]string {
+	metadata := checksumMetadata(content)
+	maps.Copy(metadata, m.runMetadata())
+	for size := len(metadata) + m.config.MetadataCount; len(metadata) < size; {
+		metadata["attr-"+m.randomString(8)] = m.randomString(16)
+	}
+	return metadata
+}
+
+// runIDMetaKey is the user metadata key (x-amz-meta-run-id) holding the
+// --run-id of the run that created an object
+const runIDMetaKey = "run-id"
+
+// maxRunIDLength keeps run IDs well within the S3 metadata size limit
+const maxRunIDLength = 128
+
+// validRunID reports whether id can be sent as a metadata value as is
+func validRunID(id string) bool {
+	if len(id) > maxRunIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// runMetadata returns the run ID metadata for objects created by this run,
+// nil when no run ID is set
+func (m *MinioClient) runMetadata() map[string]string {
+	if m.config.RunID == "" {
+		return nil
+	}
+	return map[string]string{runIDMetaKey: m.config.RunID}
+}
+
+// runIDOf returns the run ID stored in the user metadata of an object
+func runIDOf(metadata map[string]string) string {
+	for key, value := range metadata {
+		if strings.EqualFold(key, runIDMetaKey) {
+			return value
+		}
+	}
+	return ""
+}
+
+// randomString returns n random lowercase letters and digits
+func (m *MinioClient) randomString(n int) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	result := make([]byte, n)
+	for i := range result {
+		index, _ := rand.Int(m.randomSource(), big.NewInt(int64(len(alphabet))))
+		result[i] = alphabet[index.Int64()]
+	}
+	return string(result)
+}
+
+// verifyChecksum compares the checksum of the content read from obj against the
+// one stored in its metadata. Objects without the checksum (not written by this
+// tool) pass.
+func (m *MinioClient) verifyChecksum(obj *minio.Object, actual string) error {
+	info, err := obj.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat object: %v", err)
+	}
+
+	for key, expected := range info.UserMetadata {
+		if strings.EqualFold(key, checksumMetaKey) {
+			if actual != expected {
+				return fmt.Errorf("checksum mismatch, expected %s, got %s", expected, actual)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// Default depth range of generated prefixes, and the deepest one allowed
+const (
+	defaultPrefixDepthMin = 2
+	defaultPrefixDepthMax = 4
+	maxPrefixDepth        = 32
+)
+
+// defaultPrefixWords is the vocabulary of generated prefixes when --prefix-words
+// is unset, one word list per directory level
+var defaultPrefixWords = [][]string{
+	{"data", "logs", "backup", "temp", "cache", "media"},
+	{"2025", "2024", "2023", "batch-001", "batch-002", "user-001", "user-002", "session-a", "session-b"},
+	{"09", "10", "11", "q1", "q2", "q3", "daily", "weekly", "monthly"},
+	{"30", "01", "15", "prod", "test", "dev", "staging"},
+}
+
+// loadPrefixWords reads a --prefix-words file. Each non-empty line that doesn't
+// start with # lists the words of one directory level, separated by commas or
+// spaces.
+func loadPrefixWords(path string) ([][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var levels [][]string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+		if len(words) == 0 {
+			return nil, fmt.Errorf("line %d: no words", i+1)
+		}
+		for _, word := range words {
+			if strings.Contains(word, "/") {
+				return nil, fmt.Errorf("line %d: word %q must not contain a slash", i+1, word)
+			}
+		}
+		levels = append(levels, words)
+	}
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("%s has no words", path)
+	}
+	return levels, nil
+}
+
+// generateRandomPrefix returns a directory-like prefix such as data/2025/09/30/
+// with --prefix-depth-min to --prefix-depth-max levels and a trailing slash
+func (m *MinioClient) generateRandomPrefix() string {
+	words := m.prefixWords
+	if len(words) == 0 {
+		words = defaultPrefixWords
+	}
+	minDepth, maxDepth := m.config.PrefixDepthMin, m.config.PrefixDepthMax
+	if maxDepth < 1 {
+		// Clients built without the flags use the defaults
+		minDepth, maxDepth = defaultPrefixDepthMin, defaultPrefixDepthMax
+	}
+
+	depth, _ := rand.Int(m.randomSource(), big.NewInt(int64(maxDepth-minDepth+1)))
+	parts := make([]string, minDepth+int(depth.Int64()))
+	for i := range parts {
+		// Levels past the end of the vocabulary reuse its last word list
+		level := words[min(i, len(words)-1)]
+		index, _ := rand.Int(m.randomSource(), big.NewInt(int64(len(level))))
+		parts[i] = level[index.Int64()]
+	}
+	return strings.Join(parts, "/") + "/"
+}
+
+func (m *MinioClient) generateRandomTags() map[string]string {
+	// Generate 1-3 tags with distinct keys like env=prod or team=storage
+	tagValues := map[string][]string{
+		"env":      {"prod", "staging", "dev", "test"},
+		"team":     {"storage", "analytics", "platform", "audit"},
+		"retain":   {"30d", "90d", "1y", "forever"},
+		"class":    {"hot", "warm", "cold"},
+		"origin":   {"generate-s3-data", "import", "backup"},
+		"reviewed": {"true", "false"},
+	}
+	keys := make([]string, 0, len(tagValues))
+	for key := range tagValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	count, _ := rand.Int(m.randomSource(), big.NewInt(3))
+	result := make(map[string]string)
+	for len(result) < int(count.Int64())+1 {
+		keyIndex, _ := rand.Int(m.randomSource(), big.NewInt(int64(len(keys))))
+		key := keys[keyIndex.Int64()]
+		values := tagValues[key]
+		valueIndex, _ := rand.Int(m.randomSource(), big.NewInt(int64(len(values))))
+		result[key] = values[valueIndex.Int64()]
+	}
+	return result
+}
+
+// generateRetention picks a random retention mode and a retain-until date
+// 1 to 60 minutes ahead, short enough that test data becomes deletable again
+func (m *MinioClient) generateRetention() (minio.RetentionMode, time.Time) {
+	modes := []minio.RetentionMode{minio.Governance, minio.Compliance}
+	modeIndex, _ := rand.Int(m.randomSource(), big.NewInt(int64(len(modes))))
+	minutes, _ := rand.Int(m.randomSource(), big.NewInt(60))
+	retainUntil := time.Now().Add(time.Duration(minutes.Int64()+1) * time.Minute).UTC().Truncate(time.Second)
+	return modes[modeIndex.Int64()], retainUntil
+}
+
+// nameStamp returns the part of an object name after --prefix: the time with
+// milliseconds, or with --seed a sequence number so the names repeat on a rerun
+func (m *MinioClient) nameStamp() string {
+	if m.random != nil {
+		return fmt.Sprintf("seq-%08d", m.nameSequence.Add(1))
+	}
+	now := time.Now()
+	return fmt.Sprintf("%s-%03d", now.Format("2006-01-02T15-04-05"), now.Nanosecond()/1000000)
+}
+
+func (m *MinioClient) generateObjectName() string {
+	randomPrefix := m.generateRandomPrefix()
+	timestamp := m.nameStamp()
+	randomNum, _ := rand.Int(m.randomSource(), big.NewInt(10000))
+	return fmt.Sprintf("%s%s-%s-%d", randomPrefix, m.config.ObjectPrefix, timestamp, randomNum.Int64())
+}
+
+func (m *MinioClient) generateMultipartObjectName() string {
+	randomPrefix := m.generateRandomPrefix()
+	timestamp := m.nameStamp()
+	randomNum, _ := rand.Int(m.randomSource(), big.NewInt(10000))
+	return fmt.Sprintf("%s%s-%s-%d-m", randomPrefix, m.config.ObjectPrefix, timestamp, randomNum.Int64())
+}
+
+func (m *MinioClient) generateRandomContent() string {
+	sizes := []int{100, 500, 1024, 2048, 5120} // Different content sizes
+	sizeIndex, _ := rand.Int(m.randomSource(), big.NewInt(int64(len(sizes))))
+	size := sizes[sizeIndex.Int64()]
+
+	content := make([]byte, size)
+	for i := range content {
+		char, _ := rand.Int(m.randomSource(), big.NewInt(26))
+		content[i] = byte('a' + char.Int64())
+	}
+
+	return string(content)
+}
+
+// sourceFile is a file from --source-dir that can be uploaded as object content
+type sourceFile struct {
+	path string
+	size int64
+}
+
+// loadSourceFiles lists the non-empty regular files under dir, including subdirectories
+func loadSourceFiles(dir string) ([]sourceFile, error) {
+	var files []sourceFile
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() > 0 {
+			files = append(files, sourceFile{path: path, size: info.Size()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no non-empty files found in %s", dir)
+	}
+	return files, nil
+}
+
+// pickSourceFile returns a random source file of at least minSize bytes, or
+// nil when --source-dir is unset or has no file that large
+func (m *MinioClient) pickSourceFile(minSize int64) (*sourceFile, error) {
+	var candidates []sourceFile
+	for _, file := range m.sourceFiles {
+		if file.size >= minSize {
+			candidates = append(candidates, file)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	index, err := rand.Int(m.randomSource(), big.NewInt(int64(len(candidates))))
+	if err != nil {
+		return nil, err
+	}
+	return &candidates[index.Int64()], nil
+}
+
+func (f *sourceFile) read() (string, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read source file: %v", err)
+	}
+	return string(data), nil
+}
+
+// contentType guesses the Content-Type from the file extension
+func (f *sourceFile) contentType() string {
+	if contentType := mime.TypeByExtension(filepath.Ext(f.path)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// contentType returns the Content-Type of an upload of source, which is nil
+// for generated content: --content-type when set, otherwise the type guessed
+// from the file, or empty to keep the client default
+func (m *MinioClient) contentType(source *sourceFile) string {
+	if m.config.ContentType != "" {
+		return m.config.ContentType
+	}
+	if source != nil {
+		return source.contentType()
+	}
+	return ""
+}
+
+// logAttrs names the file for the operation log, empty for generated content
+func (f *sourceFile) logAttrs() []any {
+	if f == nil {
+		return nil
+	}
+	return []any{"source", filepath.Base(f.path)}
+}
+
+func (m *MinioClient) generateVeryLargeContent() string {
+	// Generate very large content for guaranteed multipart uploads
+	return patternContent(int(m.config.MultipartSize))
+}
+
+// patternContent returns size bytes of a repeating alphanumeric pattern
+func patternContent(size int) string {
+	content := make([]byte, size)
+
+	// Use a more efficient approach for very large content
+	pattern := []byte("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
+	patternLen := len(pattern)
+
+	for i := 0; i < size; i++ {
+		content[i] = pattern[i%patternLen]
+	}
+
+	return string(content)
+}
+
+func (m *MinioClient) printStats(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats := m.stats.Snapshot()
+			if m.config.Output == outputJSON {
+				m.printJSONStats(stats, false)
+				continue
+			}
+			logger.Info("stats", "read", stats.ReadOps, "rangeRead", stats.RangeReadOps, "write", stats.WriteOps, "overwrite", stats.OverwriteOps,
+				"delete", stats.DeleteOps, "prefixDelete", stats.PrefixDeleteOps, "multipart", stats.MultipartOps, "tag", stats.TagOps, "copy", stats.CopyOps, "compose", stats.ComposeOps,
+				"stat", stats.StatOps, "list", stats.ListOps, "presignPut", stats.PresignPutOps, "presignGet", stats.PresignGetOps,
+				"abortMultipart", stats.AbortMultipartOps, "abandonMultipart", stats.AbandonMultipartOps, "versionDelete", stats.VersionDeleteOps,
+				"retention", stats.RetentionOps, "legalHold", stats.LegalHoldOps, "locked", stats.LockedOps, "retried", stats.RetriedOps, "errors", stats.ErrorOps, "timeouts", stats.TimeoutOps)
+		}
+	}
+}
+
+func (m *MinioClient) printFinalStats() {
+	stats := m.stats.Snapshot()
+	if m.config.Output == outputJSON {
+		m.printJSONStats(stats, true)
+		return
+	}
+
+	switch {
+	case m.config.DryRun:
+		fmt.Println("\nFinal Statistics (dry run, no requests were sent):")
+	case m.aborted.Load():
+		fmt.Println("\nFinal Statistics (stopped early by --max-error-rate):")
+	default:
+		fmt.Println("\nFinal Statistics:")
+	}
+	if m.config.RunID != "" {
+		fmt.Printf("Run ID:                  %s\n", m.config.RunID)
+	}
+	fmt.Printf("Read Operations:         %d\n", stats.ReadOps)
+	fmt.Printf("Range Read Operations:   %d\n", stats.RangeReadOps)
+	fmt.Printf("Write Operations:        %d\n", stats.WriteOps)
+	fmt.Printf("Overwrite Operations:    %d\n", stats.OverwriteOps)
+	fmt.Printf("Delete Operations:       %d\n", stats.DeleteOps)
+	fmt.Printf("Prefix Delete Operations:%d\n", stats.PrefixDeleteOps)
+	fmt.Printf("Multipart Operations:    %d\n", stats.MultipartOps)
+	fmt.Printf("Tag Operations:          %d\n", stats.TagOps)
+	fmt.Printf("Copy Operations:         %d\n", stats.CopyOps)
+	fmt.Printf("Compose Operations:      %d\n", stats.ComposeOps)
+	fmt.Printf("Stat Operations:         %d\n", stats.StatOps)
+	fmt.Printf("List Operations:         %d\n", stats.ListOps)
+	fmt.Printf("Presigned PUT Operations:%d\n", stats.PresignPutOps)
+	fmt.Printf("Presigned GET Operations:%d\n", stats.PresignGetOps)
+	fmt.Printf("Abort Multipart Ops:     %d\n", stats.AbortMultipartOps)
+	if m.config.AbandonMultipart {
+		fmt.Printf("Abandon Multipart Ops:   %d\n", stats.AbandonMultipartOps)
+	}
+	if m.versionedBuckets() {
+		fmt.Printf("Version Delete Ops:      %d\n", stats.VersionDeleteOps)
+	}
+	if m.config.ObjectLock {
+		fmt.Printf("Retention Operations:    %d\n", stats.RetentionOps)
+		fmt.Printf("Legal Hold Operations:   %d\n", stats.LegalHoldOps)
+		fmt.Printf("Locked (expected):       %d\n", stats.LockedOps)
+	}
+	if m.config.MaxRetries > 0 {
+		fmt.Printf("Retried Operations:      %d\n", stats.RetriedOps)
+	}
+	fmt.Printf("Error Operations:        %d\n", stats.ErrorOps)
+	if m.config.OpTimeout > 0 {
+		fmt.Printf("Timed Out Operations:    %d\n", stats.TimeoutOps)
+	}
+	if m.config.Verify {
+		fmt.Printf("Verify Failures:         %d\n", stats.VerifyFailureOps)
+	}
+	if m.config.ConsistencyCheck {
+		fmt.Printf("Consistency Failures:    %d\n", stats.ConsistencyFailures)
+	}
+	fmt.Printf("Total Operations:        %d\n", stats.Total())
+	fmt.Printf("Bytes Written:           %s\n", humanize.IBytes(uint64(stats.BytesWritten)))
+	fmt.Printf("Bytes Read:              %s\n", humanize.IBytes(uint64(stats.BytesRead)))
+	fmt.Printf("Throughput:              %.2f MiB/s\n", stats.throughput(time.Since(m.startTime)))
+	if m.config.MaxObjects > 0 {
+		fmt.Printf("Net Objects Created:     %d\n", atomic.LoadInt64(&m.netObjects))
+	}
+	m.printOutcomeTable()
+	m.printEndpointTable()
+	m.printLatencyTable()
+}
+
+// printOutcomeTable prints the successes, failures and success rate of each
+// operation that ran
+func (m *MinioClient) printOutcomeTable() {
+	summaries := m.outcomes.Summaries()
+	if len(summaries) == 0 {
+		return
+	}
+
+	fmt.Println("\nOperations:")
+	fmt.Printf("%-15s %10s %10s %10s\n", "OPERATION", "SUCCEEDED", "FAILED", "SUCCESS %")
+	for _, name := range registeredOperations() {
+		summary, ok := summaries[name]
+		if !ok {
+			continue
+		}
+		fmt.Printf("%-15s %10d %10d %10.1f\n", name, summary.Succeeded, summary.Failed, summary.SuccessRate)
+	}
+}
+
+// endpointReport is the --endpoint-stats breakdown of one endpoint
+type endpointReport struct {
+	Endpoint string `json:"endpoint"`
+	Ops      int64  `json:"ops"`
+	Errors   int64  `json:"errors"`
+}
+
+// endpointReports returns the attempts per endpoint, nil without --endpoint-stats
+func (m *MinioClient) endpointReports() []endpointReport {
+	if m.endpointStats == nil {
+		return nil
+	}
+	reports := make([]endpointReport, len(m.endpointStats))
+	for i := range m.endpointStats {
+		reports[i] = endpointReport{
+			Endpoint: m.endpoints[i],
+			Ops:      atomic.LoadInt64(&m.endpointStats[i].Ops),
+			Errors:   atomic.LoadInt64(&m.endpointStats[i].Errors),
+		}
+	}
+	return reports
+}
+
+// printEndpointTable prints the successful and failed attempts of each
+// endpoint with --endpoint-stats. Retries are counted on the endpoint they
+// went to, so the errors can exceed Error Operations.
+func (m *MinioClient) printEndpointTable() {
+	reports := m.endpointReports()
+	if len(reports) == 0 {
+		return
+	}
+
+	fmt.Println("\nEndpoints:")
+	fmt.Printf("%-30s %10s %10s\n", "ENDPOINT", "OPS", "ERRORS")
+	for _, r := range reports {
+		fmt.Printf("%-30s %10d %10d\n", r.Endpoint, r.Ops, r.Errors)
+	}
+}
+
+// printLatencyTable prints the S3 call latency percentiles of each operation that ran
+func (m *MinioClient) printLatencyTable() {
+	summaries := m.latency.Summaries()
+	if len(summaries) == 0 {
+		return
+	}
+
+	fmt.Println("\nLatency (ms):")
+	fmt.Printf("%-15s %10s %10s %10s %10s %10s\n", "OPERATION", "COUNT", "P50", "P90", "P99", "MAX")
+	for _, name := range registeredOperations() {
+		summary, ok := summaries[name]
+		if !ok {
+			continue
+		}
+		fmt.Printf("%-15s %10d %10.1f %10.1f %10.1f %10.1f\n", name, summary.Count, summary.P50, summary.P90, summary.P99, summary.Max)
+	}
+}
+
+// metricsRegistry exposes the Stats counters as Prometheus metrics. The
+// values are read from the shared Stats on every scrape.
+func (m *MinioClient) metricsRegistry() *prometheus.Registry {
+	counters := []struct {
+		name  string
+		help  string
+		value func(Stats) int64
+	}{
+		{"minio_gen_read_ops_total", "Successful read operations", func(s Stats) int64 { return s.ReadOps }},
+		{"minio_gen_range_read_ops_total", "Successful ranged (partial) read operations", func(s Stats) int64 { return s.RangeReadOps }},
+		{"minio_gen_write_ops_total", "Successful write operations", func(s Stats) int64 { return s.WriteOps }},
+		{"minio_gen_overwrite_ops_total", "Successful overwrite operations", func(s Stats) int64 { return s.OverwriteOps }},
+		{"minio_gen_delete_ops_total", "Successful delete operations", func(s Stats) int64 { return s.DeleteOps }},
+		{"minio_gen_prefix_delete_ops_total", "Successful prefix delete operations", func(s Stats) int64 { return s.PrefixDeleteOps }},
+		{"minio_gen_multipart_ops_total", "Successful multipart upload operations", func(s Stats) int64 { return s.MultipartOps }},
+		{"minio_gen_tag_ops_total", "Successful object tagging operations", func(s Stats) int64 { return s.TagOps }},
+		{"minio_gen_copy_ops_total", "Successful server-side copy operations", func(s Stats) int64 { return s.CopyOps }},
+		{"minio_gen_compose_ops_total", "Successful server-side compose operations", func(s Stats) int64 { return s.ComposeOps }},
+		{"minio_gen_stat_ops_total", "Successful stat (HEAD) operations", func(s Stats) int64 { return s.StatOps }},
+		{"minio_gen_list_ops_total", "Successful recursive listings", func(s Stats) int64 { return s.ListOps }},
+		{"minio_gen_presign_put_ops_total", "Successful uploads over a presigned PUT URL", func(s Stats) int64 { return s.PresignPutOps }},
+		{"minio_gen_presign_get_ops_total", "Successful downloads over a presigned GET URL", func(s Stats) int64 { return s.PresignGetOps }},
+		{"minio_gen_abort_multipart_ops_total", "Incomplete multipart uploads aborted", func(s Stats) int64 { return s.AbortMultipartOps }},
+		{"minio_gen_abandon_multipart_ops_total", "Multipart uploads deliberately left incomplete", func(s Stats) int64 { return s.AbandonMultipartOps }},
+		{"minio_gen_version_delete_ops_total", "Successful deletions of a specific object version", func(s Stats) int64 { return s.VersionDeleteOps }},
+		{"minio_gen_retention_ops_total", "Successful object retention updates", func(s Stats) int64 { return s.RetentionOps }},
+		{"minio_gen_legal_hold_ops_total", "Successful object legal hold updates", func(s Stats) int64 { return s.LegalHoldOps }},
+		{"minio_gen_retried_ops_total", "Operations that succeeded after at least one retry", func(s Stats) int64 { return s.RetriedOps }},
+		{"minio_gen_locked_ops_total", "Operations refused because the object is locked", func(s Stats) int64 { return s.LockedOps }},
+		{"minio_gen_error_ops_total", "Failed operations", func(s Stats) int64 { return s.ErrorOps }},
+		{"minio_gen_timeout_ops_total", "Failed operations whose last attempt exceeded --op-timeout", func(s Stats) int64 { return s.TimeoutOps }},
+		{"minio_gen_verify_failure_ops_total", "Reads whose content did not match the stored checksum", func(s Stats) int64 { return s.VerifyFailureOps }},
+		{"minio_gen_consistency_failures_total", "Written objects missing or different when read back", func(s Stats) int64 { return s.ConsistencyFailures }},
+		{"minio_gen_written_bytes_total", "Object payload bytes of successful uploads", func(s Stats) int64 { return s.BytesWritten }},
+		{"minio_gen_read_bytes_total", "Object payload bytes of successful downloads", func(s Stats) int64 { return s.BytesRead }},
+	}
+
+	registry := prometheus.NewRegistry()
+	for _, c := range counters {
+		value := c.value
+		registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: c.name,
+			Help: c.help,
+		}, func() float64 {
+			return float64(value(m.stats.Snapshot()))
+		}))
+	}
+	return registry
+}
+
+// startMetricsServer serves /metrics on addr until ctx is cancelled. It returns
+// the bound address and a channel that is closed once the server has shut down.
+func (m *MinioClient) startMetricsServer(ctx context.Context, addr string) (net.Addr, <-chan struct{}, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.metricsRegistry(), promhttp.HandlerOpts{}))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	done := make(chan struct{})
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Error("metrics server failed", "error", err)
+		}
+	}()
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Error("metrics server shutdown failed", "error", err)
+		}
+	}()
+
+	return listener.Addr(), done, nil
+}
+
+// Latency histogram buckets grow geometrically from latencyMin, so every
+// percentile is accurate to within latencyGrowth (2%) without storing samples
+const (
+	latencyMin    = 10 * time.Microsecond
+	latencyGrowth = 1.02
+)
+
+// latencyHistogram counts durations in log-scaled buckets, in the style of an
+// HDR histogram
+type latencyHistogram struct {
+	buckets []int64
+	count   int64
+	max     time.Duration
+}
+
+func latencyBucket(d time.Duration) int {
+	if d <= latencyMin {
+		return 0
+	}
+	return int(math.Ceil(math.Log(float64(d)/float64(latencyMin)) / math.Log(latencyGrowth)))
+}
+
+// latencyBucketUpper is the largest duration counted in bucket i
+func latencyBucketUpper(i int) time.Duration {
+	return time.Duration(float64(latencyMin) * math.Pow(latencyGrowth, float64(i)))
+}
+
+func (h *latencyHistogram) record(d time.Duration) {
+	bucket := latencyBucket(d)
+	if bucket >= len(h.buckets) {
+		h.buckets = append(h.buckets, make([]int64, bucket-len(h.buckets)+1)...)
+	}
+	h.buckets[bucket]++
+	h.count++
+	if d > h.max {
+		h.max = d
+	}
+}
+
+// quantile returns the duration below which the fraction q of samples fall
+func (h *latencyHistogram) quantile(q float64) time.Duration {
+	target := int64(math.Ceil(q * float64(h.count)))
+	var seen int64
+	for i, count := range h.buckets {
+		seen += count
+		if seen >= target && count > 0 {
+			if upper := latencyBucketUpper(i); upper < h.max {
+				return upper
+			}
+			return h.max
+		}
+	}
+	return h.max
+}
+
+// latencySummary is the reported form of a histogram, in milliseconds
+type latencySummary struct {
+	Count int64   `json:"count"`
+	P50   float64 `json:"p50Ms"`
+	P90   float64 `json:"p90Ms"`
+	P99   float64 `json:"p99Ms"`
+	Max   float64 `json:"maxMs"`
+}
+
+func (h *latencyHistogram) summary() latencySummary {
+	milliseconds := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	return latencySummary{
+		Count: h.count,
+		P50:   milliseconds(h.quantile(0.50)),
+		P90:   milliseconds(h.quantile(0.90)),
+		P99:   milliseconds(h.quantile(0.99)),
+		Max:   milliseconds(h.max),
+	}
+}
+
+// latencyRecorder keeps one histogram per operation name and is safe for
+// concurrent use by the workers
+type latencyRecorder struct {
+	mu         sync.Mutex
+	histograms map[string]*latencyHistogram
+}
+
+func newLatencyRecorder() *latencyRecorder {
+	return &latencyRecorder{histograms: make(map[string]*latencyHistogram)}
+}
+
+func (r *latencyRecorder) Record(operation string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.histograms[operation]
+	if !ok {
+		h = &latencyHistogram{}
+		r.histograms[operation] = h
+	}
+	h.record(d)
+}
+
+// Summaries returns the percentiles of every operation recorded so far
+func (r *latencyRecorder) Summaries() map[string]latencySummary {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	summaries := make(map[string]latencySummary, len(r.histograms))
+	for operation, h := range r.histograms {
+		summaries[operation] = h.summary()
+	}
+	return summaries
+}
+
+// Overall returns the percentiles of all operations recorded so far together
+func (r *latencyRecorder) Overall() latencySummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	merged := &latencyHistogram{}
+	for _, h := range r.histograms {
+		if len(h.buckets) > len(merged.buckets) {
+			merged.buckets = append(merged.buckets, make([]int64, len(h.buckets)-len(merged.buckets))...)
+		}
+		for i, count := range h.buckets {
+			merged.buckets[i] += count
+		}
+		merged.count += h.count
+		merged.max = max(merged.max, h.max)
+	}
+	return merged.summary()
+}
+
+// operationOutcome counts how often an operation succeeded and failed.
+// SuccessRate is the percentage of succeeded runs.
+type operationOutcome struct {
+	Succeeded   int64   `json:"succeeded"`
+	Failed      int64   `json:"failed"`
+	SuccessRate float64 `json:"successRate"`
+}
+
+// outcomeRecorder keeps the outcome counts per operation name and is safe for
+// concurrent use by the workers
+type outcomeRecorder struct {
+	mu       sync.Mutex
+	outcomes map[string]*operationOutcome
+}
+
+func newOutcomeRecorder() *outcomeRecorder {
+	return &outcomeRecorder{outcomes: make(map[string]*operationOutcome)}
+}
+
+// Record counts one run of operation, failed when err is set
+func (r *outcomeRecorder) Record(operation string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.outcomes[operation]
+	if !ok {
+		o = &operationOutcome{}
+		r.outcomes[operation] = o
+	}
+	if err != nil {
+		o.Failed++
+	} else {
+		o.Succeeded++
+	}
+}
+
+// Summaries returns the outcome counts and success rate of every operation
+// recorded so far
+func (r *outcomeRecorder) Summaries() map[string]operationOutcome {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	summaries := make(map[string]operationOutcome, len(r.outcomes))
+	for operation, o := range r.outcomes {
+		summary := *o
+		summary.SuccessRate = 100 * float64(o.Succeeded) / float64(o.Succeeded+o.Failed)
+		summaries[operation] = summary
+	}
+	return summaries
+}
+
+// recordOutcome counts the final result of a scheduled operation, after any
+// retries
+func (m *MinioClient) recordOutcome(operation string, err error) {
+	if m.outcomes != nil {
+		m.outcomes.Record(operation, err)
+	}
+}
+
+// recordCall records the latency of a successful S3 call of operation started
+// at start and logs it to --log-csv
+func (m *MinioClient) recordCall(operation, bucket, key string, size int64, start time.Time) {
+	elapsed := time.Since(start)
+	if m.latency != nil {
+		m.latency.Record(operation, elapsed)
+	}
+	m.logOperation(operationRecord{
+		Start:     start,
+		Operation: operation,
+		Bucket:    bucket,
+		Key:       key,
+		Size:      size,
+		Duration:  elapsed,
+	})
+}
+
+// operationLogHeader is the first row of a new --log-csv file
+var operationLogHeader = []string{"timestamp", "operation", "bucket", "key", "size", "duration_ms", "error"}
+
+// operationRecord is one row of the --log-csv file
+type operationRecord struct {
+	Start     time.Time
+	Operation string
+	Bucket    string
+	Key       string
+	Size      int64
+	Duration  time.Duration
+	Err       error
+}
+
+// operationLog appends operationRecords to a CSV file. Rows are buffered and
+// written out when the buffer fills and on Close. It is safe for concurrent
+// use by the workers.
+type operationLog struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *csv.Writer
+}
+
+// openOperationLog opens path for appending, writing the header row if the
+// file is new or empty
+func openOperationLog(path string) (*operationLog, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	l := &operationLog{
+		file:   file,
+		writer: csv.NewWriter(bufio.NewWriterSize(file, 64*1024)),
+	}
+	if info.Size() == 0 {
+		if err := l.writer.Write(operationLogHeader); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// Record appends r. Write errors stick to the writer and are reported by Close.
+func (l *operationLog) Record(r operationRecord) {
+	errorText := ""
+	if r.Err != nil {
+		errorText = r.Err.Error()
+	}
+	row := []string{
+		r.Start.Format(time.RFC3339Nano),
+		r.Operation,
+		r.Bucket,
+		r.Key,
+		strconv.FormatInt(r.Size, 10),
+		strconv.FormatFloat(float64(r.Duration)/float64(time.Millisecond), 'f', 3, 64),
+		errorText,
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.writer.Write(row)
+}
+
+// Close flushes the buffered rows and closes the file
+func (l *operationLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.writer.Flush()
+	if err := l.writer.Error(); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
+
+// logOperation appends r to --log-csv when it is set
+func (m *MinioClient) logOperation(r operationRecord) {
+	if m.operationLog != nil {
+		m.operationLog.Record(r)
+	}
+}
+
+// runManifest is the --manifest summary of a finished run, meant to be
+// archived with the test results
+type runManifest struct {
+	RunID          string    `json:"runId"`
+	StartTime      time.Time `json:"startTime"`
+	EndTime        time.Time `json:"endTime"`
+	ElapsedSeconds float64   `json:"elapsedSeconds"`
+	DryRun         bool      `json:"dryRun,omitempty"`
+	// Aborted is set when --max-error-rate stopped the run
+	Aborted bool `json:"aborted,omitempty"`
+	// Config holds the effective settings under their flag names, with the
+	// secrets redacted
+	Config          map[string]any              `json:"config"`
+	Stats           Stats                       `json:"stats"`
+	TotalOps        int64                       `json:"totalOps"`
+	ThroughputMiBps float64                     `json:"throughputMiBps"`
+	Operations      map[string]operationOutcome `json:"operations,omitempty"`
+	Endpoints       []endpointReport            `json:"endpoints,omitempty"`
+	Latency         map[string]latencySummary   `json:"latency,omitempty"`
+}
+
+// writeManifest writes the manifest of a run that ended at end to path. The
+// file is replaced atomically, so it is either complete or absent.
+func (m *MinioClient) writeManifest(path string, end time.Time) error {
+	// The yaml round trip gives the flag names and readable durations and sizes
+	data, err := yaml.Marshal(redactConfig(m.config))
+	if err != nil {
+		return fmt.Errorf("failed to encode the configuration: %v", err)
+	}
+	var settings map[string]any
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to encode the configuration: %v", err)
+	}
+
+	stats := m.stats.Snapshot()
+	elapsed := end.Sub(m.startTime)
+	manifest := runManifest{
+		RunID:           m.config.RunID,
+		StartTime:       m.startTime,
+		EndTime:         end,
+		ElapsedSeconds:  elapsed.Seconds(),
+		DryRun:          m.config.DryRun,
+		Aborted:         m.aborted.Load(),
+		Config:          settings,
+		Stats:           stats,
+		TotalOps:        stats.Total(),
+		ThroughputMiBps: stats.throughput(elapsed),
+		Operations:      m.outcomes.Summaries(),
+		Endpoints:       m.endpointReports(),
+		Latency:         m.latency.Summaries(),
+	}
+	data, err = json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the manifest: %v", err)
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// over path, so readers never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// statsReport is the JSON form of the statistics, printed as one line per
+// periodic tick and once more with Final set when the run ends
+type statsReport struct {
+	// RunID is the --run-id stamped on the objects of this run
+	RunID string `json:"runId,omitempty"`
+	Stats
+	TotalOps       int64   `json:"totalOps"`
+	ElapsedSeconds float64 `json:"elapsedSeconds"`
+	OpsPerSecond   float64 `json:"opsPerSecond"`
+	// ThroughputMiBps is BytesWritten plus BytesRead per second of the run
+	ThroughputMiBps float64 `json:"throughputMiBps"`
+	Final           bool    `json:"final"`
+	DryRun          bool    `json:"dryRun,omitempty"`
+	// Aborted is set on the final report when --max-error-rate stopped the run
+	Aborted bool `json:"aborted,omitempty"`
+	// Latency holds the S3 call latency percentiles per operation
+	Latency map[string]latencySummary `json:"latency,omitempty"`
+	// Operations holds the successes and failures per scheduled operation
+	Operations map[string]operationOutcome `json:"operations,omitempty"`
+	// Endpoints holds the attempts per endpoint with --endpoint-stats
+	Endpoints []endpointReport `json:"endpoints,omitempty"`
+}
+
+func (m *MinioClient) printJSONStats(stats Stats, final bool) {
+	elapsed := time.Since(m.startTime)
+	report := statsReport{
+		RunID:           m.config.RunID,
+		Stats:           stats,
+		TotalOps:        stats.Total(),
+		ElapsedSeconds:  elapsed.Seconds(),
+		ThroughputMiBps: stats.throughput(elapsed),
+		Final:           final,
+		DryRun:          m.config.DryRun,
+		Aborted:         m.aborted.Load(),
+		Latency:         m.latency.Summaries(),
+		Operations:      m.outcomes.Summaries(),
+		Endpoints:       m.endpointReports(),
+	}
+	if elapsed > 0 {
+		report.OpsPerSecond = float64(report.TotalOps) / elapsed.Seconds()
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		logger.Error("failed to encode stats", "error", err)
+		return
+	}
+	fmt.Println(string(data))
+}
+
+// benchmarkReport is the summary printed by the benchmark command. Latencies
+// are those of the successful S3 calls of all operations together.
+type benchmarkReport struct {
+	RunID          string  `json:"runId"`
+	ElapsedSeconds float64 `json:"elapsedSeconds"`
+	Concurrency    int     `json:"concurrency"`
+	TotalOps       int64   `json:"totalOps"`
+	ErrorOps       int64   `json:"errorOps"`
+	OpsPerSecond   float64 `json:"opsPerSecond"`
+	// BytesPerSecond is BytesWritten plus BytesRead per second of the run
+	BytesPerSecond float64 `json:"bytesPerSecond"`
+	P50            float64 `json:"p50Ms"`
+	P99            float64 `json:"p99Ms"`
+}
+
+// benchmarkReport summarizes a benchmark that ran for elapsed
+func (m *MinioClient) benchmarkReport(elapsed time.Duration) benchmarkReport {
+	stats := m.stats.Snapshot()
+	latency := m.latency.Overall()
+	report := benchmarkReport{
+		RunID:          m.config.RunID,
+		ElapsedSeconds: elapsed.Seconds(),
+		Concurrency:    m.config.Concurrency,
+		TotalOps:       stats.Total(),
+		ErrorOps:       stats.ErrorOps,
+		P50:            latency.P50,
+		P99:            latency.P99,
+	}
+	if elapsed > 0 {
+		report.OpsPerSecond = float64(report.TotalOps) / elapsed.Seconds()
+		report.BytesPerSecond = float64(stats.BytesWritten+stats.BytesRead) / elapsed.Seconds()
+	}
+	return report
+}
+
+// print writes the report to stdout as one JSON line or as text
+func (r benchmarkReport) print(output string) {
+	if output == outputJSON {
+		data, err := json.Marshal(r)
+		if err != nil {
+			logger.Error("failed to encode benchmark summary", "error", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("\nBenchmark (%s, %d workers):\n", time.Duration(r.ElapsedSeconds*float64(time.Second)).Round(time.Millisecond), r.Concurrency)
+	fmt.Printf("Total Operations:        %d\n", r.TotalOps)
+	fmt.Printf("Error Operations:        %d\n", r.ErrorOps)
+	fmt.Printf("Operations/s:            %.2f\n", r.OpsPerSecond)
+	fmt.Printf("Throughput:              %s/s\n", humanize.IBytes(uint64(r.BytesPerSecond)))
+	fmt.Printf("Latency p50:             %.2f ms\n", r.P50)
+	fmt.Printf("Latency p99:             %.2f ms\n", r.P99)
+}
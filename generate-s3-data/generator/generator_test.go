@@ -0,0 +1,2530 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/minio-go/v7/pkg/tags"
+	"github.com/spf13/cobra"
+	"golang.org/x/time/rate"
+)
+
+func TestConfigDefaults(t *testing.T) {
+	cfg := Config{
+		Endpoint:       "localhost:9000",
+		Buckets:        "test-bucket",
+		Duration:       0,
+		OperationDelay: 1 * time.Second,
+		ObjectPrefix:   "test-object",
+	}
+
+	if cfg.Endpoint != "localhost:9000" {
+		t.Errorf("Expected endpoint localhost:9000, got %s", cfg.Endpoint)
+	}
+
+	if cfg.Buckets != "test-bucket" {
+		t.Errorf("Expected buckets test-bucket, got %s", cfg.Buckets)
+	}
+
+	if cfg.Duration != 0 {
+		t.Errorf("Expected duration 0, got %v", cfg.Duration)
+	}
+
+	if cfg.OperationDelay != 1*time.Second {
+		t.Errorf("Expected delay 1s, got %v", cfg.OperationDelay)
+	}
+}
+
+func TestStatsInitialization(t *testing.T) {
+	stats := &Stats{}
+
+	if stats.ReadOps != 0 {
+		t.Errorf("Expected ReadOps to be 0, got %d", stats.ReadOps)
+	}
+
+	if stats.WriteOps != 0 {
+		t.Errorf("Expected WriteOps to be 0, got %d", stats.WriteOps)
+	}
+
+	if stats.OverwriteOps != 0 {
+		t.Errorf("Expected OverwriteOps to be 0, got %d", stats.OverwriteOps)
+	}
+
+	if stats.DeleteOps != 0 {
+		t.Errorf("Expected DeleteOps to be 0, got %d", stats.DeleteOps)
+	}
+
+	if stats.PrefixDeleteOps != 0 {
+		t.Errorf("Expected PrefixDeleteOps to be 0, got %d", stats.PrefixDeleteOps)
+	}
+
+	if stats.MultipartOps != 0 {
+		t.Errorf("Expected MultipartOps to be 0, got %d", stats.MultipartOps)
+	}
+
+	if stats.ErrorOps != 0 {
+		t.Errorf("Expected ErrorOps to be 0, got %d", stats.ErrorOps)
+	}
+}
+
+func TestObjectNameGeneration(t *testing.T) {
+	client := &MinioClient{
+		config: Config{ObjectPrefix: "test"},
+	}
+
+	name1 := client.generateObjectName()
+	name2 := client.generateObjectName()
+
+	if name1 == name2 {
+		t.Error("Generated object names should be unique")
+	}
+
+	if len(name1) == 0 {
+		t.Error("Generated object name should not be empty")
+	}
+}
+
+func TestGenerateRandomPrefix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("# tenants\nacme, globex\n\nsales hr\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	words, err := loadPrefixWords(path)
+	if err != nil {
+		t.Fatalf("loadPrefixWords returned error: %v", err)
+	}
+	if !reflect.DeepEqual(words, [][]string{{"acme", "globex"}, {"sales", "hr"}}) {
+		t.Fatalf("Unexpected vocabulary %q", words)
+	}
+
+	m := &MinioClient{config: Config{PrefixDepthMin: 1, PrefixDepthMax: 3}, prefixWords: words}
+	depths := make(map[int]bool)
+	for i := 0; i < 200; i++ {
+		prefix := m.generateRandomPrefix()
+		if !strings.HasSuffix(prefix, "/") {
+			t.Fatalf("Expected a trailing slash, got %q", prefix)
+		}
+		parts := strings.Split(strings.TrimSuffix(prefix, "/"), "/")
+		if len(parts) < 1 || len(parts) > 3 {
+			t.Fatalf("Expected 1-3 levels, got %q", prefix)
+		}
+		if parts[0] != "acme" && parts[0] != "globex" {
+			t.Fatalf("Expected the first level from the first line, got %q", prefix)
+		}
+		// The third level reuses the last line
+		for _, part := range parts[1:] {
+			if part != "sales" && part != "hr" {
+				t.Fatalf("Expected deeper levels from the last line, got %q", prefix)
+			}
+		}
+		depths[len(parts)] = true
+	}
+	if len(depths) != 3 {
+		t.Errorf("Expected every depth from 1 to 3, got %v", depths)
+	}
+
+	if err := os.WriteFile(path, []byte("data/logs\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPrefixWords(path); err == nil {
+		t.Error("Expected words containing a slash to be rejected")
+	}
+
+	// A level of separators only would leave nothing to pick from
+	if err := os.WriteFile(path, []byte("acme\n, ,\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPrefixWords(path); err == nil || !strings.Contains(err.Error(), "line 2: no words") {
+		t.Errorf("Expected a line without words to be rejected, got %v", err)
+	}
+}
+
+func TestRandomContentGeneration(t *testing.T) {
+	client := &MinioClient{}
+
+	content1 := client.generateRandomContent()
+	content2 := client.generateRandomContent()
+
+	if len(content1) == 0 {
+		t.Error("Generated content should not be empty")
+	}
+
+	if len(content2) == 0 {
+		t.Error("Generated content should not be empty")
+	}
+
+	// Content should be variable (different sizes or content)
+	if content1 == content2 && len(content1) == len(content2) {
+		t.Log("Warning: Generated content is identical, but this could be random chance")
+	}
+}
+
+func TestMCConfigParsing(t *testing.T) {
+	// Test with a non-existent alias
+	_, err := readMCConfig("nonexistent-alias-test")
+	if err == nil {
+		t.Error("Expected error for non-existent alias")
+	}
+
+	// The error message should mention the alias not being found
+	if !strings.Contains(err.Error(), "not found in MC config") && !strings.Contains(err.Error(), "config file not found") {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+}
+
+func TestParseBuckets(t *testing.T) {
+	client := &MinioClient{}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "single bucket",
+			input:    "bucket1",
+			expected: []string{"bucket1"},
+		},
+		{
+			name:     "multiple buckets",
+			input:    "bucket1,bucket2,bucket3",
+			expected: []string{"bucket1", "bucket2", "bucket3"},
+		},
+		{
+			name:     "buckets with spaces",
+			input:    "bucket1, bucket2 , bucket3",
+			expected: []string{"bucket1", "bucket2", "bucket3"},
+		},
+		{
+			name:     "empty string",
+			input:    "",
+			expected: []string{},
+		},
+		{
+			name:     "buckets with empty values",
+			input:    "bucket1,,bucket2,",
+			expected: []string{"bucket1", "bucket2"},
+		},
+		{
+			name:     "single bucket with trailing comma",
+			input:    "bucket1,",
+			expected: []string{"bucket1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client.config.Buckets = tt.input
+			result := client.Buckets()
+
+			if len(result) != len(tt.expected) {
+				t.Errorf("Expected %d buckets, got %d", len(tt.expected), len(result))
+				return
+			}
+
+			for i, expected := range tt.expected {
+				if result[i] != expected {
+					t.Errorf("Expected bucket[%d] to be %s, got %s", i, expected, result[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetRandomBucket(t *testing.T) {
+	client := &MinioClient{}
+
+	tests := []struct {
+		name        string
+		buckets     string
+		expectError bool
+	}{
+		{
+			name:        "single bucket",
+			buckets:     "bucket1",
+			expectError: false,
+		},
+		{
+			name:        "multiple buckets",
+			buckets:     "bucket1,bucket2,bucket3",
+			expectError: false,
+		},
+		{
+			name:        "no buckets",
+			buckets:     "",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client.config.Buckets = tt.buckets
+			bucket, err := client.getRandomBucket()
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error for empty buckets")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+
+			expectedBuckets := client.Buckets()
+			found := false
+			for _, expected := range expectedBuckets {
+				if bucket == expected {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				t.Errorf("Returned bucket %s not in expected buckets %v", bucket, expectedBuckets)
+			}
+		})
+	}
+}
+
+func TestGetRandomBucketDistribution(t *testing.T) {
+	client := &MinioClient{
+		config: Config{
+			Buckets: "bucket1,bucket2,bucket3",
+		},
+	}
+
+	// Run multiple times to check if all buckets can be selected
+	bucketCounts := make(map[string]int)
+	iterations := 100
+
+	for i := 0; i < iterations; i++ {
+		bucket, err := client.getRandomBucket()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		bucketCounts[bucket]++
+	}
+
+	// Check that all buckets were selected at least once (with high probability)
+	expectedBuckets := []string{"bucket1", "bucket2", "bucket3"}
+	for _, expected := range expectedBuckets {
+		if bucketCounts[expected] == 0 {
+			t.Errorf("Bucket %s was never selected in %d iterations", expected, iterations)
+		}
+	}
+
+	// Check that no unexpected buckets were selected
+	for bucket := range bucketCounts {
+		found := false
+		for _, expected := range expectedBuckets {
+			if bucket == expected {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Unexpected bucket %s was selected", bucket)
+		}
+	}
+}
+
+func TestObjectInfo(t *testing.T) {
+	obj := ObjectInfo{
+		Bucket: "test-bucket",
+		Key:    "test/object.txt",
+	}
+
+	if obj.Bucket != "test-bucket" {
+		t.Errorf("Expected bucket test-bucket, got %s", obj.Bucket)
+	}
+
+	if obj.Key != "test/object.txt" {
+		t.Errorf("Expected key test/object.txt, got %s", obj.Key)
+	}
+}
+
+func TestRejectedFlagCombinations(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "alias with access key", args: []string{"--alias", "local", "--access-key", "a", "--secret-key", "s"}},
+		{name: "alias with endpoint", args: []string{"--alias", "local", "--endpoint", "host:9000"}},
+		{name: "alias with ssl", args: []string{"--alias", "local", "--ssl"}},
+		{name: "access key without secret key", args: []string{"--access-key", "a"}},
+		{name: "ca cert with insecure", args: []string{"--ca-cert", "ca.pem", "--insecure"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			cmd := &cobra.Command{Use: "test"}
+			registerFlags(cmd, &cfg)
+
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("Unexpected parse error: %v", err)
+			}
+			if err := cmd.ValidateFlagGroups(); err == nil {
+				t.Errorf("Expected %v to be rejected", tt.args)
+			}
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	valid := Config{
+		Buckets:           "bucket1",
+		OperationDelay:    time.Second,
+		ObjectPrefix:      "test",
+		Concurrency:       1,
+		MultipartSize:     70 * 1024 * 1024,
+		MultipartPartSize: 5 * 1024 * 1024,
+		PresignExpiry:     15 * time.Minute,
+		PrefixDepthMin:    defaultPrefixDepthMin,
+		PrefixDepthMax:    defaultPrefixDepthMax,
+		MinSamples:        100,
+		Output:            outputText,
+		LogLevel:          "debug",
+		LogFormat:         logFormatText,
+		Endpoint:          "localhost:9000",
+	}
+	if err := validateConfig(valid); err != nil {
+		t.Fatalf("Expected valid config, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+	}{
+		{name: "negative duration", modify: func(cfg *Config) { cfg.Duration = -time.Second }},
+		{name: "zero delay", modify: func(cfg *Config) { cfg.OperationDelay = 0 }},
+		{name: "zero concurrency", modify: func(cfg *Config) { cfg.Concurrency = 0 }},
+		{name: "manifest in a missing directory", modify: func(cfg *Config) { cfg.Manifest = "/nonexistent/manifest.json" }},
+		{name: "proxy without scheme", modify: func(cfg *Config) { cfg.Proxy = "proxy.internal:3128" }},
+		{name: "negative op timeout", modify: func(cfg *Config) { cfg.OpTimeout = -time.Second }},
+		{name: "negative ramp", modify: func(cfg *Config) { cfg.Ramp = -time.Second; cfg.Rate = 10 }},
+		{name: "ramp as long as duration", modify: func(cfg *Config) { cfg.Ramp = time.Minute; cfg.Duration = time.Minute; cfg.Rate = 10 }},
+		{name: "ramp with a single unpaced worker", modify: func(cfg *Config) { cfg.Ramp = time.Minute }},
+		{name: "empty endpoint list", modify: func(cfg *Config) { cfg.Endpoint = " , " }},
+		{name: "cleanup buckets without cleanup", modify: func(cfg *Config) { cfg.CleanupBuckets = true }},
+		{name: "run ID with spaces", modify: func(cfg *Config) { cfg.RunID = "nightly run" }},
+		{name: "cleanup run ID too long", modify: func(cfg *Config) { cfg.CleanupRun = strings.Repeat("a", maxRunIDLength+1) }},
+		{name: "empty prefix", modify: func(cfg *Config) { cfg.ObjectPrefix = " " }},
+		{name: "zero prefix depth", modify: func(cfg *Config) { cfg.PrefixDepthMin = 0 }},
+		{name: "prefix depth min above max", modify: func(cfg *Config) { cfg.PrefixDepthMin = 5; cfg.PrefixDepthMax = 3 }},
+		{name: "prefix depth too large", modify: func(cfg *Config) { cfg.PrefixDepthMax = maxPrefixDepth + 1 }},
+		{name: "missing prefix words", modify: func(cfg *Config) { cfg.PrefixWords = "/nonexistent/words.txt" }},
+		{name: "no buckets", modify: func(cfg *Config) { cfg.Buckets = " , " }},
+		{name: "part size below minimum", modify: func(cfg *Config) { cfg.MultipartPartSize = 4 * 1024 * 1024 }},
+		{name: "single part", modify: func(cfg *Config) { cfg.MultipartSize = cfg.MultipartPartSize }},
+		{name: "too many parts", modify: func(cfg *Config) { cfg.MultipartSize = 10001 * cfg.MultipartPartSize }},
+		{name: "unknown output", modify: func(cfg *Config) { cfg.Output = "yaml" }},
+		{name: "dry run with verify", modify: func(cfg *Config) { cfg.DryRun = true; cfg.Verify = true }},
+		{name: "sts endpoint without scheme", modify: func(cfg *Config) { cfg.STSEndpoint = "sts.example.com:9000" }},
+		{name: "role arn without sts endpoint", modify: func(cfg *Config) { cfg.RoleARN = "arn:aws:iam::123456789012:role/test" }},
+		{name: "weight for unknown bucket", modify: func(cfg *Config) { cfg.BucketWeights = "bucket1=1,other=2" }},
+		{name: "weight for unselected operation", modify: func(cfg *Config) { cfg.Operations = "write,read"; cfg.OperationWeights = "delete=2" }},
+		{name: "missing source dir", modify: func(cfg *Config) { cfg.SourceDir = "/nonexistent/source-dir" }},
+		{name: "presign expiry beyond seven days", modify: func(cfg *Config) { cfg.PresignExpiry = 8 * 24 * time.Hour }},
+		{name: "max error rate of one", modify: func(cfg *Config) { cfg.MaxErrorRate = 1 }},
+		{name: "zero min samples", modify: func(cfg *Config) { cfg.MaxErrorRate = 0.5; cfg.MinSamples = 0 }},
+		{name: "negative max retries", modify: func(cfg *Config) { cfg.MaxRetries = -1 }},
+		{name: "negative max objects", modify: func(cfg *Config) { cfg.MaxObjects = -1 }},
+		{name: "negative metadata count", modify: func(cfg *Config) { cfg.MetadataCount = -1 }},
+		{name: "unknown sse", modify: func(cfg *Config) { cfg.SSE = "kms" }},
+		{name: "sse-c key without sse c", modify: func(cfg *Config) { cfg.SSE = "s3"; cfg.SSECKey = testSSECKey }},
+		{name: "short sse-c key", modify: func(cfg *Config) { cfg.SSE = "c"; cfg.SSECKey = "c2hvcnQ=" }},
+		{name: "dry run with consistency check", modify: func(cfg *Config) { cfg.DryRun = true; cfg.ConsistencyCheck = true }},
+		{name: "unknown log level", modify: func(cfg *Config) { cfg.LogLevel = "trace" }},
+		{name: "unknown log format", modify: func(cfg *Config) { cfg.LogFormat = "logfmt" }},
+		{name: "storage class with spaces", modify: func(cfg *Config) { cfg.StorageClass = " STANDARD" }},
+		{name: "malformed content type", modify: func(cfg *Config) { cfg.ContentType = "text/" }},
+		{name: "negative lifecycle expiry", modify: func(cfg *Config) { cfg.LifecycleExpiryDays = -1 }},
+		{name: "metadata count too large", modify: func(cfg *Config) { cfg.MetadataCount = maxMetadataCount + 1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			if err := validateConfig(cfg); err == nil {
+				t.Errorf("Expected config to be rejected")
+			}
+		})
+	}
+}
+
+func TestOperationRegistry(t *testing.T) {
+	expected := []string{"write", "read", "rangeread", "overwrite", "delete", "prefixdelete", "multipart", "tag", "copy", "compose", "stat", "list", "presignput", "presignget", "abortmultipart", "abandonmultipart", "versiondelete", "retention", "legalhold"}
+	names := registeredOperations()
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %d registered operations, got %v", len(expected), names)
+	}
+	for i, name := range expected {
+		if names[i] != name {
+			t.Errorf("Expected operation[%d] to be %s, got %s", i, name, names[i])
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected duplicate registration to panic")
+		}
+	}()
+	RegisterOperation("write", func(ctx context.Context, m *MinioClient) error { return nil })
+}
+
+func TestStatsConcurrentAccess(t *testing.T) {
+	stats := &Stats{}
+	workers := 8
+	increments := 1000
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				atomic.AddInt64(&stats.WriteOps, 1)
+				atomic.AddInt64(&stats.ErrorOps, 1)
+			}
+		}()
+	}
+
+	// Read concurrently with the writers, like printStats does
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < increments; i++ {
+			_ = stats.Snapshot()
+		}
+	}()
+
+	wg.Wait()
+	<-done
+
+	snapshot := stats.Snapshot()
+	expected := int64(workers * increments)
+	if snapshot.WriteOps != expected {
+		t.Errorf("Expected WriteOps to be %d, got %d", expected, snapshot.WriteOps)
+	}
+	if snapshot.ErrorOps != expected {
+		t.Errorf("Expected ErrorOps to be %d, got %d", expected, snapshot.ErrorOps)
+	}
+}
+
+// registerTestOperation registers an operation for the duration of the test
+func registerTestOperation(t *testing.T, name string, op Operation) {
+	t.Helper()
+	registerWeightedTestOperation(t, name, 1, op)
+}
+
+// registerWeightedTestOperation registers an operation with weight for the
+// duration of the test
+func registerWeightedTestOperation(t *testing.T, name string, weight int64, op Operation) {
+	t.Helper()
+	RegisterWeightedOperation(name, weight, op)
+	t.Cleanup(func() {
+		delete(operationRegistry, name)
+		delete(operationWeights, name)
+		operationNames = operationNames[:len(operationNames)-1]
+	})
+}
+
+func TestRunOperationsRateLimit(t *testing.T) {
+	var count int64
+	registerTestOperation(t, "test-count", func(ctx context.Context, m *MinioClient) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	})
+
+	client := &MinioClient{
+		config: Config{Concurrency: 4, Rate: 20},
+		stats:  &Stats{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	client.runRateWorker(ctx, []string{"test-count"}, rate.NewLimiter(rate.Limit(client.config.Rate), 1))
+
+	// 20 ops/sec for 200ms allows about 4 operations
+	if got := atomic.LoadInt64(&count); got < 1 || got > 10 {
+		t.Errorf("Expected rate limited operation count between 1 and 10, got %d", got)
+	}
+
+	atomic.StoreInt64(&count, 0)
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	client.runRateWorker(ctx, []string{"test-count"}, nil)
+
+	if got := atomic.LoadInt64(&count); got <= 10 {
+		t.Errorf("Expected unlimited rate to run more than 10 operations, got %d", got)
+	}
+}
+
+func TestRampLimiter(t *testing.T) {
+	limiter := rate.NewLimiter(rate.Limit(1), 1)
+	rampLimiter(context.Background(), limiter, 100, 50*time.Millisecond)
+	if limiter.Limit() != 100 {
+		t.Errorf("Expected the full rate after the ramp, got %v", limiter.Limit())
+	}
+
+	// A cancelled ramp leaves the limit where it was
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limiter.SetLimit(1)
+	rampLimiter(ctx, limiter, 100, time.Hour)
+	if limiter.Limit() != 1 {
+		t.Errorf("Expected a cancelled ramp not to raise the limit, got %v", limiter.Limit())
+	}
+}
+
+func TestWorkerStartDelay(t *testing.T) {
+	var delays []time.Duration
+	for i := 0; i < 4; i++ {
+		delays = append(delays, workerStartDelay(i, 4, 8*time.Second))
+	}
+	expected := []time.Duration{0, 2 * time.Second, 4 * time.Second, 6 * time.Second}
+	if !reflect.DeepEqual(delays, expected) {
+		t.Errorf("Expected worker starts %v, got %v", expected, delays)
+	}
+}
+
+func TestSelectOperations(t *testing.T) {
+	all, err := selectOperations(Config{ObjectLock: true, AbandonMultipart: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(all) != len(registeredOperations()) {
+		t.Errorf("Expected all %d operations when allowlist is empty, got %v", len(registeredOperations()), all)
+	}
+
+	unversioned, err := selectOperations(Config{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, name := range unversioned {
+		if name == "versiondelete" {
+			t.Errorf("Expected versiondelete to be skipped without --versioned, got %v", unversioned)
+		}
+	}
+
+	if _, err := selectOperations(Config{Operations: "versiondelete"}); err == nil || !strings.Contains(err.Error(), "--versioned") {
+		t.Errorf("Expected versiondelete without --versioned to be rejected, got %v", err)
+	}
+	lockedBuckets, err := selectOperations(Config{BucketObjectLock: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if joined := strings.Join(lockedBuckets, ","); !strings.Contains(joined, "versiondelete") || strings.Contains(joined, "retention") {
+		t.Errorf("Expected --bucket-object-lock to enable versiondelete but not retention, got %v", lockedBuckets)
+	}
+	if _, err := selectOperations(Config{Operations: "abandonmultipart"}); err == nil || !strings.Contains(err.Error(), "--abandon-multipart") {
+		t.Errorf("Expected abandonmultipart without --abandon-multipart to be rejected, got %v", err)
+	}
+
+	selected, err := selectOperations(Config{Operations: "write, multipart"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(selected) != 2 || selected[0] != "write" || selected[1] != "multipart" {
+		t.Errorf("Expected [write multipart], got %v", selected)
+	}
+
+	_, err = selectOperations(Config{Operations: "write,rename"})
+	if err == nil {
+		t.Fatal("Expected error for unknown operation")
+	}
+	if !strings.Contains(err.Error(), "prefixdelete") {
+		t.Errorf("Expected error to list valid operations, got %v", err)
+	}
+}
+
+func TestContentChecksum(t *testing.T) {
+	// CRC32C check value for "123456789"
+	if got := contentChecksum([]byte("123456789")); got != "e3069283" {
+		t.Errorf("Expected checksum e3069283, got %s", got)
+	}
+
+	metadata := checksumMetadata("123456789")
+	if metadata[checksumMetaKey] != "e3069283" {
+		t.Errorf("Expected checksum metadata e3069283, got %v", metadata)
+	}
+}
+
+func TestReadBody(t *testing.T) {
+	content := strings.Repeat("payload", 1000)
+	for _, discard := range []bool{false, true} {
+		m := &MinioClient{config: Config{DiscardReads: discard, Verify: true}}
+		size, checksum, err := m.readBody(strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("readBody (discard %v) returned error: %v", discard, err)
+		}
+		if size != int64(len(content)) || checksum != contentChecksum([]byte(content)) {
+			t.Errorf("readBody (discard %v) = %d, %s; expected %d, %s", discard, size, checksum, len(content), contentChecksum([]byte(content)))
+		}
+
+		m.config.Verify = false
+		if _, checksum, _ := m.readBody(strings.NewReader(content)); checksum != "" {
+			t.Errorf("Expected no checksum without --verify (discard %v), got %s", discard, checksum)
+		}
+	}
+}
+
+func TestByteSizeFlag(t *testing.T) {
+	var cfg Config
+	cmd := &cobra.Command{Use: "test"}
+	registerFlags(cmd, &cfg)
+
+	if cfg.MultipartSize != 70*1024*1024 || cfg.MultipartPartSize != 5*1024*1024 {
+		t.Fatalf("Unexpected multipart defaults: %s, %s", cfg.MultipartSize, cfg.MultipartPartSize)
+	}
+
+	if err := cmd.ParseFlags([]string{"--multipart-size", "1GiB", "--multipart-part-size", "16MiB"}); err != nil {
+		t.Fatalf("Unexpected parse error: %v", err)
+	}
+	if cfg.MultipartSize != 1024*1024*1024 {
+		t.Errorf("Expected multipart size 1GiB, got %d", cfg.MultipartSize)
+	}
+	if cfg.MultipartPartSize != 16*1024*1024 {
+		t.Errorf("Expected part size 16MiB, got %d", cfg.MultipartPartSize)
+	}
+
+	if err := cmd.ParseFlags([]string{"--multipart-size", "lots"}); err == nil {
+		t.Error("Expected invalid size to be rejected")
+	}
+
+	// Sizes that humanize would round keep their exact byte count
+	for size, expected := range map[ByteSize]string{70 * 1024 * 1024: "70 MiB", 75000000: "75000000", 1536: "1.5 KiB"} {
+		if got := size.String(); got != expected {
+			t.Errorf("Expected %d to print as %q, got %q", size, expected, got)
+		}
+	}
+}
+
+func TestStatsReportJSON(t *testing.T) {
+	stats := Stats{ReadOps: 3, WriteOps: 2, MultipartOps: 1, ErrorOps: 4}
+	report := statsReport{Stats: stats, TotalOps: stats.Total(), Final: true}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("Unexpected marshal error: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unexpected unmarshal error: %v", err)
+	}
+	if decoded["readOps"] != float64(3) || decoded["errorOps"] != float64(4) {
+		t.Errorf("Expected counters at the top level, got %s", data)
+	}
+	if decoded["totalOps"] != float64(6) {
+		t.Errorf("Expected totalOps 6 (errors excluded), got %v", decoded["totalOps"])
+	}
+	if decoded["final"] != true {
+		t.Errorf("Expected final to be true, got %v", decoded["final"])
+	}
+}
+
+func TestSetupLogging(t *testing.T) {
+	savedOutput, savedLogger, savedOperationLogger := logOutput, logger, operationLogger
+	defer func() { logOutput, logger, operationLogger = savedOutput, savedLogger, savedOperationLogger }()
+
+	var buf bytes.Buffer
+	logOutput = &buf
+	setupLogging(Config{Output: outputText, LogLevel: "warn", LogFormat: logFormatJSON})
+
+	LogSuccess("write", "bucket", "key", "size", 10)
+	logger.Warn("operation failed", "op", "read", "error", errors.New("boom"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected only the warning at --log-level warn, got %q", buf.String())
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Expected a JSON record, got %q: %v", lines[0], err)
+	}
+	if record["level"] != "WARN" || record["op"] != "read" || record["error"] != "boom" {
+		t.Errorf("Unexpected record %v", record)
+	}
+}
+
+func TestQuietDropsOperationRecords(t *testing.T) {
+	savedOutput, savedLogger, savedOperationLogger := logOutput, logger, operationLogger
+	defer func() { logOutput, logger, operationLogger = savedOutput, savedLogger, savedOperationLogger }()
+
+	var buf bytes.Buffer
+	logOutput = &buf
+	setupLogging(Config{Output: outputText, LogLevel: "debug", LogFormat: logFormatText, Quiet: true})
+
+	LogSuccess("write", "bucket", "key", "size", 10)
+	logger.Warn("operation failed", "op", "read", "error", errors.New("boom"))
+	logger.Info("stats", "read", 1)
+
+	output := buf.String()
+	if strings.Contains(output, "operation succeeded") {
+		t.Errorf("Expected --quiet to drop successful operations, got %q", output)
+	}
+	if !strings.Contains(output, "operation failed") || !strings.Contains(output, "msg=stats") {
+		t.Errorf("Expected failures and stats to be kept with --quiet, got %q", output)
+	}
+}
+
+func TestStatsThroughput(t *testing.T) {
+	stats := Stats{BytesWritten: 3 * 1024 * 1024, BytesRead: 1024 * 1024}
+	if got := stats.throughput(2 * time.Second); got != 2 {
+		t.Errorf("Expected 2 MiB/s for 4 MiB in 2s, got %v", got)
+	}
+	if got := stats.throughput(0); got != 0 {
+		t.Errorf("Expected 0 MiB/s before any time elapsed, got %v", got)
+	}
+}
+
+func TestMetricsServer(t *testing.T) {
+	m := &MinioClient{stats: &Stats{ReadOps: 2, ErrorOps: 1}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	addr, done, err := m.startMetricsServer(ctx, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error starting metrics server: %v", err)
+	}
+	url := "http://" + addr.String() + "/metrics"
+
+	atomic.AddInt64(&m.stats.ReadOps, 1)
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("Unexpected scrape error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	for _, want := range []string{"minio_gen_read_ops_total 3", "minio_gen_error_ops_total 1"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected %q in metrics output:\n%s", want, body)
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Metrics server did not shut down after cancellation")
+	}
+	if _, err := http.Get(url); err == nil {
+		t.Error("Expected metrics server to stop accepting connections")
+	}
+}
+
+func TestGenerateRandomTags(t *testing.T) {
+	m := &MinioClient{}
+	for i := 0; i < 50; i++ {
+		generated := m.generateRandomTags()
+		if len(generated) < 1 || len(generated) > 3 {
+			t.Fatalf("Expected 1-3 tags, got %d: %v", len(generated), generated)
+		}
+		if _, err := tags.NewTags(generated, true); err != nil {
+			t.Fatalf("Generated invalid object tags %v: %v", generated, err)
+		}
+	}
+}
+
+func TestObjectMetadata(t *testing.T) {
+	m := &MinioClient{}
+	metadata := m.objectMetadata("123456789")
+	if len(metadata) != 1 || metadata[checksumMetaKey] != "e3069283" {
+		t.Errorf("Expected only checksum metadata by default, got %v", metadata)
+	}
+
+	m.config.MetadataCount = 5
+	metadata = m.objectMetadata("123456789")
+	if len(metadata) != 6 {
+		t.Fatalf("Expected checksum plus 5 random entries, got %v", metadata)
+	}
+	if metadata[checksumMetaKey] != "e3069283" {
+		t.Errorf("Expected checksum to be kept, got %v", metadata)
+	}
+
+	m.config.RunID = "run-1"
+	metadata = m.objectMetadata("123456789")
+	if len(metadata) != 7 || metadata[runIDMetaKey] != "run-1" {
+		t.Errorf("Expected checksum, run ID and 5 random entries, got %v", metadata)
+	}
+}
+
+// testSSECKey is 32 zero bytes, base64-encoded
+const testSSECKey = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+
+func TestNewServerSideEncryption(t *testing.T) {
+	sse, _, err := newServerSideEncryption(Config{SSE: "s3"})
+	if err != nil || sse.Type() != encrypt.S3 {
+		t.Fatalf("Expected SSE-S3, got %v, %v", sse, err)
+	}
+	if (&MinioClient{sse: sse}).readEncryption() != nil {
+		t.Error("Expected reads of SSE-S3 objects to send no encryption headers")
+	}
+
+	sse, key, err := newServerSideEncryption(Config{SSE: "c", SSECKey: testSSECKey})
+	if err != nil || sse.Type() != encrypt.SSEC {
+		t.Fatalf("Expected SSE-C, got %v, %v", sse, err)
+	}
+	if len(key) != 32 || key[0] != 0 {
+		t.Errorf("Expected the provided key to be used, got %x", key)
+	}
+	if (&MinioClient{sse: sse}).readEncryption() == nil {
+		t.Error("Expected reads of SSE-C objects to send the key")
+	}
+
+	_, generated, err := newServerSideEncryption(Config{SSE: "c"})
+	if err != nil || len(generated) != 32 {
+		t.Fatalf("Expected a generated 32-byte key, got %x, %v", generated, err)
+	}
+}
+
+func TestObjectLockedErrors(t *testing.T) {
+	locked := minio.ErrorResponse{Code: "InvalidRequest", Message: "Object is WORM protected and cannot be overwritten"}
+	denied := minio.ErrorResponse{Code: "AccessDenied", Message: "Access Denied."}
+
+	if !isObjectLockedError(locked) {
+		t.Error("Expected WORM protected error to be recognized as locked")
+	}
+	if isObjectLockedError(denied) {
+		t.Error("Expected a plain access denied error not to be treated as locked")
+	}
+
+	m := &MinioClient{stats: &Stats{}}
+	if m.recordLocked("delete", ObjectInfo{Bucket: "b", Key: "k"}, time.Now(), locked) {
+		t.Error("Expected lock errors to stay errors without --object-lock")
+	}
+
+	m.config.ObjectLock = true
+	if !m.recordLocked("delete", ObjectInfo{Bucket: "b", Key: "k"}, time.Now(), locked) {
+		t.Error("Expected lock error to be recorded with --object-lock")
+	}
+	if m.recordLocked("delete", ObjectInfo{Bucket: "b", Key: "k"}, time.Now(), denied) {
+		t.Error("Expected unrelated errors not to be recorded as locked")
+	}
+	if stats := m.stats.Snapshot(); stats.LockedOps != 1 || stats.ErrorOps != 0 {
+		t.Errorf("Expected 1 locked and 0 error operations, got %+v", stats)
+	}
+}
+
+func TestGenerateRetention(t *testing.T) {
+	m := &MinioClient{}
+	for i := 0; i < 20; i++ {
+		mode, retainUntil := m.generateRetention()
+		if !mode.IsValid() {
+			t.Fatalf("Generated invalid retention mode %q", mode)
+		}
+		if until := time.Until(retainUntil); until <= 0 || until > 61*time.Minute {
+			t.Fatalf("Expected retain-until within the next hour, got %v", retainUntil)
+		}
+	}
+}
+
+// replaceTestOperation swaps a built-in operation for op until the test ends
+func replaceTestOperation(t *testing.T, name string, op Operation) {
+	t.Helper()
+	original := operationRegistry[name]
+	operationRegistry[name] = op
+	t.Cleanup(func() { operationRegistry[name] = original })
+}
+
+func TestMaxObjectsStopsCreations(t *testing.T) {
+	var writes, readsOrDeletes int64
+	replaceTestOperation(t, "write", func(ctx context.Context, m *MinioClient) error {
+		atomic.AddInt64(&writes, 1)
+		atomic.AddInt64(&m.netObjects, 1)
+		return nil
+	})
+	replaceTestOperation(t, "read", func(ctx context.Context, m *MinioClient) error {
+		atomic.AddInt64(&readsOrDeletes, 1)
+		return nil
+	})
+	replaceTestOperation(t, "delete", func(ctx context.Context, m *MinioClient) error {
+		atomic.AddInt64(&readsOrDeletes, 1)
+		atomic.AddInt64(&m.netObjects, -1)
+		return nil
+	})
+
+	m := &MinioClient{config: Config{MaxObjects: 3}, stats: &Stats{}}
+	for i := 0; i < 20; i++ {
+		m.runRandomOperation(context.Background(), []string{"write", "read", "delete"})
+	}
+
+	if writes < 3 {
+		t.Errorf("Expected writes to continue until the cap, got %d", writes)
+	}
+	if readsOrDeletes == 0 {
+		t.Error("Expected writes to be replaced by reads or deletes at the cap")
+	}
+	if net := atomic.LoadInt64(&m.netObjects); net > 3 {
+		t.Errorf("Expected at most 3 net objects, got %d", net)
+	}
+
+	unlimited := &MinioClient{stats: &Stats{}}
+	if unlimited.atObjectLimit() {
+		t.Error("Expected no limit when --max-objects is 0")
+	}
+}
+
+func TestMaxObjectsKeepsToSelectedOperations(t *testing.T) {
+	var writes, lists int64
+	replaceTestOperation(t, "write", func(ctx context.Context, m *MinioClient) error {
+		atomic.AddInt64(&writes, 1)
+		atomic.AddInt64(&m.netObjects, 1)
+		return nil
+	})
+	replaceTestOperation(t, "list", func(ctx context.Context, m *MinioClient) error {
+		atomic.AddInt64(&lists, 1)
+		return nil
+	})
+	for _, name := range []string{"read", "delete"} {
+		name := name
+		replaceTestOperation(t, name, func(ctx context.Context, m *MinioClient) error {
+			t.Errorf("Expected %s to stay excluded by --operations", name)
+			return nil
+		})
+	}
+
+	m := &MinioClient{config: Config{MaxObjects: 3}, stats: &Stats{}, random: newSeededReader(1)}
+	for i := 0; i < 50; i++ {
+		m.runRandomOperation(context.Background(), []string{"write", "list"})
+	}
+	if writes != 3 {
+		t.Errorf("Expected writes to stop at the cap, got %d", writes)
+	}
+	if lists == 0 {
+		t.Error("Expected writes to be replaced by lists at the cap")
+	}
+
+	// With nothing but creations selected, a worker at the cap waits
+	m.weights = map[string]int64{"write": 1, "list": 0}
+	start := time.Now()
+	m.runRandomOperation(context.Background(), []string{"write", "list"})
+	if writes != 3 || time.Since(start) < objectLimitPause {
+		t.Errorf("Expected a paused iteration without writes, got %d writes after %v", writes, time.Since(start))
+	}
+}
+
+func writeTestConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "yaml", file: "config.yaml", content: "delay: 250ms\nconcurrency: 2\nbuckets: from-file\nmultipart-size: 20MiB\n"},
+		{name: "json", file: "config.json", content: `{"delay": "250ms", "concurrency": 2, "buckets": "from-file", "multipart-size": "20MiB"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			cmd := &cobra.Command{Use: "test"}
+			registerFlags(cmd, &cfg)
+			if err := cmd.ParseFlags([]string{"--concurrency", "8"}); err != nil {
+				t.Fatalf("Unexpected parse error: %v", err)
+			}
+
+			if err := loadConfigFile(cmd, writeTestConfigFile(t, tt.file, tt.content), &cfg); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if cfg.OperationDelay != 250*time.Millisecond || cfg.Buckets != "from-file" || cfg.MultipartSize != 20*1024*1024 {
+				t.Errorf("Expected values from the file, got %+v", cfg)
+			}
+			if cfg.Concurrency != 8 {
+				t.Errorf("Expected --concurrency to override the file, got %d", cfg.Concurrency)
+			}
+			if cfg.Endpoint != "localhost:9000" {
+				t.Errorf("Expected defaults for keys missing from the file, got %q", cfg.Endpoint)
+			}
+		})
+	}
+}
+
+func TestRateOverridesDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		file     string
+		expected bool
+	}{
+		{name: "both flags", args: []string{"--rate", "10", "--delay", "2s"}, expected: true},
+		{name: "both in file", file: "rate: 10\ndelay: 2s\n", expected: true},
+		{name: "rate in file, delay flag", args: []string{"--delay", "2s"}, file: "rate: 10\n", expected: true},
+		{name: "rate flag, delay in file", args: []string{"--rate", "0"}, file: "delay: 2s\n", expected: true},
+		{name: "rate only", args: []string{"--rate", "10"}, file: "concurrency: 2\n"},
+		{name: "delay only", file: "delay: 2s\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			cmd := &cobra.Command{Use: "test"}
+			registerFlags(cmd, &cfg)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("Unexpected parse error: %v", err)
+			}
+			if tt.file != "" {
+				if err := loadConfigFile(cmd, writeTestConfigFile(t, "config.yaml", tt.file), &cfg); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+			if got := rateOverridesDelay(cmd, cfg); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileKeepsExactSizeFlags(t *testing.T) {
+	var cfg Config
+	cmd := &cobra.Command{Use: "test"}
+	registerFlags(cmd, &cfg)
+	if err := cmd.ParseFlags([]string{"--multipart-size", "75000000"}); err != nil {
+		t.Fatalf("Unexpected parse error: %v", err)
+	}
+
+	path := writeTestConfigFile(t, "config.yaml", "multipart-size: 20MiB\nmultipart-part-size: 5MiB\n")
+	if err := loadConfigFile(cmd, path, &cfg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.MultipartSize != 75000000 {
+		t.Errorf("Expected --multipart-size 75000000 to override the file exactly, got %d", cfg.MultipartSize)
+	}
+}
+
+func TestLoadConfigFileRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "unknown key", file: "config.yaml", content: "concurrency: 2\nworkers: 4\n"},
+		{name: "bad value", file: "config.json", content: `{"delay": "soon"}`},
+		{name: "unsupported extension", file: "config.toml", content: "concurrency = 2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			cmd := &cobra.Command{Use: "test"}
+			registerFlags(cmd, &cfg)
+			if err := loadConfigFile(cmd, writeTestConfigFile(t, tt.file, tt.content), &cfg); err == nil {
+				t.Errorf("Expected config file to be rejected")
+			}
+		})
+	}
+}
+
+func TestCredentialsFromEnv(t *testing.T) {
+	for _, vars := range credentialEnvVars {
+		t.Setenv(vars[0], "")
+		t.Setenv(vars[1], "")
+	}
+	if _, _, source := credentialsFromEnv(); source != "" {
+		t.Fatalf("Expected no credentials, got them from %s", source)
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "aws-access")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "aws-secret")
+	t.Setenv("MINIO_ACCESS_KEY", "minio-access")
+	accessKey, secretKey, source := credentialsFromEnv()
+	if accessKey != "aws-access" || secretKey != "aws-secret" || source != "AWS_ACCESS_KEY_ID" {
+		t.Errorf("Expected AWS keys when the MinIO pair is incomplete, got %s/%s from %s", accessKey, secretKey, source)
+	}
+
+	t.Setenv("MINIO_SECRET_KEY", "minio-secret")
+	accessKey, secretKey, source = credentialsFromEnv()
+	if accessKey != "minio-access" || secretKey != "minio-secret" || source != "MINIO_ACCESS_KEY" {
+		t.Errorf("Expected MinIO keys to take precedence, got %s/%s from %s", accessKey, secretKey, source)
+	}
+}
+
+func TestSeedReproducesSequence(t *testing.T) {
+	var picked []string
+	for _, name := range []string{"test-a", "test-b", "test-c"} {
+		name := name
+		registerTestOperation(t, name, func(ctx context.Context, m *MinioClient) error {
+			picked = append(picked, name)
+			return nil
+		})
+	}
+
+	sequence := func(seed int64) string {
+		picked = nil
+		m := &MinioClient{random: newSeededReader(seed), stats: &Stats{}}
+		result := m.generateRandomPrefix() + m.generateRandomContent()
+		for i := 0; i < 3; i++ {
+			// Spread over several milliseconds so a timestamp would differ
+			time.Sleep(2 * time.Millisecond)
+			result += m.generateObjectName() + m.generateMultipartObjectName()
+		}
+		for i := 0; i < 10; i++ {
+			m.runRandomOperation(context.Background(), []string{"test-a", "test-b", "test-c"})
+		}
+		return result + strings.Join(picked, ",")
+	}
+
+	if first, second := sequence(42), sequence(42); first != second {
+		t.Error("Expected the same seed to reproduce the same sequence")
+	}
+	if sequence(42) == sequence(43) {
+		t.Error("Expected different seeds to produce different sequences")
+	}
+}
+
+func TestDryRunSendsNoRequests(t *testing.T) {
+	replaceTestOperation(t, "write", func(ctx context.Context, m *MinioClient) error {
+		t.Error("Expected dry run not to run the write operation")
+		return nil
+	})
+
+	m := &MinioClient{config: Config{Buckets: "bucket1", ObjectPrefix: "test", DryRun: true}, stats: &Stats{}}
+	for i := 0; i < 3; i++ {
+		m.runRandomOperation(context.Background(), []string{"write"})
+	}
+	if stats := m.stats.Snapshot(); stats.WriteOps != 3 {
+		t.Errorf("Expected 3 would-do writes, got %d", stats.WriteOps)
+	}
+
+	for _, name := range registeredOperations() {
+		if _, ok := dryRunCounters[name]; !ok {
+			t.Errorf("Built-in operation %q has no dry-run counter", name)
+		}
+	}
+}
+
+func TestDryRunCountsRegisteredOperations(t *testing.T) {
+	registerTestOperation(t, "test-custom", func(ctx context.Context, m *MinioClient) error {
+		t.Error("Expected dry run not to run the registered operation")
+		return nil
+	})
+
+	m := &MinioClient{
+		config:   Config{Buckets: "bucket1", DryRun: true},
+		stats:    &Stats{},
+		outcomes: newOutcomeRecorder(),
+	}
+	for i := 0; i < 3; i++ {
+		m.runRandomOperation(context.Background(), []string{"test-custom"})
+	}
+	if summary := m.outcomes.Summaries()["test-custom"]; summary.Succeeded != 3 || summary.Failed != 0 {
+		t.Errorf("Expected 3 would-do runs of the registered operation, got %+v", summary)
+	}
+}
+
+func TestParseBucketWeights(t *testing.T) {
+	buckets := []string{"hot", "cold", "archive"}
+	weights, err := parseBucketWeights("hot=80, cold=20", buckets)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if weights["hot"] != 80 || weights["cold"] != 20 || weights["archive"] != 0 {
+		t.Errorf("Unexpected weights: %v", weights)
+	}
+
+	for _, value := range []string{"hot", "hot=x", "hot=-1", "hot=0", "warm=10", "hot=1,hot=2"} {
+		if _, err := parseBucketWeights(value, buckets); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestWeightedBucketSelection(t *testing.T) {
+	m := &MinioClient{
+		config: Config{Buckets: "hot,cold,archive", BucketWeights: "hot=80,cold=20"},
+		random: newSeededReader(1),
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		bucket, err := m.getRandomBucket()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		counts[bucket]++
+	}
+
+	if counts["archive"] != 0 {
+		t.Errorf("Expected unweighted bucket never to be picked, got %d", counts["archive"])
+	}
+	if counts["hot"] < 700 || counts["hot"] > 900 {
+		t.Errorf("Expected about 80%% of picks in hot, got %v", counts)
+	}
+}
+
+func TestParseOperationWeights(t *testing.T) {
+	registerWeightedTestOperation(t, "test-heavy", 5, func(ctx context.Context, m *MinioClient) error { return nil })
+	operations := []string{"write", "read", "test-heavy"}
+
+	weights, err := parseOperationWeights("", operations)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if weights["write"] != 1 || weights["read"] != 1 || weights["test-heavy"] != 5 {
+		t.Errorf("Expected the registered weights, got %v", weights)
+	}
+
+	weights, err = parseOperationWeights("write=3, test-heavy=0", operations)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if weights["write"] != 3 || weights["read"] != 1 || weights["test-heavy"] != 0 {
+		t.Errorf("Expected the overrides on top of the registered weights, got %v", weights)
+	}
+
+	for _, value := range []string{"write", "write=x", "write=-1", "delete=2", "write=1,write=2", "write=0,read=0,test-heavy=0"} {
+		if _, err := parseOperationWeights(value, operations); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a zero weight to panic")
+		}
+	}()
+	RegisterWeightedOperation("test-zero", 0, func(ctx context.Context, m *MinioClient) error { return nil })
+}
+
+func TestWeightedOperationSelection(t *testing.T) {
+	counts := make(map[string]int)
+	registerWeightedTestOperation(t, "test-heavy", 3, func(ctx context.Context, m *MinioClient) error {
+		counts["test-heavy"]++
+		return nil
+	})
+	for _, name := range []string{"test-light", "test-off"} {
+		name := name
+		registerTestOperation(t, name, func(ctx context.Context, m *MinioClient) error {
+			counts[name]++
+			return nil
+		})
+	}
+
+	operations := []string{"test-heavy", "test-light", "test-off"}
+	weights, err := parseOperationWeights("test-off=0", operations)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m := &MinioClient{random: newSeededReader(1), stats: &Stats{}, weights: weights}
+	for i := 0; i < 1000; i++ {
+		m.runRandomOperation(context.Background(), operations)
+	}
+
+	if counts["test-off"] != 0 {
+		t.Errorf("Expected an operation weighted 0 never to be picked, got %d", counts["test-off"])
+	}
+	if counts["test-heavy"] < 650 || counts["test-heavy"] > 850 {
+		t.Errorf("Expected about 75%% of picks for the operation registered with weight 3, got %v", counts)
+	}
+}
+
+func TestSourceFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "nested"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"small.json":       `{"a":1}`,
+		"nested/large.png": strings.Repeat("x", 100),
+		"empty.txt":        "",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := loadSourceFiles(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected the 2 non-empty files, got %v", files)
+	}
+
+	m := &MinioClient{sourceFiles: files}
+	large, err := m.pickSourceFile(50)
+	if err != nil || large == nil || filepath.Base(large.path) != "large.png" {
+		t.Fatalf("Expected large.png for a 50 byte minimum, got %v, %v", large, err)
+	}
+	if large.contentType() != "image/png" {
+		t.Errorf("Expected image/png content type, got %s", large.contentType())
+	}
+	if none, _ := m.pickSourceFile(1000); none != nil {
+		t.Errorf("Expected no file above 1000 bytes, got %v", none)
+	}
+	if got := m.contentType(nil); got != "" {
+		t.Errorf("Expected the client default for generated content, got %q", got)
+	}
+	m.config.ContentType = "text/csv"
+	if got := m.contentType(large); got != "text/csv" {
+		t.Errorf("Expected --content-type to override the guessed type, got %q", got)
+	}
+
+	if _, err := loadSourceFiles(t.TempDir()); err == nil {
+		t.Error("Expected an empty directory to be rejected")
+	}
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	h := &latencyHistogram{}
+	for i := 1; i <= 1000; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+
+	within := func(got, want time.Duration) bool {
+		return math.Abs(float64(got-want)) <= float64(want)*(latencyGrowth-1)
+	}
+	for _, tt := range []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.50, 500 * time.Millisecond},
+		{0.90, 900 * time.Millisecond},
+		{0.99, 990 * time.Millisecond},
+	} {
+		if got := h.quantile(tt.q); !within(got, tt.want) {
+			t.Errorf("Expected p%.0f close to %v, got %v", tt.q*100, tt.want, got)
+		}
+	}
+	if h.quantile(1) != time.Second {
+		t.Errorf("Expected max to be 1s, got %v", h.quantile(1))
+	}
+}
+
+func TestLatencyRecorderConcurrentAccess(t *testing.T) {
+	m := &MinioClient{latency: newLatencyRecorder()}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.recordCall("write", "b", "k", 0, time.Now().Add(-time.Millisecond))
+				m.latency.Summaries()
+			}
+		}()
+	}
+	wg.Wait()
+
+	summary := m.latency.Summaries()["write"]
+	if summary.Count != 800 {
+		t.Errorf("Expected 800 write samples, got %d", summary.Count)
+	}
+	if summary.P50 < 1 {
+		t.Errorf("Expected p50 of at least 1ms, got %v", summary.P50)
+	}
+}
+
+func TestBenchmarkReport(t *testing.T) {
+	m := &MinioClient{
+		config:  Config{RunID: "run", Concurrency: 4},
+		stats:   &Stats{WriteOps: 60, ReadOps: 40, ErrorOps: 2, BytesWritten: 3000, BytesRead: 1000},
+		latency: newLatencyRecorder(),
+	}
+	for i := 1; i <= 50; i++ {
+		m.latency.Record("write", 10*time.Millisecond)
+		m.latency.Record("read", 100*time.Millisecond)
+	}
+
+	report := m.benchmarkReport(2 * time.Second)
+	if report.TotalOps != 100 || report.ErrorOps != 2 || report.Concurrency != 4 || report.RunID != "run" {
+		t.Errorf("Unexpected counts in %+v", report)
+	}
+	if report.OpsPerSecond != 50 || report.BytesPerSecond != 2000 {
+		t.Errorf("Expected 50 ops/s and 2000 bytes/s, got %v and %v", report.OpsPerSecond, report.BytesPerSecond)
+	}
+	// Half the samples are 10ms and half 100ms, across both operations
+	if report.P50 > 10*latencyGrowth || report.P99 < 100/latencyGrowth {
+		t.Errorf("Expected p50 near 10ms and p99 near 100ms, got %v and %v", report.P50, report.P99)
+	}
+
+	empty := (&MinioClient{config: Config{}, stats: &Stats{}, latency: newLatencyRecorder()}).benchmarkReport(0)
+	if empty.OpsPerSecond != 0 || empty.P99 != 0 {
+		t.Errorf("Expected an empty report for no operations, got %+v", empty)
+	}
+}
+
+func TestValidateBenchmarkConfig(t *testing.T) {
+	valid := Config{Duration: 30 * time.Second}
+	if err := validateBenchmarkConfig(valid); err != nil {
+		t.Fatalf("Expected valid benchmark config, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+	}{
+		{name: "infinite duration", modify: func(cfg *Config) { cfg.Duration = 0 }},
+		{name: "dry run", modify: func(cfg *Config) { cfg.DryRun = true }},
+		{name: "cleanup", modify: func(cfg *Config) { cfg.Cleanup = true }},
+		{name: "cleanup run", modify: func(cfg *Config) { cfg.CleanupRun = "run" }},
+		{name: "metrics", modify: func(cfg *Config) { cfg.MetricsAddr = ":9100" }},
+		{name: "manifest", modify: func(cfg *Config) { cfg.Manifest = "run.json" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			if err := validateBenchmarkConfig(cfg); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestOperationLogAppendsRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ops.csv")
+
+	// Two runs appending to the same file should share one header row
+	for run := 0; run < 2; run++ {
+		operationLog, err := openOperationLog(path)
+		if err != nil {
+			t.Fatalf("openOperationLog returned error: %v", err)
+		}
+		m := &MinioClient{operationLog: operationLog}
+		m.recordCall("write", "bucket", "key, with comma", 42, time.Now().Add(-time.Millisecond))
+		m.logOperation(operationRecord{Start: time.Now(), Operation: "read", Err: errors.New("read operation failed: boom")})
+		if err := operationLog.Close(); err != nil {
+			t.Fatalf("Close returned error: %v", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV, got %v", err)
+	}
+	if len(rows) != 5 || !reflect.DeepEqual(rows[0], operationLogHeader) {
+		t.Fatalf("Expected a header and 4 rows, got %q", rows)
+	}
+
+	write, read := rows[1], rows[2]
+	if write[1] != "write" || write[2] != "bucket" || write[3] != "key, with comma" || write[4] != "42" || write[6] != "" {
+		t.Errorf("Unexpected write row %q", write)
+	}
+	if duration, err := strconv.ParseFloat(write[5], 64); err != nil || duration < 1 {
+		t.Errorf("Expected a duration of at least 1ms, got %q", write[5])
+	}
+	if _, err := time.Parse(time.RFC3339Nano, write[0]); err != nil {
+		t.Errorf("Expected an RFC 3339 timestamp, got %q", write[0])
+	}
+	if read[1] != "read" || read[6] != "read operation failed: boom" {
+		t.Errorf("Unexpected failure row %q", read)
+	}
+}
+
+func TestDoPresignedRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			return
+		}
+		io.WriteString(w, "content")
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/object", nil)
+	var body strings.Builder
+	if err := doPresignedRequest(req, &body); err != nil || body.String() != "content" {
+		t.Fatalf("Expected the body to be copied, got %q, %v", body.String(), err)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/missing", nil)
+	err := doPresignedRequest(req, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "The specified key does not exist.") {
+		t.Errorf("Expected the S3 error message, got %v", err)
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	notPEM := filepath.Join(t.TempDir(), "ca.txt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if transport, err := newTransport(Config{}); err != nil || transport.Proxy == nil || transport.TLSClientConfig.InsecureSkipVerify {
+		t.Fatalf("Expected a verifying transport with the environment proxy by default, got %v, %v", transport, err)
+	}
+	if _, err := newTransport(Config{CACert: notPEM}); err == nil {
+		t.Error("Expected a file without certificates to be rejected")
+	}
+
+	for _, cfg := range []Config{{CACert: caFile}, {Insecure: true}} {
+		transport, err := newTransport(cfg)
+		if err != nil {
+			t.Fatalf("newTransport(%+v) returned error: %v", cfg, err)
+		}
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err != nil {
+			t.Errorf("Expected the self-signed server to be trusted with %+v, got %v", cfg, err)
+			continue
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestNewTransportProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy gets the absolute URL of the target
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	transport, err := newTransport(Config{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatalf("newTransport returned error: %v", err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get("http://minio.invalid:9000/bucket/key")
+	if err != nil {
+		t.Fatalf("Expected the request to go through the proxy, got %v", err)
+	}
+	resp.Body.Close()
+	if proxied != "http://minio.invalid:9000/bucket/key" {
+		t.Errorf("Expected the proxy to receive the S3 request, got %q", proxied)
+	}
+
+	// The proxy combines with a custom CA
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	transport, err = newTransport(Config{Proxy: proxy.URL, CACert: caFile})
+	if err != nil {
+		t.Fatalf("newTransport returned error: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://minio.internal/bucket", nil)
+	if u, err := transport.Proxy(req); err != nil || u.String() != proxy.URL || transport.TLSClientConfig.RootCAs == nil {
+		t.Errorf("Expected both the proxy and the CA to be set, got proxy %v (%v)", u, err)
+	}
+}
+
+func TestInitializeMinioClientPathStyle(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+
+	for _, pathStyle := range []bool{false, true} {
+		config = Config{
+			Endpoint:  "s3.amazonaws.com",
+			AccessKey: "access",
+			SecretKey: "secret",
+			UseSSL:    true,
+			Region:    "us-east-1",
+			PathStyle: pathStyle,
+		}
+		clients, err := initializeMinioClient()
+		if err != nil {
+			t.Fatalf("initializeMinioClient returned error: %v", err)
+		}
+		// Presigning builds the URL without sending a request
+		u, err := clients[0].PresignedGetObject(context.Background(), "bucket", "key", time.Minute, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// minio-go picks the regional AWS host, only the bucket's place matters
+		inHost, path := true, "/key"
+		if pathStyle {
+			inHost, path = false, "/bucket/key"
+		}
+		if strings.HasPrefix(u.Host, "bucket.") != inHost || u.Path != path {
+			t.Errorf("With --path-style=%v expected the bucket in the host: %v and path %s, got %s%s", pathStyle, inHost, path, u.Host, u.Path)
+		}
+	}
+}
+
+func TestInitializeMinioClientAssumesRole(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.Form
+		io.WriteString(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><AssumeRoleResult><Credentials>`+
+			`<AccessKeyId>TEMPACCESS</AccessKeyId><SecretAccessKey>tempsecret</SecretAccessKey><SessionToken>token</SessionToken>`+
+			`<Expiration>2099-01-01T00:00:00Z</Expiration></Credentials></AssumeRoleResult></AssumeRoleResponse>`)
+	}))
+	defer server.Close()
+
+	saved := config
+	defer func() { config = saved }()
+	config = Config{
+		Endpoint:    "localhost:9000",
+		AccessKey:   "caller",
+		SecretKey:   "caller-secret",
+		STSEndpoint: server.URL,
+		RoleARN:     "arn:aws:iam::123456789012:role/test",
+	}
+	if _, err := initializeMinioClient(); err != nil {
+		t.Fatalf("initializeMinioClient returned error: %v", err)
+	}
+	if form.Get("Action") != "AssumeRole" || form.Get("RoleArn") != config.RoleARN {
+		t.Errorf("Expected an AssumeRole call for %s, got %v", config.RoleARN, form)
+	}
+
+	config.STSEndpoint = server.URL + "/unreachable"
+	server.Close()
+	if _, err := initializeMinioClient(); err == nil {
+		t.Error("Expected an error when the role cannot be assumed")
+	}
+}
+
+func TestRetriesTransientErrors(t *testing.T) {
+	slowDown := minio.ErrorResponse{Code: "SlowDown", StatusCode: http.StatusServiceUnavailable}
+	var attempts int
+	registerTestOperation(t, "test-flaky", func(ctx context.Context, m *MinioClient) error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("flaky operation failed: %w", slowDown)
+		}
+		return nil
+	})
+	registerTestOperation(t, "test-missing", func(ctx context.Context, m *MinioClient) error {
+		attempts++
+		return fmt.Errorf("missing operation failed: %w", minio.ErrorResponse{Code: "NoSuchKey", StatusCode: http.StatusNotFound})
+	})
+
+	m := &MinioClient{config: Config{MaxRetries: 3}, stats: &Stats{}, outcomes: newOutcomeRecorder()}
+	m.runRandomOperation(context.Background(), []string{"test-flaky"})
+	if stats := m.stats.Snapshot(); attempts != 3 || stats.RetriedOps != 1 || stats.ErrorOps != 0 {
+		t.Errorf("Expected success on the third attempt, got %d attempts and %+v", attempts, stats)
+	}
+
+	attempts = 0
+	m.runRandomOperation(context.Background(), []string{"test-missing"})
+	if stats := m.stats.Snapshot(); attempts != 1 || stats.ErrorOps != 1 {
+		t.Errorf("Expected a 404 not to be retried, got %d attempts and %+v", attempts, stats)
+	}
+
+	expected := map[string]operationOutcome{
+		"test-flaky":   {Succeeded: 1, SuccessRate: 100},
+		"test-missing": {Failed: 1},
+	}
+	if got := m.outcomes.Summaries(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected outcomes after retries %+v, got %+v", expected, got)
+	}
+}
+
+func TestOutcomeRecorder(t *testing.T) {
+	r := newOutcomeRecorder()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				var err error
+				if j%4 == 0 {
+					err = errors.New("read operation failed")
+				}
+				r.Record("read", err)
+			}
+		}()
+	}
+	wg.Wait()
+	r.Record("write", nil)
+
+	summaries := r.Summaries()
+	if read := summaries["read"]; read.Succeeded != 600 || read.Failed != 200 || read.SuccessRate != 75 {
+		t.Errorf("Expected 600 succeeded and 200 failed reads at 75%%, got %+v", read)
+	}
+	if write := summaries["write"]; write.Succeeded != 1 || write.SuccessRate != 100 {
+		t.Errorf("Expected one successful write, got %+v", write)
+	}
+	if (*outcomeRecorder)(nil).Summaries() != nil {
+		t.Error("Expected no summaries from a nil recorder")
+	}
+}
+
+func TestOperationTimeout(t *testing.T) {
+	attempts := 0
+	registerTestOperation(t, "test-hang", func(ctx context.Context, m *MinioClient) error {
+		attempts++
+		if attempts == 2 {
+			return nil
+		}
+		<-ctx.Done()
+		return fmt.Errorf("hang operation failed: %w", ctx.Err())
+	})
+
+	m := &MinioClient{config: Config{OpTimeout: 20 * time.Millisecond}, stats: &Stats{}}
+	m.runRandomOperation(context.Background(), []string{"test-hang"})
+	if stats := m.stats.Snapshot(); stats.ErrorOps != 1 || stats.TimeoutOps != 1 {
+		t.Errorf("Expected the hung operation to time out, got %+v", stats)
+	}
+
+	// A timed out attempt is retried
+	attempts = 0
+	m = &MinioClient{config: Config{OpTimeout: 20 * time.Millisecond, MaxRetries: 1}, stats: &Stats{}}
+	m.runRandomOperation(context.Background(), []string{"test-hang"})
+	if stats := m.stats.Snapshot(); attempts != 2 || stats.RetriedOps != 1 || stats.ErrorOps != 0 {
+		t.Errorf("Expected the retry to succeed, got %d attempts and %+v", attempts, stats)
+	}
+
+	// Shutdown is not a timeout
+	attempts = 0
+	m = &MinioClient{config: Config{OpTimeout: time.Hour}, stats: &Stats{}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	m.runRandomOperation(ctx, []string{"test-hang"})
+	if stats := m.stats.Snapshot(); stats.ErrorOps != 0 || stats.TimeoutOps != 0 {
+		t.Errorf("Expected an interrupted operation not to count, got %+v", stats)
+	}
+}
+
+func TestIsRetriableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "slow down", err: minio.ErrorResponse{Code: "SlowDown", StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "internal error", err: minio.ErrorResponse{Code: "InternalError", StatusCode: http.StatusInternalServerError}, want: true},
+		{name: "wrapped 503", err: fmt.Errorf("read operation failed: %w", minio.ErrorResponse{StatusCode: http.StatusServiceUnavailable}), want: true},
+		{name: "network", err: &url.Error{Op: "Get", URL: "http://localhost:9000", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, want: true},
+		{name: "access denied", err: minio.ErrorResponse{Code: "AccessDenied", StatusCode: http.StatusForbidden}, want: false},
+		{name: "plain", err: errors.New("checksum mismatch"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetriableError(tt.err); got != tt.want {
+				t.Errorf("isRetriableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteObjectsBatch(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["delete"]; r.Method != http.MethodPost || !ok {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		requests++
+		io.WriteString(w, `<DeleteResult><Deleted><Key>prefix/a</Key></Deleted><Deleted><Key>prefix/b</Key></Deleted>`+
+			`<Error><Key>prefix/c</Key><Code>AccessDenied</Code><Message>Access Denied.</Message></Error></DeleteResult>`)
+	}))
+	defer server.Close()
+
+	client, err := minio.New(strings.TrimPrefix(server.URL, "http://"), &minio.Options{
+		Creds:  credentials.NewStaticV4("access", "secret", ""),
+		Region: "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &MinioClient{client: client, stats: &Stats{}}
+	objects := []ObjectInfo{{Bucket: "bucket", Key: "prefix/a"}, {Bucket: "bucket", Key: "prefix/b"}, {Bucket: "bucket", Key: "prefix/c"}}
+	if deleted := m.deleteObjectsBatch(context.Background(), objects); deleted != 2 {
+		t.Errorf("Expected 2 objects deleted, got %d", deleted)
+	}
+	if requests != 1 {
+		t.Errorf("Expected a single multi-object delete request, got %d", requests)
+	}
+
+	// Keys never sent once the context is cancelled aren't deleted
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if deleted := m.deleteObjectsBatch(ctx, objects); deleted != 0 {
+		t.Errorf("Expected no objects deleted after cancellation, got %d", deleted)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusNotFound)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(int(status.Load()))
+		if status.Load() == http.StatusForbidden {
+			io.WriteString(w, `<Error><Code>InvalidAccessKeyId</Code><Message>The Access Key Id you provided does not exist.</Message></Error>`)
+		}
+	}))
+	defer server.Close()
+
+	endpoint := strings.TrimPrefix(server.URL, "http://")
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4("access", "secret", ""),
+		Region: "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &MinioClient{
+		client:    client,
+		clients:   []*minio.Client{client},
+		endpoints: []string{endpoint},
+		config:    Config{Endpoint: endpoint, Buckets: "missing"},
+	}
+	if err := m.healthCheck(); err != nil {
+		t.Errorf("Expected a missing bucket to pass the health check, got %v", err)
+	}
+
+	status.Store(http.StatusForbidden)
+	err = m.healthCheck()
+	if err == nil {
+		t.Fatal("Expected a rejected key to fail the health check")
+	}
+	if !strings.Contains(err.Error(), endpoint) || !strings.Contains(err.Error(), "TLS disabled") {
+		t.Errorf("Expected the endpoint and TLS setting in the error, got %v", err)
+	}
+}
+
+func TestBucketCache(t *testing.T) {
+	var mu sync.Mutex
+	heads := 0
+	existing := map[string]bool{"bucket1": true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		bucket := strings.Trim(r.URL.Path, "/")
+		switch r.Method {
+		case http.MethodHead:
+			heads++
+			if !existing[bucket] {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodPut:
+			existing[bucket] = true
+		}
+	}))
+	defer server.Close()
+
+	endpoint := strings.TrimPrefix(server.URL, "http://")
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4("access", "secret", ""),
+		Region: "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &MinioClient{
+		client:    client,
+		clients:   []*minio.Client{client},
+		endpoints: []string{endpoint},
+		config:    Config{Endpoint: endpoint, Buckets: "bucket1,bucket2"},
+		buckets:   newBucketCache(),
+	}
+	headsAfter := func(step string, expected int) {
+		t.Helper()
+		mu.Lock()
+		defer mu.Unlock()
+		if heads != expected {
+			t.Errorf("Expected %d HEAD bucket requests after %s, got %d", expected, step, heads)
+		}
+	}
+
+	if err := m.healthCheck(); err != nil {
+		t.Fatal(err)
+	}
+	headsAfter("the health check", 1)
+
+	// bucket1 comes from the cache, bucket2 is checked and created
+	if err := m.ensureBucket(); err != nil {
+		t.Fatal(err)
+	}
+	headsAfter("the first ensure", 2)
+
+	// Creating bucket2 invalidated its entry
+	if err := m.ensureBucket(); err != nil {
+		t.Fatal(err)
+	}
+	headsAfter("the second ensure", 3)
+
+	if err := m.ensureBucket(); err != nil {
+		t.Fatal(err)
+	}
+	headsAfter("the third ensure", 3)
+
+	if exists, ok := m.buckets.Lookup(endpoint, "bucket2"); !ok || !exists {
+		t.Errorf("Expected bucket2 cached as existing, got exists=%v ok=%v", exists, ok)
+	}
+	if _, ok := m.buckets.Lookup("other:9000", "bucket2"); ok {
+		t.Error("Expected the cache to be kept per endpoint")
+	}
+}
+
+func TestEnsureBucketLifecycle(t *testing.T) {
+	var mu sync.Mutex
+	lifecycles := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		bucket := strings.Trim(r.URL.Path, "/")
+		switch {
+		case r.Method == http.MethodHead && bucket != "existing":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPut && r.URL.Query().Has("lifecycle"):
+			body, _ := io.ReadAll(r.Body)
+			lifecycles[bucket] = string(body)
+		}
+	}))
+	defer server.Close()
+
+	client, err := minio.New(strings.TrimPrefix(server.URL, "http://"), &minio.Options{
+		Creds:  credentials.NewStaticV4("access", "secret", ""),
+		Region: "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &MinioClient{
+		client: client,
+		config: Config{Buckets: "existing,created", LifecycleExpiryDays: 7},
+	}
+	if err := m.ensureBucket(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := lifecycles["existing"]; ok {
+		t.Error("Expected the existing bucket to keep its lifecycle")
+	}
+	body, ok := lifecycles["created"]
+	if !ok {
+		t.Fatal("Expected a lifecycle on the created bucket")
+	}
+	for _, want := range []string{"<Days>7</Days>", "<Status>Enabled</Status>", "<ID>" + expiryLifecycleRuleID + "</ID>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s in the lifecycle, got %s", want, body)
+		}
+	}
+}
+
+func TestCheckConsistency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeObject := func(body string) {
+			w.Header().Set("ETag", `"etag"`)
+			w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			io.WriteString(w, body)
+		}
+		switch r.URL.Path {
+		case "/bucket/same":
+			writeObject("content")
+		case "/bucket/changed":
+			writeObject("stale")
+		case "/bucket/broken":
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, `<Error><Code>AccessDenied</Code><Message>Access Denied.</Message></Error>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+		}
+	}))
+	defer server.Close()
+
+	client, err := minio.New(strings.TrimPrefix(server.URL, "http://"), &minio.Options{
+		Creds:  credentials.NewStaticV4("access", "secret", ""),
+		Region: "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &MinioClient{client: client, stats: &Stats{}, config: Config{ConsistencyCheck: true}}
+	for _, key := range []string{"same", "changed", "missing", "broken"} {
+		m.checkConsistency(context.Background(), "write", "bucket", key, "content")
+	}
+	if failures := m.stats.ConsistencyFailures; failures != 2 {
+		t.Errorf("Expected the changed and missing objects to fail the check, got %d failures", failures)
+	}
+	if read := m.stats.BytesRead; read != int64(len("content")) {
+		t.Errorf("Expected the matching read-back to count as read bytes, got %d", read)
+	}
+}
+
+// readChunkedBody returns the payload of an upload, decoding the
+// aws-chunked encoding the client uses for streaming signatures. It runs in
+// the server goroutine, so malformed input is reported with Errorf.
+func readChunkedBody(t *testing.T, r *http.Request) string {
+	if r.Header.Get("X-Amz-Decoded-Content-Length") == "" {
+		body, _ := io.ReadAll(r.Body)
+		return string(body)
+	}
+
+	var payload strings.Builder
+	reader := bufio.NewReader(r.Body)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			t.Errorf("Malformed chunk header: %v", err)
+			return payload.String()
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			t.Errorf("Malformed chunk size %q: %v", header, err)
+			return payload.String()
+		}
+		if size == 0 {
+			return payload.String()
+		}
+		if _, err := io.CopyN(&payload, reader, size); err != nil {
+			t.Errorf("Short chunk: %v", err)
+			return payload.String()
+		}
+		reader.Discard(2) // CRLF after the chunk data
+	}
+}
+
+func TestComposeOperation(t *testing.T) {
+	var mu sync.Mutex
+	objects := map[string]string{}
+	parts := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		query := r.URL.Query()
+		writeHeaders := func(body string) {
+			w.Header().Set("ETag", `"etag"`)
+			w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		switch {
+		case r.Method == http.MethodPost && query.Has("uploads"):
+			io.WriteString(w, `<InitiateMultipartUploadResult><UploadId>upload</UploadId></InitiateMultipartUploadResult>`)
+		case r.Method == http.MethodPut && query.Has("uploadId"):
+			source, _ := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+			parts[query.Get("partNumber")] = objects["/"+strings.TrimPrefix(source, "/")]
+			io.WriteString(w, `<CopyPartResult><ETag>"etag"</ETag><LastModified>2024-01-01T00:00:00Z</LastModified></CopyPartResult>`)
+		case r.Method == http.MethodPost && query.Has("uploadId"):
+			objects[r.URL.Path] = parts["1"] + parts["2"] + parts["3"]
+			io.WriteString(w, `<CompleteMultipartUploadResult><Bucket>bucket</Bucket><ETag>"etag"</ETag></CompleteMultipartUploadResult>`)
+		case r.Method == http.MethodPut:
+			objects[r.URL.Path] = readChunkedBody(t, r)
+			w.Header().Set("ETag", `"etag"`)
+		case r.Method == http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+				return
+			}
+			writeHeaders(body)
+			if r.Method == http.MethodGet {
+				io.WriteString(w, body)
+			}
+		}
+	}))
+	defer server.Close()
+
+	client, err := minio.New(strings.TrimPrefix(server.URL, "http://"), &minio.Options{
+		Creds:  credentials.NewStaticV4("access", "secret", ""),
+		Region: "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &MinioClient{client: client, stats: &Stats{}, latency: newLatencyRecorder(), config: Config{Buckets: "bucket", ObjectPrefix: "test"}}
+	if err := m.composeOperation(context.Background()); err != nil {
+		t.Fatalf("composeOperation returned error: %v", err)
+	}
+	if m.stats.ComposeOps != 1 {
+		t.Errorf("Expected one compose operation, got %d", m.stats.ComposeOps)
+	}
+	if len(objects) != 1 {
+		t.Fatalf("Expected only the composed object to remain, got %d objects", len(objects))
+	}
+	for key, content := range objects {
+		if len(content) <= minPartSize || len(content) > 2*minPartSize+5120 {
+			t.Errorf("Unexpected composed size %d for %s", len(content), key)
+		}
+	}
+}
+
+func TestCleanupRun(t *testing.T) {
+	var mu sync.Mutex
+	// Objects map to their run ID; test-locked can't be deleted
+	objects := map[string]string{
+		"/bucket/test-a":      "run-1",
+		"/bucket/test-b":      "run-2",
+		"/bucket/test-locked": "run-1",
+		"/bucket/other":       "run-1",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Query().Has("versions"):
+			var listing strings.Builder
+			listing.WriteString(`<ListVersionsResult><Name>bucket</Name><IsTruncated>false</IsTruncated>`)
+			for path := range objects {
+				fmt.Fprintf(&listing, `<Version><Key>%s</Key><VersionId>null</VersionId><IsLatest>true</IsLatest><LastModified>2024-01-01T00:00:00Z</LastModified><ETag>"etag"</ETag><Size>1</Size></Version>`,
+					strings.TrimPrefix(path, "/bucket/"))
+			}
+			listing.WriteString(`<DeleteMarker><Key>test-c</Key><VersionId>marker</VersionId><IsLatest>true</IsLatest><LastModified>2024-01-01T00:00:00Z</LastModified></DeleteMarker>`)
+			listing.WriteString(`</ListVersionsResult>`)
+			io.WriteString(w, listing.String())
+		case r.Method == http.MethodHead:
+			w.Header().Set("ETag", `"etag"`)
+			w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+			w.Header().Set("Content-Length", "1")
+			w.Header().Set("X-Amz-Meta-Run-Id", objects[r.URL.Path])
+		case r.Method == http.MethodDelete && r.URL.Path == "/bucket/test-locked":
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, `<Error><Code>AccessDenied</Code><Message>Object is WORM protected and cannot be overwritten</Message></Error>`)
+		case r.Method == http.MethodDelete:
+			if r.URL.Query().Get("versionId") != "null" {
+				t.Errorf("Expected the listed version to be deleted, got %s", r.URL.RawQuery)
+			}
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer server.Close()
+
+	client, err := minio.New(strings.TrimPrefix(server.URL, "http://"), &minio.Options{
+		Creds:  credentials.NewStaticV4("access", "secret", ""),
+		Region: "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &MinioClient{client: client, config: Config{Buckets: "bucket", ObjectPrefix: "test"}}
+	removed, failed, err := m.cleanupRun(context.Background(), "run-1")
+	if err != nil {
+		t.Fatalf("cleanupRun returned error: %v", err)
+	}
+	if removed != 1 || failed != 1 {
+		t.Errorf("Expected 1 removed and 1 failed object, got %d and %d", removed, failed)
+	}
+	for _, path := range []string{"/bucket/test-b", "/bucket/test-locked", "/bucket/other"} {
+		if _, ok := objects[path]; !ok {
+			t.Errorf("Expected %s to be kept", path)
+		}
+	}
+	if _, ok := objects["/bucket/test-a"]; ok {
+		t.Error("Expected the object of run-1 to be deleted")
+	}
+}
+
+func TestCleanupPrefix(t *testing.T) {
+	var mu sync.Mutex
+	objects := map[string]bool{"test-1": true, "logs/test-2": true, "keep-me": true}
+	uploads := map[string]bool{"test-3": true, "other": true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodGet && query.Has("uploads"):
+			var listing strings.Builder
+			listing.WriteString(`<ListMultipartUploadsResult><Bucket>bucket</Bucket><IsTruncated>false</IsTruncated>`)
+			for key := range uploads {
+				if strings.HasPrefix(key, query.Get("prefix")) {
+					fmt.Fprintf(&listing, `<Upload><Key>%s</Key><UploadId>%s-upload</UploadId><Initiated>2024-01-01T00:00:00Z</Initiated></Upload>`, key, key)
+				}
+			}
+			listing.WriteString(`</ListMultipartUploadsResult>`)
+			io.WriteString(w, listing.String())
+		case r.Method == http.MethodGet:
+			var listing strings.Builder
+			listing.WriteString(`<ListBucketResult><Name>bucket</Name><IsTruncated>false</IsTruncated>`)
+			for key := range objects {
+				fmt.Fprintf(&listing, `<Contents><Key>%s</Key><Size>1</Size><LastModified>2024-01-01T00:00:00Z</LastModified></Contents>`, key)
+			}
+			listing.WriteString(`</ListBucketResult>`)
+			io.WriteString(w, listing.String())
+		case r.Method == http.MethodPost && query.Has("delete"):
+			var request struct {
+				Objects []struct {
+					Key string `xml:"Key"`
+				} `xml:"Object"`
+			}
+			if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+				t.Errorf("Failed to decode multi-object delete: %v", err)
+			}
+			for _, object := range request.Objects {
+				delete(objects, object.Key)
+			}
+			io.WriteString(w, `<DeleteResult></DeleteResult>`)
+		case r.Method == http.MethodDelete && query.Has("uploadId"):
+			delete(uploads, strings.TrimSuffix(query.Get("uploadId"), "-upload"))
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete && r.URL.Path == "/bucket/":
+			w.WriteHeader(http.StatusConflict)
+			io.WriteString(w, `<Error><Code>BucketNotEmpty</Code><Message>The bucket you tried to delete is not empty</Message></Error>`)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer server.Close()
+
+	client, err := minio.New(strings.TrimPrefix(server.URL, "http://"), &minio.Options{
+		Creds:  credentials.NewStaticV4("access", "secret", ""),
+		Region: "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &MinioClient{client: client, config: Config{Buckets: "bucket", ObjectPrefix: "test"}}
+	removed, failed, err := m.cleanupPrefix(context.Background())
+	if err != nil {
+		t.Fatalf("cleanupPrefix returned error: %v", err)
+	}
+	if removed != 3 || failed != 0 {
+		t.Errorf("Expected 2 objects and 1 upload removed, got %d removed and %d failed", removed, failed)
+	}
+	if !reflect.DeepEqual(objects, map[string]bool{"keep-me": true}) || !reflect.DeepEqual(uploads, map[string]bool{"other": true}) {
+		t.Errorf("Expected only keys without the prefix to be kept, got objects %v and uploads %v", objects, uploads)
+	}
+
+	// The bucket still holds keep-me, which is not a failure
+	if failed := m.removeEmptyBuckets(context.Background()); failed != 0 {
+		t.Errorf("Expected a non-empty bucket to be kept without failing, got %d failures", failed)
+	}
+}
+
+func TestCancelAbortsInFlightOperation(t *testing.T) {
+	requested := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hang like a slow upload until the test is over
+		once.Do(func() { close(requested) })
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := minio.New(strings.TrimPrefix(server.URL, "http://"), &minio.Options{
+		Creds:  credentials.NewStaticV4("access", "secret", ""),
+		Region: "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &MinioClient{client: client, config: Config{Buckets: "bucket", ObjectPrefix: "test"}, stats: &Stats{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.runRandomOperation(ctx, []string{"write"})
+	}()
+
+	<-requested
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the in-flight write to return after cancel")
+	}
+	if stats := m.stats.Snapshot(); stats.WriteOps != 0 || stats.ErrorOps != 0 {
+		t.Errorf("Expected a cancelled write to count neither as success nor as error, got %+v", stats)
+	}
+}
+
+func TestMaxErrorRateStopsRun(t *testing.T) {
+	var calls int64
+	registerTestOperation(t, "test-failing", func(ctx context.Context, m *MinioClient) error {
+		atomic.AddInt64(&calls, 1)
+		return errors.New("failing operation failed")
+	})
+
+	m := &MinioClient{
+		config: Config{Concurrency: 4, Rate: 0, Operations: "test-failing", MaxErrorRate: 0.5, MinSamples: 20},
+		stats:  &Stats{},
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.runOperations(context.Background())
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the run to stop once the error rate was exceeded")
+	}
+	if !m.aborted.Load() {
+		t.Error("Expected the run to be marked as aborted")
+	}
+	// Workers already running when the threshold is crossed may finish their operation
+	if errs := m.stats.Snapshot().ErrorOps; errs < 20 || errs > 20+4 {
+		t.Errorf("Expected to stop right after 20 samples, got %d errors", errs)
+	}
+}
+
+func TestEndpointRoundRobin(t *testing.T) {
+	var clients []*minio.Client
+	endpoints := []string{"node1:9000", "node2:9000", "node3:9000"}
+	for _, endpoint := range endpoints {
+		client, err := minio.New(endpoint, &minio.Options{Creds: credentials.NewStaticV4("access", "secret", "")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		clients = append(clients, client)
+	}
+	m := &MinioClient{
+		client:        clients[0],
+		clients:       clients,
+		endpoints:     endpoints,
+		endpointStats: make([]endpointCounters, len(clients)),
+	}
+
+	ctx := context.Background()
+	if m.ClientFor(ctx) != clients[0] {
+		t.Error("Expected the first endpoint without a chosen one")
+	}
+	for i := 0; i < 2*len(clients); i++ {
+		attemptCtx, endpoint := m.withNextEndpoint(ctx)
+		if endpoint != i%len(clients) {
+			t.Fatalf("Attempt %d: expected endpoint %d, got %d", i, i%len(clients), endpoint)
+		}
+		if m.ClientFor(attemptCtx) != clients[endpoint] {
+			t.Errorf("Attempt %d: expected the client of %s", i, endpoints[endpoint])
+		}
+	}
+
+	m.countEndpointAttempt(ctx, 1, nil)
+	m.countEndpointAttempt(ctx, 1, errors.New("connection refused"))
+	m.countEndpointAttempt(ctx, 2, nil)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	m.countEndpointAttempt(canceled, 2, errors.New("context canceled"))
+
+	expected := []endpointReport{
+		{Endpoint: "node1:9000"},
+		{Endpoint: "node2:9000", Ops: 1, Errors: 1},
+		{Endpoint: "node3:9000", Ops: 1},
+	}
+	if got := m.endpointReports(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	if (&MinioClient{}).endpointReports() != nil {
+		t.Error("Expected no endpoint reports without --endpoint-stats")
+	}
+}
+
+func TestWriteManifest(t *testing.T) {
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := &MinioClient{
+		config: Config{
+			Endpoint:       "localhost:9000",
+			SecretKey:      "secret",
+			RunID:          "run-1",
+			OperationDelay: 500 * time.Millisecond,
+			MultipartSize:  70 * 1024 * 1024,
+		},
+		stats:     &Stats{WriteOps: 3, ErrorOps: 1, BytesWritten: 2 * 1024 * 1024},
+		startTime: start,
+		latency:   newLatencyRecorder(),
+		outcomes:  newOutcomeRecorder(),
+	}
+	m.outcomes.Record("write", nil)
+	m.outcomes.Record("read", errors.New("read operation failed"))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.json")
+	if err := os.WriteFile(path, []byte("previous run"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.writeManifest(path, start.Add(2*time.Second)); err != nil {
+		t.Fatalf("writeManifest returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest runManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("Failed to decode manifest: %v\n%s", err, data)
+	}
+	if manifest.RunID != "run-1" || manifest.ElapsedSeconds != 2 || manifest.TotalOps != 3 || manifest.Stats.ErrorOps != 1 {
+		t.Errorf("Unexpected manifest %+v", manifest)
+	}
+	if manifest.ThroughputMiBps != 1 {
+		t.Errorf("Expected 1 MiB/s, got %v", manifest.ThroughputMiBps)
+	}
+	if manifest.Operations["read"].Failed != 1 {
+		t.Errorf("Expected the failed read in the breakdown, got %+v", manifest.Operations)
+	}
+	for key, want := range map[string]any{"endpoint": "localhost:9000", "secret-key": "REDACTED", "delay": "500ms", "multipart-size": "70 MiB"} {
+		if manifest.Config[key] != want {
+			t.Errorf("Expected config %s = %v, got %v", key, want, manifest.Config[key])
+		}
+	}
+
+	// Only the manifest remains, the temporary file was renamed
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the manifest in %s, got %v", dir, entries)
+	}
+}
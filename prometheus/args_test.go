@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	args, err := parseArgs([]string{"--both", "sample.txt", "10"})
+	if err != nil {
+		t.Fatalf("parseArgs returned error: %v", err)
+	}
+	if args.Input != "sample.txt" || args.TopN != 10 {
+		t.Fatalf("unexpected args: %+v", args)
+	}
+	if !args.Display.ShowVersions || !args.Display.ShowSizes {
+		t.Fatalf("expected --both to enable versions and sizes, got %+v", args.Display)
+	}
+	if args.JSON {
+		t.Fatalf("expected the table output by default")
+	}
+
+	args, err = parseArgs([]string{"sample.txt", "--json"})
+	if err != nil {
+		t.Fatalf("parseArgs returned error: %v", err)
+	}
+	if !args.JSON {
+		t.Fatalf("expected --json to select the JSON output")
+	}
+
+	args, err = parseArgs([]string{"--sizes", "-", "3"})
+	if err != nil {
+		t.Fatalf("parseArgs returned error: %v", err)
+	}
+	if args.Input != "-" || args.TopN != 3 {
+		t.Fatalf("expected - to be taken as the stdin input, got %+v", args)
+	}
+
+	for _, flag := range []string{"--reverse", "--asc"} {
+		args, err = parseArgs([]string{"sample.txt", flag})
+		if err != nil {
+			t.Fatalf("parseArgs returned error: %v", err)
+		}
+		if !args.Display.Reverse {
+			t.Fatalf("expected %s to reverse the sort order", flag)
+		}
+	}
+}
+
+func TestParseArgsRejectsContradictions(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "no input", args: []string{"--versions"}},
+		{name: "two inputs", args: []string{"a.txt", "b.txt"}},
+		{name: "unknown option", args: []string{"sample.txt", "--verbose"}},
+		{name: "missing value", args: []string{"http://localhost:9000/metrics", "--bearer-token"}},
+		{name: "zero top n", args: []string{"sample.txt", "0"}},
+		{name: "token and keys", args: []string{"http://localhost:9000/metrics", "--bearer-token", "t", "--access-key", "a"}},
+		{name: "token for file input", args: []string{"sample.txt", "--bearer-token", "t"}},
+		{name: "keys for file input", args: []string{"sample.txt", "--access-key", "a", "--secret-key", "s"}},
+		{name: "unknown sort key", args: []string{"sample.txt", "--sort", "date"}},
+		{name: "invalid filter regex", args: []string{"sample.txt", "--filter", "a("}},
+		{name: "invalid min objects", args: []string{"sample.txt", "--min-objects", "many"}},
+		{name: "invalid min size", args: []string{"sample.txt", "--min-size", "1XB"}},
+		{name: "diff with csv", args: []string{"new.txt", "--diff", "old.txt", "--csv", "out.csv"}},
+		{name: "diff with min size", args: []string{"new.txt", "--diff", "old.txt", "--min-size", "1GiB"}},
+		{name: "diff with sort", args: []string{"new.txt", "--diff", "old.txt", "--sort", "name"}},
+		{name: "diff with reverse", args: []string{"new.txt", "--diff", "old.txt", "--asc"}},
+		{name: "diff with top n", args: []string{"new.txt", "--diff", "old.txt", "10"}},
+		{name: "diff both from stdin", args: []string{"-", "--diff", "-"}},
+		{name: "by server with diff", args: []string{"new.txt", "--by-server", "--diff", "old.txt"}},
+		{name: "by server with csv", args: []string{"sample.txt", "--by-server", "--csv", "out.csv"}},
+		{name: "by server with top n", args: []string{"sample.txt", "--by-server", "3"}},
+		{name: "by server with cluster", args: []string{"sample.txt", "--by-server", "--cluster"}},
+		{name: "missing csv file", args: []string{"sample.txt", "--csv"}},
+		{name: "csv totals without csv", args: []string{"sample.txt", "--no-csv-totals"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseArgs(tt.args); err == nil {
+				t.Fatalf("expected %v to be rejected", tt.args)
+			}
+		})
+	}
+}
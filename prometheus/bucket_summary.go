@@ -2,25 +2,68 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 )
 
 // BucketSummary represents the summary information for a bucket
 type BucketSummary struct {
-	Name                string
-	ObjectCount         int64
-	SizeBytes           int64
-	SizeHuman           string
-	Servers             []string
-	VersionDistribution map[string]int64 // Tracks object version distribution
-	SizeDistribution    map[string]int64 // Tracks object size distribution
+	Name                string           `json:"name"`
+	ObjectCount         int64            `json:"objectCount"`
+	SizeBytes           int64            `json:"sizeBytes"`
+	SizeHuman           string           `json:"sizeHuman"`
+	Servers             []string         `json:"servers"`
+	VersionDistribution map[string]int64 `json:"versionDistribution"` // Tracks object version distribution
+	SizeDistribution    map[string]int64 `json:"sizeDistribution"`    // Tracks object size distribution
+	// ServerUsage holds the object count and size each server reported, keyed by server
+	ServerUsage map[string]*ServerUsage `json:"serverUsage,omitempty"`
+}
+
+// ServerUsage is the usage of a bucket reported by one server
+type ServerUsage struct {
+	ObjectCount int64 `json:"objectCount"`
+	SizeBytes   int64 `json:"sizeBytes"`
+}
+
+// clusterAggregateName is the row name used for the cluster-level aggregates
+const clusterAggregateName = "<cluster-aggregate>"
+
+// SummaryReport is the JSON document printed with --json
+type SummaryReport struct {
+	Buckets []BucketReport `json:"buckets"`
+	Totals  SummaryTotals  `json:"totals"`
+}
+
+// BucketReport is a bucket summary along with its derived statuses
+type BucketReport struct {
+	*BucketSummary
+	VersioningStatus string `json:"versioningStatus"`
+	SizeStatus       string `json:"sizeStatus"`
+}
+
+// SummaryTotals holds the totals over all reported buckets
+type SummaryTotals struct {
+	Buckets     int    `json:"buckets"`
+	ObjectCount int64  `json:"objectCount"`
+	SizeBytes   int64  `json:"sizeBytes"`
+	SizeHuman   string `json:"sizeHuman"`
 }
 
 // MetricParser parses Prometheus metrics
@@ -31,15 +74,43 @@ type MetricParser struct {
 	ClusterBytes       int64
 	ClusterVersionDist map[string]int64
 	ClusterSizeDist    map[string]int64
+	// Timestamp is the latest sample timestamp, zero when the metrics carry none
+	Timestamp time.Time
 }
 
 // DisplayOptions controls what information to show
 type DisplayOptions struct {
-	ShowVersions bool // Show version distribution
-	ShowSizes    bool // Show size distribution
-	Cluster      bool // Force include cluster-level aggregates
+	ShowVersions bool           // Show version distribution
+	ShowSizes    bool           // Show size distribution
+	Cluster      bool           // Force include cluster-level aggregates
+	SortBy       string         // Sort key of the buckets, one of the sortBy* keys (size when empty)
+	Reverse      bool           // Reverse the sort order, e.g. smallest buckets first
+	Filter       *regexp.Regexp // Keep only the buckets whose name matches, all when nil
+	MinObjects   int64          // Keep only the buckets with at least this many objects
+	MinSize      int64          // Keep only the buckets of at least this many bytes
+	SizeRanges   []SizeRange    // Size range mapping of --ranges, the built-in one when nil
+}
+
+// selects reports whether the options leave any bucket out
+func (opts DisplayOptions) selects() bool {
+	return opts.Filter != nil || opts.MinObjects > 0 || opts.MinSize > 0
+}
+
+// includes reports whether the bucket passes the name filter and thresholds
+func (opts DisplayOptions) includes(bucket *BucketSummary) bool {
+	if opts.Filter != nil && !opts.Filter.MatchString(bucket.Name) {
+		return false
+	}
+	return bucket.ObjectCount >= opts.MinObjects && bucket.SizeBytes >= opts.MinSize
 }
 
+// Sort keys of --sort
+const (
+	sortBySize    = "size"
+	sortByObjects = "objects"
+	sortByName    = "name"
+)
+
 // NewMetricParser creates a new metric parser
 func NewMetricParser() *MetricParser {
 	return &MetricParser{
@@ -64,6 +135,49 @@ func formatBytes(bytes int64) string {
 		float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
+// sizeUnits maps the suffixes accepted by parseSize to their multiplier. They
+// are powers of 1024, like the sizes formatBytes prints.
+var sizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"K":   1 << 10,
+	"KB":  1 << 10,
+	"KIB": 1 << 10,
+	"M":   1 << 20,
+	"MB":  1 << 20,
+	"MIB": 1 << 20,
+	"G":   1 << 30,
+	"GB":  1 << 30,
+	"GIB": 1 << 30,
+	"T":   1 << 40,
+	"TB":  1 << 40,
+	"TIB": 1 << 40,
+	"P":   1 << 50,
+	"PB":  1 << 50,
+	"PIB": 1 << 50,
+}
+
+// parseSize parses a size in bytes with an optional human suffix, e.g. 512,
+// 100MB, 1.5GiB or 2T
+func parseSize(value string) (int64, error) {
+	trimmed := strings.TrimSpace(value)
+	i := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(trimmed)
+	}
+	number, err := strconv.ParseFloat(trimmed[:i], 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	unit, ok := sizeUnits[strings.ToUpper(strings.TrimSpace(trimmed[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", value, trimmed[i:])
+	}
+	return int64(number * float64(unit)), nil
+}
+
 // formatVersionDistribution creates a summary of version distribution
 func formatVersionDistribution(versionDist map[string]int64) string {
 	if len(versionDist) == 0 {
@@ -138,62 +252,114 @@ func getVersioningStatus(versionDist map[string]int64) string {
 	}
 }
 
-// formatSizeDistribution creates a summary of size distribution
-func formatSizeDistribution(sizeDist map[string]int64) string {
+// Coarse size categories of the size distribution ranges
+const (
+	sizeSmall  = "small"
+	sizeMedium = "medium"
+	sizeLarge  = "large"
+)
+
+// SizeRange maps a raw size distribution range label to its display label
+// and coarse category
+type SizeRange struct {
+	Range    string `json:"range"`
+	Label    string `json:"label"`
+	Category string `json:"category"` // small, medium or large
+}
+
+// defaultSizeRanges is the built-in mapping of the MinIO size distribution
+// ranges, smallest to largest. The range keys are normalized, so
+// BETWEEN_1024B_AND_1_MB is covered by BETWEEN_1024_B_AND_1_MB.
+var defaultSizeRanges = []SizeRange{
+	{Range: "LESS_THAN_1024_B", Label: "<1KB", Category: sizeSmall},
+	{Range: "BETWEEN_1024_B_AND_64_KB", Label: "1KB-64KB", Category: sizeSmall},
+	{Range: "BETWEEN_64_KB_AND_256_KB", Label: "64KB-256KB", Category: sizeSmall},
+	{Range: "BETWEEN_256_KB_AND_512_KB", Label: "256KB-512KB", Category: sizeSmall},
+	{Range: "BETWEEN_512_KB_AND_1_MB", Label: "512KB-1MB", Category: sizeSmall},
+	{Range: "BETWEEN_1024_B_AND_1_MB", Label: "1KB-1MB", Category: sizeSmall},
+	{Range: "BETWEEN_1_MB_AND_10_MB", Label: "1-10MB", Category: sizeMedium},
+	{Range: "BETWEEN_10_MB_AND_64_MB", Label: "10-64MB", Category: sizeMedium},
+	{Range: "BETWEEN_64_MB_AND_128_MB", Label: "64-128MB", Category: sizeLarge},
+	{Range: "BETWEEN_128_MB_AND_512_MB", Label: "128-512MB", Category: sizeLarge},
+	{Range: "GREATER_THAN_512_MB", Label: ">512MB", Category: sizeLarge},
+}
+
+// loadSizeRanges reads the size range mapping of --ranges from a JSON file
+// holding a list of {"range", "label", "category"} objects, in display order.
+// The label defaults to the range.
+func loadSizeRanges(filename string) ([]SizeRange, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ranges file: %w", err)
+	}
+	var ranges []SizeRange
+	if err := json.Unmarshal(data, &ranges); err != nil {
+		return nil, fmt.Errorf("error parsing ranges file %s: %w", filename, err)
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("ranges file %s has no ranges", filename)
+	}
+
+	seen := make(map[string]bool)
+	for i := range ranges {
+		r := &ranges[i]
+		if r.Range == "" {
+			return nil, fmt.Errorf("ranges file %s: entry %d has no range", filename, i+1)
+		}
+		// match the keys the parser stores
+		r.Range = normalizeRange(r.Range)
+		if seen[r.Range] {
+			return nil, fmt.Errorf("ranges file %s: range %s is listed twice", filename, r.Range)
+		}
+		seen[r.Range] = true
+		if r.Label == "" {
+			r.Label = r.Range
+		}
+		switch r.Category {
+		case sizeSmall, sizeMedium, sizeLarge:
+		default:
+			return nil, fmt.Errorf("ranges file %s: range %s has category %q, expected %s, %s or %s", filename, r.Range, r.Category, sizeSmall, sizeMedium, sizeLarge)
+		}
+	}
+	return ranges, nil
+}
+
+// sizeRanges returns the size range mapping of --ranges, the built-in one
+// when none was loaded
+func (opts DisplayOptions) sizeRanges() []SizeRange {
+	if opts.SizeRanges != nil {
+		return opts.SizeRanges
+	}
+	return defaultSizeRanges
+}
+
+// formatSizeDistribution creates a summary of size distribution. Ranges the
+// mapping doesn't know are listed last under their raw name rather than
+// dropped.
+func formatSizeDistribution(sizeDist map[string]int64, ranges []SizeRange) string {
 	if len(sizeDist) == 0 {
 		return "N/A"
 	}
 
 	var parts []string
-
-	// Order the ranges for better readability (smallest to largest)
-	rangeOrder := []string{
-		"LESS_THAN_1024_B",
-		// 1KB-64KB (sometimes labeled BETWEEN_1024_B_AND_64_KB)
-		"BETWEEN_1024_B_AND_64_KB",
-		// various KB ranges
-		"BETWEEN_64_KB_AND_256_KB",
-		"BETWEEN_256_KB_AND_512_KB",
-		"BETWEEN_512_KB_AND_1_MB",
-		// 1KB-1MB (sometimes labeled BETWEEN_1024B_AND_1_MB or BETWEEN_1024_B_AND_1_MB)
-		"BETWEEN_1024_B_AND_1_MB",
-		"BETWEEN_1024B_AND_1_MB",
-		// MB ranges
-		"BETWEEN_1_MB_AND_10_MB",
-		"BETWEEN_10_MB_AND_64_MB",
-		"BETWEEN_64_MB_AND_128_MB",
-		"BETWEEN_128_MB_AND_512_MB",
-		"GREATER_THAN_512_MB",
+	known := make(map[string]bool)
+	for _, r := range ranges {
+		known[r.Range] = true
+		if count := sizeDist[r.Range]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", r.Label, count))
+		}
 	}
 
-	for _, rangeKey := range rangeOrder {
-		if count, exists := sizeDist[rangeKey]; exists && count > 0 {
-			switch rangeKey {
-			case "LESS_THAN_1024_B":
-				parts = append(parts, fmt.Sprintf("<1KB: %d", count))
-			case "BETWEEN_1024_B_AND_64_KB":
-				parts = append(parts, fmt.Sprintf("1KB-64KB: %d", count))
-			case "BETWEEN_64_KB_AND_256_KB":
-				parts = append(parts, fmt.Sprintf("64KB-256KB: %d", count))
-			case "BETWEEN_256_KB_AND_512_KB":
-				parts = append(parts, fmt.Sprintf("256KB-512KB: %d", count))
-			case "BETWEEN_512_KB_AND_1_MB":
-				parts = append(parts, fmt.Sprintf("512KB-1MB: %d", count))
-			case "BETWEEN_1024_B_AND_1_MB", "BETWEEN_1024B_AND_1_MB":
-				parts = append(parts, fmt.Sprintf("1KB-1MB: %d", count))
-			case "BETWEEN_1_MB_AND_10_MB":
-				parts = append(parts, fmt.Sprintf("1-10MB: %d", count))
-			case "BETWEEN_10_MB_AND_64_MB":
-				parts = append(parts, fmt.Sprintf("10-64MB: %d", count))
-			case "BETWEEN_64_MB_AND_128_MB":
-				parts = append(parts, fmt.Sprintf("64-128MB: %d", count))
-			case "BETWEEN_128_MB_AND_512_MB":
-				parts = append(parts, fmt.Sprintf("128-512MB: %d", count))
-			case "GREATER_THAN_512_MB":
-				parts = append(parts, fmt.Sprintf(">512MB: %d", count))
-			}
+	var unknown []string
+	for rangeKey, count := range sizeDist {
+		if !known[rangeKey] && count > 0 {
+			unknown = append(unknown, rangeKey)
 		}
 	}
+	sort.Strings(unknown)
+	for _, rangeKey := range unknown {
+		parts = append(parts, fmt.Sprintf("%s: %d", rangeKey, sizeDist[rangeKey]))
+	}
 
 	if len(parts) == 0 {
 		return "All zeros"
@@ -202,21 +368,27 @@ func formatSizeDistribution(sizeDist map[string]int64) string {
 	return strings.Join(parts, ", ")
 }
 
-// getSizeStatus provides a simple status based on size distribution
-func getSizeStatus(sizeDist map[string]int64) string {
+// getSizeStatus provides a simple status based on size distribution, from
+// the objects in the small, medium and large categories of the ranges
+func getSizeStatus(sizeDist map[string]int64, ranges []SizeRange) string {
 	if len(sizeDist) == 0 {
 		return "Unknown"
 	}
 
-	// small: <1KB + 1KB-1MB (and KB subranges)
-	small := sizeDist["LESS_THAN_1024_B"] + sizeDist["BETWEEN_1024_B_AND_64_KB"] + sizeDist["BETWEEN_1024_B_AND_1_MB"] + sizeDist["BETWEEN_1024B_AND_1_MB"] + sizeDist["BETWEEN_64_KB_AND_256_KB"] + sizeDist["BETWEEN_256_KB_AND_512_KB"] + sizeDist["BETWEEN_512_KB_AND_1_MB"]
-	// medium: 1-10MB and 10-64MB
-	medium := sizeDist["BETWEEN_1_MB_AND_10_MB"] + sizeDist["BETWEEN_10_MB_AND_64_MB"]
-	// large: >=64MB
-	large := sizeDist["BETWEEN_64_MB_AND_128_MB"] + sizeDist["BETWEEN_128_MB_AND_512_MB"] + sizeDist["GREATER_THAN_512_MB"]
+	categories := make(map[string]int64)
+	for _, r := range ranges {
+		categories[r.Category] += sizeDist[r.Range]
+	}
+	small, medium, large := categories[sizeSmall], categories[sizeMedium], categories[sizeLarge]
 
 	total := small + medium + large
 	if total == 0 {
+		for _, count := range sizeDist {
+			if count > 0 {
+				// only objects in ranges the mapping doesn't know
+				return "Unknown"
+			}
+		}
 		return "Empty"
 	}
 
@@ -304,12 +476,38 @@ func isLetter(c byte) bool {
 	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
 }
 
+// sampleFields returns the fields after the metric name and labels: the value
+// and the optional timestamp
+func sampleFields(line string) []string {
+	if i := strings.LastIndex(line, "}"); i >= 0 {
+		return strings.Fields(line[i+1:])
+	}
+	if parts := strings.Fields(line); len(parts) > 0 {
+		return parts[1:]
+	}
+	return nil
+}
+
+// extractTimestamp extracts the optional sample timestamp (milliseconds since
+// the epoch) from the line
+func extractTimestamp(line string) (time.Time, bool) {
+	parts := sampleFields(line)
+	if len(parts) < 2 {
+		return time.Time{}, false
+	}
+	millis, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(millis), true
+}
+
 // extractValue extracts the metric value from the line
 func extractValue(line string) int64 {
-	parts := strings.Fields(line)
+	parts := sampleFields(line)
 	if len(parts) > 0 {
-		// Get the last part which should be the value
-		valueStr := parts[len(parts)-1]
+		// The value comes first, before the optional timestamp
+		valueStr := parts[0]
 		// Try integer first, then float (to handle scientific notation like 1.23e+08)
 		if value, err := strconv.ParseInt(valueStr, 10, 64); err == nil {
 			return value
@@ -331,15 +529,99 @@ func (bs *BucketSummary) addServer(server string) {
 	bs.Servers = append(bs.Servers, server)
 }
 
-// ParseFile parses the Prometheus metrics file
+// serverUsage returns the usage reported by server, adding it when missing
+func (bs *BucketSummary) serverUsage(server string) *ServerUsage {
+	usage, exists := bs.ServerUsage[server]
+	if !exists {
+		usage = &ServerUsage{}
+		bs.ServerUsage[server] = usage
+	}
+	return usage
+}
+
+// stdinInput is the input name that reads the metrics from stdin
+const stdinInput = "-"
+
+// ParseFile parses the Prometheus metrics file, stdin when filename is "-".
+// A file named *.gz must be gzip compressed.
 func (mp *MetricParser) ParseFile(filename string) error {
+	if filename == stdinInput {
+		return mp.ParseReader(os.Stdin)
+	}
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return fmt.Errorf("error opening file: %w", err)
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	if strings.HasSuffix(filename, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("error opening gzip file: %w", err)
+		}
+		defer gz.Close()
+		return mp.ParseReader(gz)
+	}
+	return mp.ParseReader(file)
+}
+
+// gzipMagic are the first bytes of gzip compressed data
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// decompressReader returns a reader of the decompressed data when r starts
+// with the gzip magic bytes, of r's data unchanged otherwise
+func decompressReader(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(len(gzipMagic))
+	if err != nil || !bytes.Equal(magic, gzipMagic) {
+		// too short to be gzip, let the scanner read what there is
+		return buffered, nil
+	}
+	gz, err := gzip.NewReader(buffered)
+	if err != nil {
+		return nil, fmt.Errorf("error opening gzip data: %w", err)
+	}
+	return gz, nil
+}
+
+// ScrapeURL fetches metrics from a live MinIO metrics endpoint and parses them.
+// When token is non-empty it is sent as a bearer token.
+func (mp *MetricParser) ScrapeURL(metricsURL, token string) error {
+	req, err := http.NewRequest(http.MethodGet, metricsURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error scraping %s: %w", metricsURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error scraping %s: unexpected status %s", metricsURL, resp.Status)
+	}
+
+	return mp.ParseReader(resp.Body)
+}
+
+// ParseReader parses Prometheus metrics from the given reader, decompressing
+// them first when they are gzip compressed.
+//
+// Every server reports the usage of the whole bucket (and cluster), not its
+// own share, so the values of the same metric are not summed across servers:
+// the largest value reported for each bucket, metric and range is kept.
+func (mp *MetricParser) ParseReader(r io.Reader) error {
+	r, err := decompressReader(r)
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(r)
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -349,18 +631,22 @@ func (mp *MetricParser) ParseFile(filename string) error {
 			continue
 		}
 
+		if timestamp, ok := extractTimestamp(line); ok && timestamp.After(mp.Timestamp) {
+			mp.Timestamp = timestamp
+		}
+
 		bucketName := extractBucketName(line)
 		// If there's no bucket label, it might be a cluster-level metric. Parse those as fallback.
 		if bucketName == "" {
 			// Cluster object count
 			if strings.Contains(line, "minio_cluster_usage_object_total") {
-				mp.ClusterObjects += extractValue(line)
+				mp.ClusterObjects = max(mp.ClusterObjects, extractValue(line))
 				continue
 			}
 
 			// Cluster size
 			if strings.Contains(line, "minio_cluster_usage_total_bytes") {
-				mp.ClusterBytes += extractValue(line)
+				mp.ClusterBytes = max(mp.ClusterBytes, extractValue(line))
 				continue
 			}
 
@@ -368,7 +654,8 @@ func (mp *MetricParser) ParseFile(filename string) error {
 			if strings.Contains(line, "minio_cluster_objects_version_distribution") {
 				rangeValue := extractRange(line)
 				if rangeValue != "" {
-					mp.ClusterVersionDist[normalizeRange(rangeValue)] += extractValue(line)
+					key := normalizeRange(rangeValue)
+					mp.ClusterVersionDist[key] = max(mp.ClusterVersionDist[key], extractValue(line))
 				}
 				continue
 			}
@@ -377,7 +664,8 @@ func (mp *MetricParser) ParseFile(filename string) error {
 			if strings.Contains(line, "minio_cluster_objects_size_distribution") {
 				rangeValue := extractRange(line)
 				if rangeValue != "" {
-					mp.ClusterSizeDist[normalizeRange(rangeValue)] += extractValue(line)
+					key := normalizeRange(rangeValue)
+					mp.ClusterSizeDist[key] = max(mp.ClusterSizeDist[key], extractValue(line))
 				}
 				continue
 			}
@@ -395,6 +683,7 @@ func (mp *MetricParser) ParseFile(filename string) error {
 				Servers:             make([]string, 0),
 				VersionDistribution: make(map[string]int64),
 				SizeDistribution:    make(map[string]int64),
+				ServerUsage:         make(map[string]*ServerUsage),
 			}
 		}
 
@@ -404,22 +693,26 @@ func (mp *MetricParser) ParseFile(filename string) error {
 		// Parse object count metrics
 		if strings.Contains(line, "minio_bucket_usage_object_total") {
 			value := extractValue(line)
-			bucket.ObjectCount += value
+			bucket.ObjectCount = max(bucket.ObjectCount, value)
+			usage := bucket.serverUsage(serverName)
+			usage.ObjectCount = max(usage.ObjectCount, value)
 		}
 
 		// Parse size metrics
 		if strings.Contains(line, "minio_bucket_usage_total_bytes") {
 			value := extractValue(line)
-			bucket.SizeBytes += value
+			bucket.SizeBytes = max(bucket.SizeBytes, value)
 			bucket.SizeHuman = formatBytes(bucket.SizeBytes)
+			usage := bucket.serverUsage(serverName)
+			usage.SizeBytes = max(usage.SizeBytes, value)
 		}
 
 		// Parse version distribution metrics
 		if strings.Contains(line, "minio_bucket_objects_version_distribution") {
 			rangeValue := extractRange(line)
 			if rangeValue != "" {
-				value := extractValue(line)
-				bucket.VersionDistribution[normalizeRange(rangeValue)] += value
+				key := normalizeRange(rangeValue)
+				bucket.VersionDistribution[key] = max(bucket.VersionDistribution[key], extractValue(line))
 			}
 		}
 
@@ -427,8 +720,8 @@ func (mp *MetricParser) ParseFile(filename string) error {
 		if strings.Contains(line, "minio_bucket_objects_size_distribution") {
 			rangeValue := extractRange(line)
 			if rangeValue != "" {
-				value := extractValue(line)
-				bucket.SizeDistribution[normalizeRange(rangeValue)] += value
+				key := normalizeRange(rangeValue)
+				bucket.SizeDistribution[key] = max(bucket.SizeDistribution[key], extractValue(line))
 			}
 		}
 	}
@@ -436,46 +729,212 @@ func (mp *MetricParser) ParseFile(filename string) error {
 	return scanner.Err()
 }
 
-// GetSummary returns a sorted list of bucket summaries
-func (mp *MetricParser) GetSummary() []*BucketSummary {
+// GetSummary returns the list of bucket summaries matching opts.Filter and the
+// minimum thresholds, sorted by opts.SortBy
+func (mp *MetricParser) GetSummary(opts DisplayOptions) []*BucketSummary {
 	summaries := make([]*BucketSummary, 0, len(mp.buckets))
 
 	for _, bucket := range mp.buckets {
+		if !opts.includes(bucket) {
+			continue
+		}
 		summaries = append(summaries, bucket)
 	}
 
-	// Sort by size (descending)
+	sortSummaries(summaries, opts)
+	return summaries
+}
+
+// sortSummaries sorts the summaries by size or object count (descending), or
+// by name, as chosen by opts.SortBy. Ties are ordered by name so the output is
+// stable. With opts.Reverse the whole order is reversed.
+func sortSummaries(summaries []*BucketSummary, opts DisplayOptions) {
+	sortBy := opts.SortBy
 	sort.Slice(summaries, func(i, j int) bool {
-		return summaries[i].SizeBytes > summaries[j].SizeBytes
+		a, b := summaries[i], summaries[j]
+		switch sortBy {
+		case sortByObjects:
+			if a.ObjectCount != b.ObjectCount {
+				return a.ObjectCount > b.ObjectCount
+			}
+		case sortByName:
+		default:
+			if a.SizeBytes != b.SizeBytes {
+				return a.SizeBytes > b.SizeBytes
+			}
+		}
+		return a.Name < b.Name
 	})
+	if opts.Reverse {
+		slices.Reverse(summaries)
+	}
+}
 
-	return summaries
+// sortTitle names the order of the top N listing for the sort options
+func sortTitle(opts DisplayOptions) string {
+	title := "Size"
+	switch opts.SortBy {
+	case sortByObjects:
+		title = "Object Count"
+	case sortByName:
+		title = "Name"
+	}
+	if opts.Reverse {
+		title += " (reversed)"
+	}
+	return title
+}
+
+// hasClusterData reports whether any cluster-level aggregate was parsed
+func (mp *MetricParser) hasClusterData() bool {
+	return mp.ClusterObjects > 0 || mp.ClusterBytes > 0 || len(mp.ClusterVersionDist) > 0 || len(mp.ClusterSizeDist) > 0
+}
+
+// clusterSummary returns the cluster-level aggregates as a summary row
+func (mp *MetricParser) clusterSummary() *BucketSummary {
+	return &BucketSummary{
+		Name:                clusterAggregateName,
+		Servers:             make([]string, 0),
+		ObjectCount:         mp.ClusterObjects,
+		SizeBytes:           mp.ClusterBytes,
+		SizeHuman:           formatBytes(mp.ClusterBytes),
+		VersionDistribution: mp.ClusterVersionDist,
+		SizeDistribution:    mp.ClusterSizeDist,
+	}
+}
+
+// displaySummaries returns the rows to display: the bucket summaries of
+// GetSummary, the cluster-level aggregates instead when there is no per-bucket data
+// (fallback is then true), and with them when requested with opts.Cluster
+func (mp *MetricParser) displaySummaries(opts DisplayOptions) (summaries []*BucketSummary, fallback bool) {
+	summaries = mp.GetSummary(opts)
+	if !mp.hasClusterData() {
+		return summaries, false
+	}
+
+	// Buckets left out by the filter don't make the cluster aggregates a fallback
+	if len(mp.buckets) == 0 {
+		return []*BucketSummary{mp.clusterSummary()}, true
+	}
+
+	if opts.Cluster {
+		summaries = append(summaries, mp.clusterSummary())
+
+		// Re-sort after adding cluster aggregate so it fits into the ordering
+		sortSummaries(summaries, opts)
+	}
+	return summaries, false
+}
+
+// BuildReport builds the JSON report of the rows displayed by PrintSummaryTable
+func (mp *MetricParser) BuildReport(opts DisplayOptions) SummaryReport {
+	summaries, _ := mp.displaySummaries(opts)
+
+	report := SummaryReport{Buckets: make([]BucketReport, 0, len(summaries))}
+	for _, bucket := range summaries {
+		report.Buckets = append(report.Buckets, BucketReport{
+			BucketSummary:    bucket,
+			VersioningStatus: getVersioningStatus(bucket.VersionDistribution),
+			SizeStatus:       getSizeStatus(bucket.SizeDistribution, opts.sizeRanges()),
+		})
+	}
+	report.Totals.Buckets, report.Totals.ObjectCount, report.Totals.SizeBytes = summaryTotals(summaries)
+	report.Totals.SizeHuman = formatBytes(report.Totals.SizeBytes)
+	return report
+}
+
+// WriteSummaryJSON writes the report of the bucket summaries as indented JSON
+func (mp *MetricParser) WriteSummaryJSON(w io.Writer, opts DisplayOptions) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(mp.BuildReport(opts))
+}
+
+// WriteSummaryCSV writes one CSV row per bucket of the report, after a header
+// row, and a final totals row unless totals is false
+func (mp *MetricParser) WriteSummaryCSV(w io.Writer, opts DisplayOptions, totals bool) error {
+	report := mp.BuildReport(opts)
+
+	cw := csv.NewWriter(w)
+	rows := [][]string{{"bucket", "object_count", "size_bytes", "size_human", "versioning_status", "size_status"}}
+	for _, bucket := range report.Buckets {
+		rows = append(rows, []string{
+			bucket.Name,
+			strconv.FormatInt(bucket.ObjectCount, 10),
+			strconv.FormatInt(bucket.SizeBytes, 10),
+			bucket.SizeHuman,
+			bucket.VersioningStatus,
+			bucket.SizeStatus,
+		})
+	}
+	if totals {
+		rows = append(rows, []string{
+			fmt.Sprintf("TOTAL (%d buckets)", report.Totals.Buckets),
+			strconv.FormatInt(report.Totals.ObjectCount, 10),
+			strconv.FormatInt(report.Totals.SizeBytes, 10),
+			report.Totals.SizeHuman,
+			"",
+			"",
+		})
+	}
+	return cw.WriteAll(rows)
+}
+
+// writeSummaryCSVFile writes the CSV report of WriteSummaryCSV to filename
+func (mp *MetricParser) writeSummaryCSVFile(filename string, opts DisplayOptions, totals bool) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("error creating file: %w", err)
+	}
+	if err := mp.WriteSummaryCSV(file, opts, totals); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// summaryTotals counts the buckets of the summaries and adds up their object
+// count and size. The cluster aggregate row of --cluster covers the same
+// objects again, so it is left out unless it is the only row (the fallback
+// without per-bucket data).
+func summaryTotals(summaries []*BucketSummary) (buckets int, objects, bytes int64) {
+	for _, bucket := range summaries {
+		if bucket.Name == clusterAggregateName && len(summaries) > 1 {
+			continue
+		}
+		buckets++
+		objects += bucket.ObjectCount
+		bytes += bucket.SizeBytes
+	}
+	return buckets, objects, bytes
+}
+
+// percentOf formats part as a percentage of total, "-" when total is zero
+func percentOf(part, total int64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)/float64(total)*100)
+}
+
+// noBucketsMessage explains why there is no bucket to display
+func (mp *MetricParser) noBucketsMessage(opts DisplayOptions) string {
+	if opts.selects() && len(mp.buckets) > 0 {
+		return "No bucket matches the --filter/--min-objects/--min-size selection"
+	}
+	return "No bucket data found"
 }
 
 // PrintSummaryTable prints a formatted table of bucket summaries
 func (mp *MetricParser) PrintSummaryTable(opts DisplayOptions) {
-	summaries := mp.GetSummary()
+	summaries, fallback := mp.displaySummaries(opts)
 
 	if len(summaries) == 0 {
-		// If no per-bucket data, but cluster aggregates exist, print cluster summary
-		if mp.ClusterObjects > 0 || mp.ClusterBytes > 0 || len(mp.ClusterVersionDist) > 0 || len(mp.ClusterSizeDist) > 0 {
-			fmt.Println("No per-bucket data found; showing cluster-level aggregates instead")
-
-			// Create temporary summary row for cluster
-			cluster := &BucketSummary{
-				Name:                "<cluster-aggregate>",
-				ObjectCount:         mp.ClusterObjects,
-				SizeBytes:           mp.ClusterBytes,
-				SizeHuman:           formatBytes(mp.ClusterBytes),
-				VersionDistribution: mp.ClusterVersionDist,
-				SizeDistribution:    mp.ClusterSizeDist,
-			}
-
-			summaries = append(summaries, cluster)
-		} else {
-			fmt.Println("No bucket data found")
-			return
-		}
+		fmt.Println(mp.noBucketsMessage(opts))
+		return
+	}
+	if fallback {
+		fmt.Println("No per-bucket data found; showing cluster-level aggregates instead")
 	}
 
 	// Create tabwriter for aligned output with proper spacing
@@ -483,51 +942,23 @@ func (mp *MetricParser) PrintSummaryTable(opts DisplayOptions) {
 
 	// Print header based on display options
 	if opts.ShowVersions && opts.ShowSizes {
-		fmt.Fprintln(w, "BUCKET NAME\tOBJECT COUNT\tSIZE (BYTES)\tSIZE (HUMAN)\tVERSIONING\tSIZE DIST")
-		fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------\t--------")
+		fmt.Fprintln(w, "BUCKET NAME\tOBJECT COUNT\tSIZE (BYTES)\tSIZE (HUMAN)\t% BYTES\t% OBJECTS\tVERSIONING\tSIZE DIST")
+		fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------\t--------\t--------\t--------")
 	} else if opts.ShowVersions {
-		fmt.Fprintln(w, "BUCKET NAME\tOBJECT COUNT\tSIZE (BYTES)\tSIZE (HUMAN)\tVERSIONING")
-		fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------")
+		fmt.Fprintln(w, "BUCKET NAME\tOBJECT COUNT\tSIZE (BYTES)\tSIZE (HUMAN)\t% BYTES\t% OBJECTS\tVERSIONING")
+		fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------\t--------\t--------")
 	} else if opts.ShowSizes {
-		fmt.Fprintln(w, "BUCKET NAME\tOBJECT COUNT\tSIZE (BYTES)\tSIZE (HUMAN)\tSIZE DIST")
-		fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------")
+		fmt.Fprintln(w, "BUCKET NAME\tOBJECT COUNT\tSIZE (BYTES)\tSIZE (HUMAN)\t% BYTES\t% OBJECTS\tSIZE DIST")
+		fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------\t--------\t--------")
 	} else {
-		fmt.Fprintln(w, "BUCKET NAME\tOBJECT COUNT\tSIZE (BYTES)\tSIZE (HUMAN)")
-		fmt.Fprintln(w, "--------\t--------\t--------\t--------")
+		fmt.Fprintln(w, "BUCKET NAME\tOBJECT COUNT\tSIZE (BYTES)\tSIZE (HUMAN)\t% BYTES\t% OBJECTS")
+		fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------\t--------")
 	}
 
-	var totalObjects int64
-	var totalBytes int64
+	// The totals come first so each bucket's share of them can be printed
+	totalBuckets, totalObjects, totalBytes := summaryTotals(summaries)
 
 	// Print bucket data
-	// If user requested cluster-level aggregate explicitly and we have cluster data, append it
-	if opts.Cluster && (mp.ClusterObjects > 0 || mp.ClusterBytes > 0 || len(mp.ClusterVersionDist) > 0 || len(mp.ClusterSizeDist) > 0) {
-		// Avoid duplicating if summaries already contains the cluster-aggregate entry
-		found := false
-		for _, b := range summaries {
-			if b.Name == "<cluster-aggregate>" {
-				found = true
-				break
-			}
-		}
-		if !found {
-			cluster := &BucketSummary{
-				Name:                "<cluster-aggregate>",
-				ObjectCount:         mp.ClusterObjects,
-				SizeBytes:           mp.ClusterBytes,
-				SizeHuman:           formatBytes(mp.ClusterBytes),
-				VersionDistribution: mp.ClusterVersionDist,
-				SizeDistribution:    mp.ClusterSizeDist,
-			}
-			summaries = append(summaries, cluster)
-
-			// Re-sort after adding cluster aggregate so it fits into the ordering
-			sort.Slice(summaries, func(i, j int) bool {
-				return summaries[i].SizeBytes > summaries[j].SizeBytes
-			})
-		}
-	}
-
 	for _, bucket := range summaries {
 		// Truncate bucket name if too long
 		bucketName := bucket.Name
@@ -537,61 +968,66 @@ func (mp *MetricParser) PrintSummaryTable(opts DisplayOptions) {
 
 		if opts.ShowVersions && opts.ShowSizes {
 			versioningStatus := getVersioningStatus(bucket.VersionDistribution)
-			sizeStatus := getSizeStatus(bucket.SizeDistribution)
-			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n",
+			sizeStatus := getSizeStatus(bucket.SizeDistribution, opts.sizeRanges())
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\n",
 				bucketName,
 				bucket.ObjectCount,
 				bucket.SizeBytes,
 				bucket.SizeHuman,
+				percentOf(bucket.SizeBytes, totalBytes),
+				percentOf(bucket.ObjectCount, totalObjects),
 				versioningStatus,
 				sizeStatus)
 		} else if opts.ShowVersions {
 			versioningStatus := getVersioningStatus(bucket.VersionDistribution)
-			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n",
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n",
 				bucketName,
 				bucket.ObjectCount,
 				bucket.SizeBytes,
 				bucket.SizeHuman,
+				percentOf(bucket.SizeBytes, totalBytes),
+				percentOf(bucket.ObjectCount, totalObjects),
 				versioningStatus)
 		} else if opts.ShowSizes {
-			sizeStatus := getSizeStatus(bucket.SizeDistribution)
-			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n",
+			sizeStatus := getSizeStatus(bucket.SizeDistribution, opts.sizeRanges())
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n",
 				bucketName,
 				bucket.ObjectCount,
 				bucket.SizeBytes,
 				bucket.SizeHuman,
+				percentOf(bucket.SizeBytes, totalBytes),
+				percentOf(bucket.ObjectCount, totalObjects),
 				sizeStatus)
 		} else {
-			fmt.Fprintf(w, "%s\t%d\t%d\t%s\n",
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n",
 				bucketName,
 				bucket.ObjectCount,
 				bucket.SizeBytes,
-				bucket.SizeHuman)
+				bucket.SizeHuman,
+				percentOf(bucket.SizeBytes, totalBytes),
+				percentOf(bucket.ObjectCount, totalObjects))
 		}
-
-		totalObjects += bucket.ObjectCount
-		totalBytes += bucket.SizeBytes
 	}
 
 	// Print totals
 	if opts.ShowVersions && opts.ShowSizes {
-		fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------\t--------")
-		fmt.Fprintf(w, "TOTAL (%d buckets)\t%d\t%d\t%s\t\t\n",
-			len(summaries),
+		fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------\t--------\t--------\t--------")
+		fmt.Fprintf(w, "TOTAL (%d buckets)\t%d\t%d\t%s\t\t\t\t\n",
+			totalBuckets,
 			totalObjects,
 			totalBytes,
 			formatBytes(totalBytes))
 	} else if opts.ShowVersions || opts.ShowSizes {
-		fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------")
-		fmt.Fprintf(w, "TOTAL (%d buckets)\t%d\t%d\t%s\t\n",
-			len(summaries),
+		fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------\t--------\t--------")
+		fmt.Fprintf(w, "TOTAL (%d buckets)\t%d\t%d\t%s\t\t\t\n",
+			totalBuckets,
 			totalObjects,
 			totalBytes,
 			formatBytes(totalBytes))
 	} else {
-		fmt.Fprintln(w, "--------\t--------\t--------\t--------")
-		fmt.Fprintf(w, "TOTAL (%d buckets)\t%d\t%d\t%s\n",
-			len(summaries),
+		fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------\t--------")
+		fmt.Fprintf(w, "TOTAL (%d buckets)\t%d\t%d\t%s\t\t\n",
+			totalBuckets,
 			totalObjects,
 			totalBytes,
 			formatBytes(totalBytes))
@@ -600,60 +1036,22 @@ func (mp *MetricParser) PrintSummaryTable(opts DisplayOptions) {
 	w.Flush()
 }
 
-// PrintTopBuckets prints the top N buckets by size
+// PrintTopBuckets prints the top N buckets in the opts.SortBy order
 func (mp *MetricParser) PrintTopBuckets(n int, opts DisplayOptions) {
-	summaries := mp.GetSummary()
+	summaries, _ := mp.displaySummaries(opts)
 
 	if len(summaries) == 0 {
-		// Fallback to cluster-level aggregates if available
-		if mp.ClusterObjects > 0 || mp.ClusterBytes > 0 || len(mp.ClusterVersionDist) > 0 || len(mp.ClusterSizeDist) > 0 {
-			cluster := &BucketSummary{
-				Name:                "<cluster-aggregate>",
-				ObjectCount:         mp.ClusterObjects,
-				SizeBytes:           mp.ClusterBytes,
-				SizeHuman:           formatBytes(mp.ClusterBytes),
-				VersionDistribution: mp.ClusterVersionDist,
-				SizeDistribution:    mp.ClusterSizeDist,
-			}
-			summaries = append(summaries, cluster)
-		} else {
-			fmt.Println("No bucket data found")
-			return
-		}
-	}
-
-	// If user requested cluster-level aggregate explicitly and we have cluster data, append it
-	if opts.Cluster && (mp.ClusterObjects > 0 || mp.ClusterBytes > 0 || len(mp.ClusterVersionDist) > 0 || len(mp.ClusterSizeDist) > 0) {
-		found := false
-		for _, b := range summaries {
-			if b.Name == "<cluster-aggregate>" {
-				found = true
-				break
-			}
-		}
-		if !found {
-			cluster := &BucketSummary{
-				Name:                "<cluster-aggregate>",
-				ObjectCount:         mp.ClusterObjects,
-				SizeBytes:           mp.ClusterBytes,
-				SizeHuman:           formatBytes(mp.ClusterBytes),
-				VersionDistribution: mp.ClusterVersionDist,
-				SizeDistribution:    mp.ClusterSizeDist,
-			}
-			summaries = append(summaries, cluster)
-
-			// Re-sort after adding cluster aggregate so it fits into the ordering
-			sort.Slice(summaries, func(i, j int) bool {
-				return summaries[i].SizeBytes > summaries[j].SizeBytes
-			})
-		}
+		fmt.Println(mp.noBucketsMessage(opts))
+		return
 	}
 
 	if n > len(summaries) {
 		n = len(summaries)
 	}
 
-	fmt.Printf("\nTop %d Buckets by Size:\n", n)
+	_, totalObjects, totalBytes := summaryTotals(summaries)
+
+	fmt.Printf("\nTop %d Buckets by %s:\n", n, sortTitle(opts))
 	fmt.Println(strings.Repeat("=", 50))
 
 	for i := 0; i < n; i++ {
@@ -661,6 +1059,7 @@ func (mp *MetricParser) PrintTopBuckets(n int, opts DisplayOptions) {
 		fmt.Printf("%d. %s\n", i+1, bucket.Name)
 		fmt.Printf("   Objects: %d\n", bucket.ObjectCount)
 		fmt.Printf("   Size: %s (%d bytes)\n", bucket.SizeHuman, bucket.SizeBytes)
+		fmt.Printf("   Share: %s of bytes, %s of objects\n", percentOf(bucket.SizeBytes, totalBytes), percentOf(bucket.ObjectCount, totalObjects))
 
 		if opts.ShowVersions {
 			versioningStatus := getVersioningStatus(bucket.VersionDistribution)
@@ -672,8 +1071,8 @@ func (mp *MetricParser) PrintTopBuckets(n int, opts DisplayOptions) {
 		}
 
 		if opts.ShowSizes {
-			sizeStatus := getSizeStatus(bucket.SizeDistribution)
-			sizeDetail := formatSizeDistribution(bucket.SizeDistribution)
+			sizeStatus := getSizeStatus(bucket.SizeDistribution, opts.sizeRanges())
+			sizeDetail := formatSizeDistribution(bucket.SizeDistribution, opts.sizeRanges())
 			fmt.Printf("   Size Distribution: %s\n", sizeStatus)
 			if sizeDetail != "N/A" && sizeDetail != "All zeros" {
 				fmt.Printf("   Size Details: %s\n", sizeDetail)
@@ -684,87 +1083,726 @@ func (mp *MetricParser) PrintTopBuckets(n int, opts DisplayOptions) {
 	}
 }
 
-func main() {
-	if len(os.Args) < 2 || os.Args[1] == "--help" || os.Args[1] == "-h" {
-		fmt.Printf("Usage: %s <prometheus_metrics_file> [options] [top_n]\n", os.Args[0])
-		fmt.Println("Options:")
-		fmt.Println("  --versions    Show version distribution information")
-		fmt.Println("  --sizes       Show size distribution information")
-		fmt.Println("  --both        Show both version and size distribution")
-		fmt.Println("  --help, -h    Show this help message")
-		fmt.Println("Examples:")
-		fmt.Printf("  %s sample.txt\n", os.Args[0])
-		fmt.Printf("  %s sample.txt --versions\n", os.Args[0])
-		fmt.Printf("  %s sample.txt --sizes 10\n", os.Args[0])
-		fmt.Printf("  %s sample.txt --both 5\n", os.Args[0])
-		os.Exit(1)
+// BucketServers is the --by-server breakdown of a bucket: the usage each
+// server reported and its share of the sum over all servers
+type BucketServers struct {
+	Name        string        `json:"name"`
+	ObjectCount int64         `json:"objectCount"`
+	SizeBytes   int64         `json:"sizeBytes"`
+	Servers     []ServerShare `json:"servers"`
+}
+
+// ServerShare is the usage of a bucket reported by one server
+type ServerShare struct {
+	Server         string  `json:"server"`
+	ObjectCount    int64   `json:"objectCount"`
+	SizeBytes      int64   `json:"sizeBytes"`
+	ObjectsPercent float64 `json:"objectsPercent"`
+	BytesPercent   float64 `json:"bytesPercent"`
+}
+
+// serverDisplayName names the server of metrics without a server label
+func serverDisplayName(server string) string {
+	if server == "" {
+		return "-"
+	}
+	return server
+}
+
+// sharePercent returns part as a percentage of total, zero when total is zero
+func sharePercent(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
+
+// BuildServerBreakdown returns the per-server usage of the buckets of
+// GetSummary, in the same order. The servers of a bucket are ordered by the
+// bytes they reported, largest first, then by name.
+func (mp *MetricParser) BuildServerBreakdown(opts DisplayOptions) []BucketServers {
+	summaries := mp.GetSummary(opts)
+	breakdown := make([]BucketServers, 0, len(summaries))
+	for _, bucket := range summaries {
+		var objects, bytes int64
+		for _, usage := range bucket.ServerUsage {
+			objects += usage.ObjectCount
+			bytes += usage.SizeBytes
+		}
+
+		servers := make([]ServerShare, 0, len(bucket.ServerUsage))
+		for server, usage := range bucket.ServerUsage {
+			servers = append(servers, ServerShare{
+				Server:         serverDisplayName(server),
+				ObjectCount:    usage.ObjectCount,
+				SizeBytes:      usage.SizeBytes,
+				ObjectsPercent: sharePercent(usage.ObjectCount, objects),
+				BytesPercent:   sharePercent(usage.SizeBytes, bytes),
+			})
+		}
+		sort.Slice(servers, func(i, j int) bool {
+			if servers[i].SizeBytes != servers[j].SizeBytes {
+				return servers[i].SizeBytes > servers[j].SizeBytes
+			}
+			return servers[i].Server < servers[j].Server
+		})
+
+		breakdown = append(breakdown, BucketServers{
+			Name:        bucket.Name,
+			ObjectCount: bucket.ObjectCount,
+			SizeBytes:   bucket.SizeBytes,
+			Servers:     servers,
+		})
+	}
+	return breakdown
+}
+
+// PrintServerBreakdown prints the usage every server reported for each bucket
+// with its share of the bucket's objects and bytes over all servers
+func (mp *MetricParser) PrintServerBreakdown(opts DisplayOptions) {
+	breakdown := mp.BuildServerBreakdown(opts)
+	if len(breakdown) == 0 {
+		fmt.Println(mp.noBucketsMessage(opts))
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 8, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "BUCKET NAME\tSERVER\tOBJECT COUNT\tSIZE (BYTES)\tSIZE (HUMAN)\t% BYTES\t% OBJECTS")
+	fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------\t--------\t--------")
+	for _, bucket := range breakdown {
+		// Truncate bucket name if too long
+		bucketName := bucket.Name
+		if len(bucketName) > 40 {
+			bucketName = bucketName[:37] + "..."
+		}
+
+		// The bucket name only goes on its first server row
+		for i, server := range bucket.Servers {
+			if i > 0 {
+				bucketName = ""
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%.1f%%\t%.1f%%\n",
+				bucketName,
+				server.Server,
+				server.ObjectCount,
+				server.SizeBytes,
+				formatBytes(server.SizeBytes),
+				server.BytesPercent,
+				server.ObjectsPercent)
+		}
+	}
+	w.Flush()
+}
+
+// Change of a bucket between two snapshots
+const (
+	bucketNew     = "new"
+	bucketDeleted = "deleted"
+	bucketChanged = "changed"
+)
+
+// BucketDelta is the change of a bucket between an old and a new snapshot
+type BucketDelta struct {
+	Name        string `json:"name"`
+	Change      string `json:"change"` // new, deleted or changed
+	OldObjects  int64  `json:"oldObjects"`
+	NewObjects  int64  `json:"newObjects"`
+	ObjectDelta int64  `json:"objectDelta"`
+	OldBytes    int64  `json:"oldBytes"`
+	NewBytes    int64  `json:"newBytes"`
+	ByteDelta   int64  `json:"byteDelta"`
+	// BytesPerDay is the growth rate, only set when the interval is known
+	BytesPerDay *int64 `json:"bytesPerDay,omitempty"`
+}
+
+// SnapshotDiff is the change of the buckets between two snapshots, printed
+// with --diff
+type SnapshotDiff struct {
+	// IntervalSeconds is the time between the snapshots, 0 when unknown
+	IntervalSeconds float64 `json:"intervalSeconds,omitempty"`
+	// IntervalSource tells where the snapshot times come from
+	IntervalSource string        `json:"intervalSource,omitempty"`
+	Buckets        []BucketDelta `json:"buckets"`
+	Unchanged      int           `json:"unchanged"`
+	ObjectDelta    int64         `json:"objectDelta"`
+	ByteDelta      int64         `json:"byteDelta"`
+}
+
+// SnapshotTime is when a snapshot was taken and where that time comes from
+type SnapshotTime struct {
+	Time   time.Time
+	Source string
+}
+
+// DiffSnapshots compares the buckets matching opts.Filter of an old and a new
+// snapshot. Buckets that didn't change are only counted. The buckets are
+// sorted by the largest size growth first, then by object growth and name.
+func DiffSnapshots(old, cur *MetricParser, oldTime, curTime SnapshotTime, opts DisplayOptions) SnapshotDiff {
+	diff := SnapshotDiff{Buckets: make([]BucketDelta, 0)}
+	interval := curTime.Time.Sub(oldTime.Time)
+	if !oldTime.Time.IsZero() && !curTime.Time.IsZero() && interval > 0 {
+		diff.IntervalSeconds = interval.Seconds()
+		diff.IntervalSource = oldTime.Source
+		if curTime.Source != oldTime.Source {
+			diff.IntervalSource = oldTime.Source + " / " + curTime.Source
+		}
+	}
+
+	names := make(map[string]bool)
+	for name := range old.buckets {
+		names[name] = true
+	}
+	for name := range cur.buckets {
+		names[name] = true
+	}
+
+	for name := range names {
+		if opts.Filter != nil && !opts.Filter.MatchString(name) {
+			continue
+		}
+		delta := BucketDelta{Name: name, Change: bucketChanged}
+		oldBucket, inOld := old.buckets[name]
+		curBucket, inCur := cur.buckets[name]
+		if inOld {
+			delta.OldObjects, delta.OldBytes = oldBucket.ObjectCount, oldBucket.SizeBytes
+		} else {
+			delta.Change = bucketNew
+		}
+		if inCur {
+			delta.NewObjects, delta.NewBytes = curBucket.ObjectCount, curBucket.SizeBytes
+		} else {
+			delta.Change = bucketDeleted
+		}
+		delta.ObjectDelta = delta.NewObjects - delta.OldObjects
+		delta.ByteDelta = delta.NewBytes - delta.OldBytes
+
+		if delta.Change == bucketChanged && delta.ObjectDelta == 0 && delta.ByteDelta == 0 {
+			diff.Unchanged++
+			continue
+		}
+		if diff.IntervalSeconds > 0 {
+			perDay := int64(float64(delta.ByteDelta) / diff.IntervalSeconds * (24 * time.Hour).Seconds())
+			delta.BytesPerDay = &perDay
+		}
+		diff.Buckets = append(diff.Buckets, delta)
+		diff.ObjectDelta += delta.ObjectDelta
+		diff.ByteDelta += delta.ByteDelta
+	}
+
+	sort.Slice(diff.Buckets, func(i, j int) bool {
+		a, b := diff.Buckets[i], diff.Buckets[j]
+		if a.ByteDelta != b.ByteDelta {
+			return a.ByteDelta > b.ByteDelta
+		}
+		if a.ObjectDelta != b.ObjectDelta {
+			return a.ObjectDelta > b.ObjectDelta
+		}
+		return a.Name < b.Name
+	})
+	return diff
+}
+
+// formatBytesDelta formats a signed size difference, e.g. +1.5 GB or -512 B
+func formatBytesDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + formatBytes(-delta)
+	}
+	return "+" + formatBytes(delta)
+}
+
+// bucketNames lists the names of the deltas with the given change
+func bucketNames(deltas []BucketDelta, change string) []string {
+	var names []string
+	for _, delta := range deltas {
+		if delta.Change == change {
+			names = append(names, delta.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PrintDiffTable prints the changes of the buckets since the old snapshot, with
+// the growth per day when the interval between the snapshots is known
+func PrintDiffTable(diff SnapshotDiff, oldInput string) {
+	if diff.IntervalSeconds > 0 {
+		interval := time.Duration(diff.IntervalSeconds * float64(time.Second)).Round(time.Second)
+		fmt.Printf("\nBucket Growth since %s (%s ago, from %s):\n", oldInput, interval, diff.IntervalSource)
+	} else {
+		fmt.Printf("\nBucket Growth since %s (interval unknown, no growth rate):\n", oldInput)
+	}
+	fmt.Println(strings.Repeat("=", 60))
+
+	if len(diff.Buckets) == 0 {
+		fmt.Printf("No bucket changed (%d unchanged)\n", diff.Unchanged)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 8, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "BUCKET NAME\tCHANGE\tOBJECTS\tOBJECTS DELTA\tSIZE (HUMAN)\tSIZE DELTA (BYTES)\tSIZE DELTA (HUMAN)\tGROWTH/DAY")
+	fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------\t--------\t--------\t--------")
+	for _, delta := range diff.Buckets {
+		bucketName := delta.Name
+		if len(bucketName) > 40 {
+			bucketName = bucketName[:37] + "..."
+		}
+		perDay := "-"
+		if delta.BytesPerDay != nil {
+			perDay = formatBytesDelta(*delta.BytesPerDay)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%+d\t%s\t%+d\t%s\t%s\n",
+			bucketName,
+			strings.ToUpper(delta.Change),
+			delta.NewObjects,
+			delta.ObjectDelta,
+			formatBytes(delta.NewBytes),
+			delta.ByteDelta,
+			formatBytesDelta(delta.ByteDelta),
+			perDay)
+	}
+	fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------\t--------\t--------\t--------")
+	fmt.Fprintf(w, "TOTAL (%d buckets changed)\t\t\t%+d\t\t%+d\t%s\t\n",
+		len(diff.Buckets),
+		diff.ObjectDelta,
+		diff.ByteDelta,
+		formatBytesDelta(diff.ByteDelta))
+	w.Flush()
+
+	fmt.Println()
+	if names := bucketNames(diff.Buckets, bucketNew); len(names) > 0 {
+		fmt.Printf("New buckets (%d): %s\n", len(names), strings.Join(names, ", "))
+	}
+	if names := bucketNames(diff.Buckets, bucketDeleted); len(names) > 0 {
+		fmt.Printf("Deleted buckets (%d): %s\n", len(names), strings.Join(names, ", "))
+	}
+	fmt.Printf("Unchanged buckets: %d\n", diff.Unchanged)
+}
+
+// inputTime returns when the metrics of input were taken: the latest sample
+// timestamp, else the scrape time of a metrics URL or the modification time
+// of a file. It is zero for stdin without timestamps.
+func inputTime(mp *MetricParser, input string, scraped time.Time) SnapshotTime {
+	if !mp.Timestamp.IsZero() {
+		return SnapshotTime{Time: mp.Timestamp, Source: "metric timestamps"}
 	}
+	if isMetricsURL(input) {
+		return SnapshotTime{Time: scraped, Source: "scrape time"}
+	}
+	if input == stdinInput {
+		return SnapshotTime{}
+	}
+	info, err := os.Stat(input)
+	if err != nil {
+		return SnapshotTime{}
+	}
+	return SnapshotTime{Time: info.ModTime(), Source: "file modification time"}
+}
+
+// Environment variables consulted when scraping a live metrics endpoint
+const (
+	envMetricsToken = "MINIO_METRICS_TOKEN"
+	envAccessKey    = "MINIO_ACCESS_KEY"
+	envSecretKey    = "MINIO_SECRET_KEY"
+)
+
+// metricsTokenExpiry is the lifetime of tokens generated from access/secret keys.
+// A token is generated per run, so it only needs to outlive a single scrape.
+const metricsTokenExpiry = 1 * time.Hour
+
+// generateMetricsToken creates the HS512 signed JWT the MinIO metrics endpoint
+// expects, the same token `mc admin prometheus generate` produces.
+func generateMetricsToken(accessKey, secretKey string, expiry time.Duration) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "HS512", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"exp": time.Now().Add(expiry).Unix(),
+		"sub": accessKey,
+		"iss": "prometheus",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha512.New, []byte(secretKey))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
 
-	var filename string
-	var topN = 5 // default
-	var opts DisplayOptions
+// resolveBearerToken picks the token used to scrape the metrics endpoint.
+// Flags come before the environment: --bearer-token, then a token generated
+// from --access-key/--secret-key (a missing one taken from its environment
+// variable), then $MINIO_METRICS_TOKEN, then a token generated from
+// $MINIO_ACCESS_KEY and $MINIO_SECRET_KEY. An empty token means the endpoint
+// is scraped without authentication.
+func resolveBearerToken(flagToken, accessKey, secretKey string) (string, error) {
+	if flagToken != "" {
+		return flagToken, nil
+	}
+	if accessKey == "" && secretKey == "" {
+		if token := os.Getenv(envMetricsToken); token != "" {
+			return token, nil
+		}
+	}
 
-	// Parse command line arguments (flags may appear before or after filename)
-	args := os.Args[1:]
-	for _, arg := range args {
+	if accessKey == "" {
+		accessKey = os.Getenv(envAccessKey)
+	}
+	if secretKey == "" {
+		secretKey = os.Getenv(envSecretKey)
+	}
+	if accessKey == "" && secretKey == "" {
+		return "", nil
+	}
+	if accessKey == "" || secretKey == "" {
+		return "", fmt.Errorf("both access key and secret key are required to generate a metrics token")
+	}
+	return generateMetricsToken(accessKey, secretKey, metricsTokenExpiry)
+}
+
+// isMetricsURL reports whether the input refers to a live metrics endpoint
+func isMetricsURL(input string) bool {
+	return strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://")
+}
+
+// printUsage prints the command line help
+func printUsage() {
+	fmt.Printf("Usage: %s <prometheus_metrics_file|metrics_url|-> [options] [top_n]\n", os.Args[0])
+	fmt.Println("Use - to read the metrics from stdin.")
+	fmt.Println("Options:")
+	fmt.Println("  --versions              Show version distribution information")
+	fmt.Println("  --sizes                 Show size distribution information")
+	fmt.Println("  --cluster               Force include cluster-level aggregates")
+	fmt.Println("  --both                  Show both version and size distribution")
+	fmt.Println("  --ranges <file>         Map the size distribution ranges to labels and categories (JSON)")
+	fmt.Println("  --sort <key>            Sort the buckets by size (default), objects or name")
+	fmt.Println("  --reverse, --asc        Reverse the sort order, e.g. smallest buckets first")
+	fmt.Println("  --filter <regex>        Show only the buckets whose name matches the regex")
+	fmt.Println("  --min-objects <n>       Show only the buckets with at least n objects")
+	fmt.Println("  --min-size <size>       Show only the buckets of at least size, e.g. 500MiB or 1GiB")
+	fmt.Println("  --json                  Print the bucket summaries and totals as JSON")
+	fmt.Println("  --csv <file>            Also write one CSV row per bucket to file, with a totals row")
+	fmt.Println("  --no-csv-totals         Leave the totals row out of the --csv file")
+	fmt.Println("  --diff <oldfile>        Show the per-bucket growth since the older metrics file instead")
+	fmt.Println("  --by-server             Show the objects and bytes each server reported per bucket instead")
+	fmt.Println("  --bearer-token <token>  Bearer token used when scraping a metrics URL")
+	fmt.Println("  --access-key <key>      Access key used to generate a metrics token")
+	fmt.Println("  --secret-key <key>      Secret key used to generate a metrics token")
+	fmt.Println("  --help, -h              Show this help message")
+	fmt.Println("Environment (metrics URL only):")
+	fmt.Printf("  %-22s  Bearer token, used when none of --bearer-token, --access-key and --secret-key is set\n", envMetricsToken)
+	fmt.Printf("  %-22s  Access key, used when --access-key is not set\n", envAccessKey)
+	fmt.Printf("  %-22s  Secret key, used when --secret-key is not set\n", envSecretKey)
+	fmt.Println("Examples:")
+	fmt.Printf("  %s sample.txt\n", os.Args[0])
+	fmt.Printf("  %s sample.txt --versions\n", os.Args[0])
+	fmt.Printf("  %s sample.txt --sizes 10\n", os.Args[0])
+	fmt.Printf("  %s sample.txt --both 5\n", os.Args[0])
+	fmt.Printf("  %s sample.txt --sort objects 10\n", os.Args[0])
+	fmt.Printf("  %s sample.txt --asc 20\n", os.Args[0])
+	fmt.Printf("  %s sample.txt --filter '-prod$'\n", os.Args[0])
+	fmt.Printf("  %s sample.txt --min-size 1GiB --min-objects 1000\n", os.Args[0])
+	fmt.Printf("  %s sample.txt --json | jq '.totals'\n", os.Args[0])
+	fmt.Printf("  %s sample.txt --csv buckets.csv\n", os.Args[0])
+	fmt.Printf("  %s today.txt --diff yesterday.txt\n", os.Args[0])
+	fmt.Printf("  %s sample.txt --by-server --filter '-prod$'\n", os.Args[0])
+	fmt.Printf("  %s=<token> %s http://localhost:9000/minio/v2/metrics/bucket\n", envMetricsToken, os.Args[0])
+	fmt.Printf("  curl -s http://localhost:9000/minio/v2/metrics/bucket | %s - --both\n", os.Args[0])
+}
+
+// cliArgs holds the parsed command line arguments
+type cliArgs struct {
+	Input       string
+	TopN        int
+	TopNGiven   bool // top_n was given rather than defaulted
+	SortGiven   bool // --sort was given rather than defaulted
+	Display     DisplayOptions
+	JSON        bool
+	CSVFile     string
+	NoCSVTotals bool
+	DiffFile    string
+	ByServer    bool
+	BearerToken string
+	AccessKey   string
+	SecretKey   string
+	Help        bool
+}
+
+// parseArgs parses the command line arguments (flags may appear before or after
+// the input) and rejects contradictory combinations up front
+func parseArgs(args []string) (*cliArgs, error) {
+	parsed := &cliArgs{TopN: 5, Display: DisplayOptions{SortBy: sortBySize}}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
 		switch arg {
 		case "--versions":
-			opts.ShowVersions = true
+			parsed.Display.ShowVersions = true
 		case "--sizes":
-			opts.ShowSizes = true
+			parsed.Display.ShowSizes = true
 		case "--cluster":
-			opts.Cluster = true
+			parsed.Display.Cluster = true
 		case "--both":
-			opts.ShowVersions = true
-			opts.ShowSizes = true
+			parsed.Display.ShowVersions = true
+			parsed.Display.ShowSizes = true
+		case "--reverse", "--asc":
+			parsed.Display.Reverse = true
+		case "--json":
+			parsed.JSON = true
+		case "--no-csv-totals":
+			parsed.NoCSVTotals = true
+		case "--by-server":
+			parsed.ByServer = true
+		case "--sort", "--filter", "--min-objects", "--min-size", "--ranges", "--csv", "--diff", "--bearer-token", "--access-key", "--secret-key":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", arg)
+			}
+			i++
+			switch arg {
+			case "--sort":
+				parsed.Display.SortBy = args[i]
+				parsed.SortGiven = true
+			case "--filter":
+				filter, err := regexp.Compile(args[i])
+				if err != nil {
+					return nil, fmt.Errorf("invalid --filter regex %q: %w", args[i], err)
+				}
+				parsed.Display.Filter = filter
+			case "--min-objects":
+				n, err := strconv.ParseInt(args[i], 10, 64)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid --min-objects %q, expected a non-negative number", args[i])
+				}
+				parsed.Display.MinObjects = n
+			case "--min-size":
+				size, err := parseSize(args[i])
+				if err != nil {
+					return nil, fmt.Errorf("invalid --min-size: %w", err)
+				}
+				parsed.Display.MinSize = size
+			case "--ranges":
+				ranges, err := loadSizeRanges(args[i])
+				if err != nil {
+					return nil, err
+				}
+				parsed.Display.SizeRanges = ranges
+			case "--csv":
+				parsed.CSVFile = args[i]
+			case "--diff":
+				parsed.DiffFile = args[i]
+			case "--bearer-token":
+				parsed.BearerToken = args[i]
+			case "--access-key":
+				parsed.AccessKey = args[i]
+			case "--secret-key":
+				parsed.SecretKey = args[i]
+			}
 		case "--help", "-h":
-			fmt.Printf("Usage: %s <prometheus_metrics_file> [options] [top_n]\n", os.Args[0])
-			fmt.Println("Options:")
-			fmt.Println("  --versions    Show version distribution information")
-			fmt.Println("  --sizes       Show size distribution information")
-			fmt.Println("  --cluster     Force include cluster-level aggregates")
-			fmt.Println("  --both        Show both version and size distribution")
-			fmt.Println("  --help, -h    Show this help message")
-			fmt.Println("Examples:")
-			fmt.Printf("  %s sample.txt\n", os.Args[0])
-			fmt.Printf("  %s sample.txt --versions\n", os.Args[0])
-			fmt.Printf("  %s sample.txt --sizes 10\n", os.Args[0])
-			fmt.Printf("  %s sample.txt --both 5\n", os.Args[0])
-			os.Exit(0)
+			parsed.Help = true
+			return parsed, nil
 		default:
+			if strings.HasPrefix(arg, "--") {
+				return nil, fmt.Errorf("unknown option %s", arg)
+			}
 			// Non-flag; could be filename or topN
 			if n, err := strconv.Atoi(arg); err == nil {
-				topN = n
+				parsed.TopN = n
+				parsed.TopNGiven = true
 				continue
 			}
-			if filename == "" {
-				filename = arg
+			if parsed.Input != "" {
+				return nil, fmt.Errorf("only one input is supported, got %q and %q", parsed.Input, arg)
+			}
+			parsed.Input = arg
+		}
+	}
+
+	if err := parsed.validate(); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// validate rejects option combinations that contradict each other
+func (a *cliArgs) validate() error {
+	if a.Input == "" {
+		return fmt.Errorf("no prometheus metrics file, metrics URL or - for stdin given")
+	}
+	if a.TopN < 1 {
+		return fmt.Errorf("top_n must be at least 1, got %d", a.TopN)
+	}
+
+	switch a.Display.SortBy {
+	case sortBySize, sortByObjects, sortByName:
+	default:
+		return fmt.Errorf("--sort must be %s, %s or %s, got %q", sortBySize, sortByObjects, sortByName, a.Display.SortBy)
+	}
+	if a.NoCSVTotals && a.CSVFile == "" {
+		return fmt.Errorf("--no-csv-totals only applies to --csv")
+	}
+
+	if a.DiffFile != "" {
+		if a.DiffFile == stdinInput && a.Input == stdinInput {
+			return fmt.Errorf("only one of the input and --diff can be read from stdin")
+		}
+		// The growth rows have their own order and are all listed
+		for name, set := range map[string]bool{
+			"--csv":           a.CSVFile != "",
+			"--min-objects":   a.Display.MinObjects > 0,
+			"--min-size":      a.Display.MinSize > 0,
+			"--sort":          a.SortGiven,
+			"--reverse/--asc": a.Display.Reverse,
+			"top_n":           a.TopNGiven,
+		} {
+			if err := exclusiveOptions(map[string]bool{"--diff": true, name: set}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if a.ByServer {
+		for name, set := range map[string]bool{
+			"--csv":     a.CSVFile != "",
+			"--diff":    a.DiffFile != "",
+			"--cluster": a.Display.Cluster,
+			"top_n":     a.TopNGiven,
+		} {
+			if err := exclusiveOptions(map[string]bool{"--by-server": true, name: set}); err != nil {
+				return err
 			}
 		}
 	}
 
-	if filename == "" {
-		fmt.Printf("Usage: %s <prometheus_metrics_file> [options] [top_n]\n", os.Args[0])
+	tokenFromKeys := a.AccessKey != "" || a.SecretKey != ""
+	if err := exclusiveOptions(map[string]bool{
+		"--bearer-token":            a.BearerToken != "",
+		"--access-key/--secret-key": tokenFromKeys,
+	}); err != nil {
+		return err
+	}
+	if !isMetricsURL(a.Input) && (a.BearerToken != "" || tokenFromKeys) {
+		return fmt.Errorf("--bearer-token, --access-key and --secret-key only apply to a metrics URL, not %q", a.Input)
+	}
+	return nil
+}
+
+// exclusiveOptions returns an error listing the options when more than one of
+// the given mutually exclusive options is set
+func exclusiveOptions(options map[string]bool) error {
+	var set []string
+	for name, enabled := range options {
+		if enabled {
+			set = append(set, name)
+		}
+	}
+	if len(set) > 1 {
+		sort.Strings(set)
+		return fmt.Errorf("options %s cannot be used together", strings.Join(set, ", "))
+	}
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
 		os.Exit(1)
 	}
 
+	args, err := parseArgs(os.Args[1:])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		fmt.Printf("Run '%s --help' for usage\n", os.Args[0])
+		os.Exit(1)
+	}
+	if args.Help {
+		printUsage()
+		os.Exit(0)
+	}
+
 	// Default: show basic columns only (no versions/sizes unless explicitly requested)
 	// No default options needed - both ShowVersions and ShowSizes default to false
 
 	parser := NewMetricParser()
 
-	fmt.Printf("Parsing MinIO metrics from: %s\n", filename)
-	fmt.Println(strings.Repeat("=", 60))
+	// Keep stdout pure JSON with --json
+	if !args.JSON {
+		source := args.Input
+		if source == stdinInput {
+			source = "stdin"
+		}
+		fmt.Printf("Parsing MinIO metrics from: %s\n", source)
+		fmt.Println(strings.Repeat("=", 60))
+	}
 
-	if err := parser.ParseFile(filename); err != nil {
+	if isMetricsURL(args.Input) {
+		token, err := resolveBearerToken(args.BearerToken, args.AccessKey, args.SecretKey)
+		if err != nil {
+			log.Fatalf("Error resolving bearer token: %v", err)
+		}
+		if err := parser.ScrapeURL(args.Input, token); err != nil {
+			log.Fatalf("Error scraping metrics: %v", err)
+		}
+	} else if err := parser.ParseFile(args.Input); err != nil {
 		log.Fatalf("Error parsing file: %v", err)
 	}
 
+	if args.DiffFile != "" {
+		scraped := time.Now()
+		oldParser := NewMetricParser()
+		if err := oldParser.ParseFile(args.DiffFile); err != nil {
+			log.Fatalf("Error parsing file: %v", err)
+		}
+		diff := DiffSnapshots(oldParser, parser,
+			inputTime(oldParser, args.DiffFile, scraped),
+			inputTime(parser, args.Input, scraped),
+			args.Display)
+		if args.JSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(diff); err != nil {
+				log.Fatalf("Error writing JSON: %v", err)
+			}
+			return
+		}
+		PrintDiffTable(diff, args.DiffFile)
+		return
+	}
+
+	if args.ByServer {
+		if args.JSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(parser.BuildServerBreakdown(args.Display)); err != nil {
+				log.Fatalf("Error writing JSON: %v", err)
+			}
+			return
+		}
+		fmt.Println("\nBucket Usage by Server:")
+		fmt.Println(strings.Repeat("=", 60))
+		parser.PrintServerBreakdown(args.Display)
+		return
+	}
+
+	if args.CSVFile != "" {
+		if err := parser.writeSummaryCSVFile(args.CSVFile, args.Display, !args.NoCSVTotals); err != nil {
+			log.Fatalf("Error writing CSV: %v", err)
+		}
+	}
+
+	if args.JSON {
+		if err := parser.WriteSummaryJSON(os.Stdout, args.Display); err != nil {
+			log.Fatalf("Error writing JSON: %v", err)
+		}
+		return
+	}
+
 	// Print complete summary table
 	fmt.Println("\nBucket Summary Table:")
 	fmt.Println(strings.Repeat("=", 60))
-	parser.PrintSummaryTable(opts)
+	parser.PrintSummaryTable(args.Display)
 
 	// Print top buckets
-	parser.PrintTopBuckets(topN, opts)
+	parser.PrintTopBuckets(args.TopN, args.Display)
 }
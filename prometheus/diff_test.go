@@ -0,0 +1,98 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+const (
+	oldSnapshot = `minio_bucket_usage_object_total{bucket="grows",server="s1"} 100
+minio_bucket_usage_total_bytes{bucket="grows",server="s1"} 1024
+minio_bucket_usage_object_total{bucket="same",server="s1"} 5
+minio_bucket_usage_total_bytes{bucket="same",server="s1"} 512
+minio_bucket_usage_object_total{bucket="gone",server="s1"} 7
+minio_bucket_usage_total_bytes{bucket="gone",server="s1"} 2048
+`
+	newSnapshot = `minio_bucket_usage_object_total{bucket="grows",server="s1"} 300
+minio_bucket_usage_total_bytes{bucket="grows",server="s1"} 9216
+minio_bucket_usage_object_total{bucket="same",server="s1"} 5
+minio_bucket_usage_total_bytes{bucket="same",server="s1"} 512
+minio_bucket_usage_object_total{bucket="added",server="s1"} 1
+minio_bucket_usage_total_bytes{bucket="added",server="s1"} 100
+`
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	old, cur := parseMetrics(t, oldSnapshot), parseMetrics(t, newSnapshot)
+	oldTime := SnapshotTime{Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Source: "file modification time"}
+	curTime := SnapshotTime{Time: oldTime.Time.Add(48 * time.Hour), Source: "file modification time"}
+
+	diff := DiffSnapshots(old, cur, oldTime, curTime, DisplayOptions{})
+	if diff.IntervalSeconds != (48*time.Hour).Seconds() || diff.IntervalSource != "file modification time" {
+		t.Fatalf("unexpected interval %v from %q", diff.IntervalSeconds, diff.IntervalSource)
+	}
+	if diff.Unchanged != 1 || len(diff.Buckets) != 3 {
+		t.Fatalf("expected 3 changed buckets and 1 unchanged, got %+v", diff)
+	}
+
+	want := []struct {
+		name, change string
+		objectDelta  int64
+		byteDelta    int64
+		perDay       int64
+	}{
+		{name: "grows", change: bucketChanged, objectDelta: 200, byteDelta: 8192, perDay: 4096},
+		{name: "added", change: bucketNew, objectDelta: 1, byteDelta: 100, perDay: 50},
+		{name: "gone", change: bucketDeleted, objectDelta: -7, byteDelta: -2048, perDay: -1024},
+	}
+	for i, w := range want {
+		got := diff.Buckets[i]
+		if got.Name != w.name || got.Change != w.change || got.ObjectDelta != w.objectDelta || got.ByteDelta != w.byteDelta {
+			t.Fatalf("bucket %d: expected %+v, got %+v", i, w, got)
+		}
+		if got.BytesPerDay == nil || *got.BytesPerDay != w.perDay {
+			t.Fatalf("bucket %s: expected %d bytes per day, got %v", w.name, w.perDay, got.BytesPerDay)
+		}
+	}
+	if diff.ObjectDelta != 194 || diff.ByteDelta != 6244 {
+		t.Fatalf("unexpected total deltas %d objects, %d bytes", diff.ObjectDelta, diff.ByteDelta)
+	}
+	if names := bucketNames(diff.Buckets, bucketNew); len(names) != 1 || names[0] != "added" {
+		t.Fatalf("expected added to be the new bucket, got %v", names)
+	}
+}
+
+func TestDiffSnapshotsUnknownInterval(t *testing.T) {
+	old, cur := parseMetrics(t, oldSnapshot), parseMetrics(t, newSnapshot)
+
+	diff := DiffSnapshots(old, cur, SnapshotTime{}, SnapshotTime{}, DisplayOptions{Filter: regexp.MustCompile("^g")})
+	if diff.IntervalSeconds != 0 || diff.IntervalSource != "" {
+		t.Fatalf("expected no interval, got %v from %q", diff.IntervalSeconds, diff.IntervalSource)
+	}
+	if len(diff.Buckets) != 2 || diff.Buckets[0].Name != "grows" || diff.Buckets[1].Name != "gone" {
+		t.Fatalf("expected only the filtered buckets grows and gone, got %+v", diff.Buckets)
+	}
+	if diff.Buckets[0].BytesPerDay != nil {
+		t.Fatalf("expected no growth rate without interval")
+	}
+}
+
+func TestSampleTimestamp(t *testing.T) {
+	mp := parseMetrics(t, `minio_bucket_usage_object_total{bucket="b1",server="s1"} 42 1760000000000
+minio_bucket_usage_total_bytes{bucket="b1",server="s1"} 1.5e+03 1760000060000
+minio_cluster_usage_object_total 7
+`)
+	if mp.buckets["b1"].ObjectCount != 42 || mp.buckets["b1"].SizeBytes != 1500 {
+		t.Fatalf("expected the value, not the timestamp, got %+v", mp.buckets["b1"])
+	}
+	if mp.ClusterObjects != 7 {
+		t.Fatalf("expected 7 cluster objects from an unlabeled sample, got %d", mp.ClusterObjects)
+	}
+	if !mp.Timestamp.Equal(time.UnixMilli(1760000060000)) {
+		t.Fatalf("expected the latest sample timestamp, got %v", mp.Timestamp)
+	}
+	if formatBytesDelta(-2048) != "-2.0 KB" || formatBytesDelta(100) != "+100 B" {
+		t.Fatalf("unexpected signed sizes %q, %q", formatBytesDelta(-2048), formatBytesDelta(100))
+	}
+}
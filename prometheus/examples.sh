@@ -20,7 +20,15 @@ echo "4. Include cluster-level aggregates (force cluster summary):"
 echo "   ./bucket_summary sample.txt --cluster"
 echo
 
-echo "4. Using make commands:"
+echo "5. Bucket summaries and totals as JSON:"
+echo "   ./bucket_summary sample.txt --json | jq '.totals'"
+echo
+
+echo "6. Bucket summaries as CSV for a spreadsheet:"
+echo "   ./bucket_summary sample.txt --csv buckets.csv"
+echo
+
+echo "7. Using make commands:"
 echo "   make build      # Build the tool"
 echo "   make run        # Run with sample data"
 echo "   make test       # Run with test data"
@@ -36,7 +44,7 @@ echo
 
 echo "The tool will:"
 echo "• Parse MinIO Prometheus metrics"
-echo "• Aggregate data across multiple servers"
+echo "• Combine the reports of multiple servers without double-counting"
 echo "• Track object versioning distribution"
 echo "• Sort buckets by size (largest first)"
 echo "• Show human-readable sizes"
@@ -62,3 +62,26 @@ minio_cluster_objects_version_distribution{range="SINGLE_VERSION",server="s1"} 3
 		t.Fatalf("expected SINGLE_VERSION in ClusterVersionDist")
 	}
 }
+
+func TestParseFileStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("unable to create pipe: %v", err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	go func() {
+		w.WriteString(`minio_bucket_usage_object_total{bucket="b1",server="s1"} 42` + "\n")
+		w.Close()
+	}()
+
+	mp := NewMetricParser()
+	if err := mp.ParseFile("-"); err != nil {
+		t.Fatalf("ParseFile returned error: %v", err)
+	}
+	if mp.buckets["b1"] == nil || mp.buckets["b1"].ObjectCount != 42 {
+		t.Fatalf("expected bucket b1 with 42 objects from stdin, got %+v", mp.buckets["b1"])
+	}
+}
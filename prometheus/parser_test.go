@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseReaderDoesNotSumAcrossServers(t *testing.T) {
+	var content strings.Builder
+	for _, server := range []string{"node1:9000", "node2:9000", "node3:9000"} {
+		content.WriteString(`minio_bucket_usage_object_total{bucket="b1",server="` + server + `"} 1000` + "\n")
+		content.WriteString(`minio_bucket_usage_total_bytes{bucket="b1",server="` + server + `"} 1.073741824e+09` + "\n")
+		content.WriteString(`minio_bucket_objects_version_distribution{bucket="b1",range="SINGLE_VERSION",server="` + server + `"} 1000` + "\n")
+		content.WriteString(`minio_bucket_objects_size_distribution{bucket="b1",range="LESS_THAN_1024_B",server="` + server + `"} 1000` + "\n")
+		content.WriteString(`minio_cluster_usage_object_total{server="` + server + `"} 1000` + "\n")
+	}
+
+	mp := NewMetricParser()
+	if err := mp.ParseReader(strings.NewReader(content.String())); err != nil {
+		t.Fatalf("ParseReader returned error: %v", err)
+	}
+
+	bucket := mp.buckets["b1"]
+	if bucket == nil {
+		t.Fatalf("expected bucket b1")
+	}
+	if bucket.ObjectCount != 1000 {
+		t.Fatalf("expected 1000 objects, not tripled, got %d", bucket.ObjectCount)
+	}
+	if bucket.SizeBytes != 1073741824 || bucket.SizeHuman != "1.0 GB" {
+		t.Fatalf("expected 1.0 GB, not tripled, got %d (%s)", bucket.SizeBytes, bucket.SizeHuman)
+	}
+	if bucket.VersionDistribution["SINGLE_VERSION"] != 1000 || bucket.SizeDistribution["LESS_THAN_1024_B"] != 1000 {
+		t.Fatalf("expected distributions not tripled, got %v and %v", bucket.VersionDistribution, bucket.SizeDistribution)
+	}
+	if len(bucket.Servers) != 3 {
+		t.Fatalf("expected the three reporting servers, got %v", bucket.Servers)
+	}
+	if mp.ClusterObjects != 1000 {
+		t.Fatalf("expected 1000 cluster objects, not tripled, got %d", mp.ClusterObjects)
+	}
+}
+
+func TestParseReaderKeepsLargestServerValue(t *testing.T) {
+	// A server with a stale usage scan reports less than the others
+	mp := NewMetricParser()
+	err := mp.ParseReader(strings.NewReader(`minio_bucket_usage_object_total{bucket="b1",server="node1:9000"} 900
+minio_bucket_usage_object_total{bucket="b1",server="node2:9000"} 1000
+minio_bucket_usage_object_total{bucket="b2",server="node1:9000"} 5
+`))
+	if err != nil {
+		t.Fatalf("ParseReader returned error: %v", err)
+	}
+	if mp.buckets["b1"].ObjectCount != 1000 || mp.buckets["b2"].ObjectCount != 5 {
+		t.Fatalf("expected 1000 and 5 objects, got %d and %d", mp.buckets["b1"].ObjectCount, mp.buckets["b2"].ObjectCount)
+	}
+}
+
+func gzipData(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(content)); err != nil {
+		t.Fatalf("unable to gzip: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("unable to gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseGzip(t *testing.T) {
+	content := `minio_bucket_usage_object_total{bucket="b1",server="s1"} 42` + "\n"
+	dir := t.TempDir()
+	compressed := filepath.Join(dir, "metrics.txt.gz")
+	detected := filepath.Join(dir, "metrics.bin")
+	for _, name := range []string{compressed, detected} {
+		if err := os.WriteFile(name, gzipData(t, content), 0o644); err != nil {
+			t.Fatalf("unable to write %s: %v", name, err)
+		}
+	}
+
+	parsers := map[string]func(mp *MetricParser) error{
+		"reader":          func(mp *MetricParser) error { return mp.ParseReader(bytes.NewReader(gzipData(t, content))) },
+		".gz file":        func(mp *MetricParser) error { return mp.ParseFile(compressed) },
+		"gzip magic file": func(mp *MetricParser) error { return mp.ParseFile(detected) },
+		"plain reader":    func(mp *MetricParser) error { return mp.ParseReader(strings.NewReader(content)) },
+	}
+	for name, parse := range parsers {
+		mp := NewMetricParser()
+		if err := parse(mp); err != nil {
+			t.Fatalf("%s: parse returned error: %v", name, err)
+		}
+		if mp.buckets["b1"] == nil || mp.buckets["b1"].ObjectCount != 42 {
+			t.Fatalf("%s: expected bucket b1 with 42 objects, got %+v", name, mp.buckets["b1"])
+		}
+	}
+
+	plain := filepath.Join(dir, "plain.gz")
+	if err := os.WriteFile(plain, []byte(content), 0o644); err != nil {
+		t.Fatalf("unable to write %s: %v", plain, err)
+	}
+	if err := NewMetricParser().ParseFile(plain); err == nil {
+		t.Fatalf("expected an error for a .gz file that isn't gzip compressed")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeRangesFile(t *testing.T, content string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "ranges.json")
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing ranges file: %v", err)
+	}
+	return name
+}
+
+func TestFormatSizeDistributionDefaultRanges(t *testing.T) {
+	dist := map[string]int64{
+		"LESS_THAN_1024_B":         3,
+		"BETWEEN_1_MB_AND_10_MB":   2,
+		"BETWEEN_1_GB_AND_10_GB":   1,
+		"BETWEEN_64_KB_AND_256_KB": 0,
+	}
+	got := formatSizeDistribution(dist, defaultSizeRanges)
+	if want := "<1KB: 3, 1-10MB: 2, BETWEEN_1_GB_AND_10_GB: 1"; got != want {
+		t.Fatalf("expected unknown ranges to be listed last, got %q, want %q", got, want)
+	}
+	if got := getSizeStatus(dist, defaultSizeRanges); got != "Mixed Sizes" {
+		t.Fatalf("unexpected size status %q", got)
+	}
+}
+
+func TestLoadSizeRanges(t *testing.T) {
+	name := writeRangesFile(t, `[
+		{"range": "LESS_THAN_1024_B", "label": "tiny", "category": "small"},
+		{"range": "BETWEEN_1_GB_AND_10_GB", "label": "1-10GB", "category": "large"},
+		{"range": "BETWEEN_1024B_AND_1_MB", "category": "medium"}
+	]`)
+	ranges, err := loadSizeRanges(name)
+	if err != nil {
+		t.Fatalf("loadSizeRanges returned error: %v", err)
+	}
+	if ranges[2].Range != "BETWEEN_1024_B_AND_1_MB" || ranges[2].Label != "BETWEEN_1024_B_AND_1_MB" {
+		t.Fatalf("expected the range to be normalized and used as label, got %+v", ranges[2])
+	}
+
+	dist := map[string]int64{"LESS_THAN_1024_B": 1, "BETWEEN_1_GB_AND_10_GB": 9}
+	if got, want := formatSizeDistribution(dist, ranges), "tiny: 1, 1-10GB: 9"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if got := getSizeStatus(dist, ranges); got != "Mostly Large" {
+		t.Fatalf("expected the configured categories to be used, got %q", got)
+	}
+
+	if got := getSizeStatus(map[string]int64{"BETWEEN_1_MB_AND_10_MB": 4}, ranges); got != "Unknown" {
+		t.Fatalf("expected objects in unmapped ranges only to give an unknown status, got %q", got)
+	}
+
+	args, err := parseArgs([]string{"sample.txt", "--ranges", name})
+	if err != nil {
+		t.Fatalf("parseArgs returned error: %v", err)
+	}
+	if len(args.Display.SizeRanges) != 3 {
+		t.Fatalf("expected --ranges to load 3 ranges, got %+v", args.Display.SizeRanges)
+	}
+}
+
+func TestLoadSizeRangesRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "not json", content: `range,label`},
+		{name: "empty", content: `[]`},
+		{name: "missing range", content: `[{"label": "x", "category": "small"}]`},
+		{name: "unknown category", content: `[{"range": "LESS_THAN_1024_B", "category": "tiny"}]`},
+		{name: "duplicate range", content: `[{"range": "BETWEEN_1024B_AND_1_MB", "category": "small"}, {"range": "BETWEEN_1024_B_AND_1_MB", "category": "small"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadSizeRanges(writeRangesFile(t, tt.content)); err == nil {
+				t.Fatalf("expected %s to be rejected", tt.content)
+			}
+		})
+	}
+	if _, err := parseArgs([]string{"sample.txt", "--ranges", filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Fatalf("expected a missing ranges file to be rejected")
+	}
+}
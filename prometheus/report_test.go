@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const reportMetrics = `minio_bucket_usage_object_total{bucket="small",server="s1"} 10
+minio_bucket_usage_total_bytes{bucket="small",server="s1"} 1024
+minio_bucket_usage_object_total{bucket="large",server="s1"} 20
+minio_bucket_usage_total_bytes{bucket="large",server="s1"} 4096
+minio_bucket_objects_version_distribution{bucket="large",range="BETWEEN_2_AND_10",server="s1"} 20
+minio_bucket_objects_size_distribution{bucket="large",range="LESS_THAN_1024_B",server="s1"} 20
+minio_cluster_usage_object_total{server="s1"} 30
+minio_cluster_usage_total_bytes{server="s1"} 5120
+`
+
+func parseMetrics(t *testing.T, content string) *MetricParser {
+	t.Helper()
+	mp := NewMetricParser()
+	if err := mp.ParseReader(strings.NewReader(content)); err != nil {
+		t.Fatalf("ParseReader returned error: %v", err)
+	}
+	return mp
+}
+
+func TestWriteSummaryJSON(t *testing.T) {
+	mp := parseMetrics(t, reportMetrics)
+
+	var out bytes.Buffer
+	if err := mp.WriteSummaryJSON(&out, DisplayOptions{}); err != nil {
+		t.Fatalf("WriteSummaryJSON returned error: %v", err)
+	}
+
+	var report SummaryReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if len(report.Buckets) != 2 || report.Buckets[0].Name != "large" || report.Buckets[1].Name != "small" {
+		t.Fatalf("expected buckets large, small, got %+v", report.Buckets)
+	}
+	large := report.Buckets[0]
+	if large.VersioningStatus != "Multi-Version" || large.SizeStatus != "Mostly Small" {
+		t.Fatalf("unexpected derived statuses: %q, %q", large.VersioningStatus, large.SizeStatus)
+	}
+	if large.VersionDistribution["BETWEEN_2_AND_10"] != 20 {
+		t.Fatalf("expected the version distribution in the output, got %+v", large.VersionDistribution)
+	}
+	want := SummaryTotals{Buckets: 2, ObjectCount: 30, SizeBytes: 5120, SizeHuman: "5.0 KB"}
+	if report.Totals != want {
+		t.Fatalf("expected totals %+v, got %+v", want, report.Totals)
+	}
+}
+
+func TestBuildReportClusterAggregate(t *testing.T) {
+	report := parseMetrics(t, reportMetrics).BuildReport(DisplayOptions{Cluster: true})
+	if len(report.Buckets) != 3 || report.Buckets[0].Name != clusterAggregateName {
+		t.Fatalf("expected the cluster aggregate first with --cluster, got %+v", report.Buckets)
+	}
+	// The aggregate covers the same objects as the buckets, so it isn't counted twice
+	if report.Totals.Buckets != 2 || report.Totals.ObjectCount != 30 || report.Totals.SizeBytes != 5120 {
+		t.Fatalf("expected the totals of the buckets only with --cluster, got %+v", report.Totals)
+	}
+
+	// Without per-bucket metrics the cluster aggregates are reported instead
+	report = parseMetrics(t, "minio_cluster_usage_object_total{server=\"s1\"} 30\n").BuildReport(DisplayOptions{})
+	if len(report.Buckets) != 1 || report.Buckets[0].Name != clusterAggregateName || report.Totals.ObjectCount != 30 {
+		t.Fatalf("expected only the cluster aggregate, got %+v", report)
+	}
+}
+
+func TestWriteSummaryCSV(t *testing.T) {
+	mp := parseMetrics(t, reportMetrics)
+
+	var out bytes.Buffer
+	if err := mp.WriteSummaryCSV(&out, DisplayOptions{}, true); err != nil {
+		t.Fatalf("WriteSummaryCSV returned error: %v", err)
+	}
+	want := `bucket,object_count,size_bytes,size_human,versioning_status,size_status
+large,20,4096,4.0 KB,Multi-Version,Mostly Small
+small,10,1024,1.0 KB,Unknown,Unknown
+TOTAL (2 buckets),30,5120,5.0 KB,,
+`
+	if out.String() != want {
+		t.Fatalf("unexpected CSV:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if err := mp.WriteSummaryCSV(&out, DisplayOptions{}, false); err != nil {
+		t.Fatalf("WriteSummaryCSV returned error: %v", err)
+	}
+	if strings.Contains(out.String(), "TOTAL") {
+		t.Fatalf("expected no totals row, got:\n%s", out.String())
+	}
+}
+
+func TestGetSummarySort(t *testing.T) {
+	mp := parseMetrics(t, `minio_bucket_usage_object_total{bucket="b",server="s1"} 500
+minio_bucket_usage_total_bytes{bucket="b",server="s1"} 1024
+minio_bucket_usage_object_total{bucket="c",server="s1"} 5
+minio_bucket_usage_total_bytes{bucket="c",server="s1"} 4096
+minio_bucket_usage_object_total{bucket="a",server="s1"} 5
+minio_bucket_usage_total_bytes{bucket="a",server="s1"} 4096
+`)
+
+	tests := []struct {
+		sortBy  string
+		reverse bool
+		want    string
+	}{
+		{sortBy: "", want: "a,c,b"},
+		{sortBy: sortBySize, want: "a,c,b"},
+		{sortBy: sortByObjects, want: "b,a,c"},
+		{sortBy: sortByName, want: "a,b,c"},
+		{sortBy: sortBySize, reverse: true, want: "b,c,a"},
+		{sortBy: sortByObjects, reverse: true, want: "c,a,b"},
+		{sortBy: sortByName, reverse: true, want: "c,b,a"},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, bucket := range mp.GetSummary(DisplayOptions{SortBy: tt.sortBy, Reverse: tt.reverse}) {
+			names = append(names, bucket.Name)
+		}
+		if got := strings.Join(names, ","); got != tt.want {
+			t.Fatalf("sort %q (reverse %v): expected %s, got %s", tt.sortBy, tt.reverse, tt.want, got)
+		}
+	}
+}
+
+func TestBuildReportFilter(t *testing.T) {
+	mp := parseMetrics(t, reportMetrics)
+
+	report := mp.BuildReport(DisplayOptions{Filter: regexp.MustCompile("^sm")})
+	if len(report.Buckets) != 1 || report.Buckets[0].Name != "small" {
+		t.Fatalf("expected only bucket small, got %+v", report.Buckets)
+	}
+	want := SummaryTotals{Buckets: 1, ObjectCount: 10, SizeBytes: 1024, SizeHuman: "1.0 KB"}
+	if report.Totals != want {
+		t.Fatalf("expected totals of the filtered buckets %+v, got %+v", want, report.Totals)
+	}
+
+	// A filter matching no bucket doesn't fall back to the cluster aggregates
+	report = mp.BuildReport(DisplayOptions{Filter: regexp.MustCompile("none")})
+	if len(report.Buckets) != 0 {
+		t.Fatalf("expected no buckets, got %+v", report.Buckets)
+	}
+}
+
+func TestGetSummaryThresholds(t *testing.T) {
+	mp := parseMetrics(t, reportMetrics)
+
+	tests := []struct {
+		name string
+		opts DisplayOptions
+		want string
+	}{
+		{name: "none", opts: DisplayOptions{}, want: "large,small"},
+		{name: "min objects", opts: DisplayOptions{MinObjects: 11}, want: "large"},
+		{name: "min size", opts: DisplayOptions{MinSize: 1024}, want: "large,small"},
+		{name: "min size above", opts: DisplayOptions{MinSize: 1025}, want: "large"},
+		{name: "both", opts: DisplayOptions{MinObjects: 5, MinSize: 8192}, want: ""},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, bucket := range mp.GetSummary(tt.opts) {
+			names = append(names, bucket.Name)
+		}
+		if got := strings.Join(names, ","); got != tt.want {
+			t.Fatalf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+
+	report := mp.BuildReport(DisplayOptions{MinObjects: 11})
+	if report.Totals.Buckets != 1 || report.Totals.ObjectCount != 20 || report.Totals.SizeBytes != 4096 {
+		t.Fatalf("expected totals of the surviving bucket, got %+v", report.Totals)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"512":     512,
+		"100B":    100,
+		"1k":      1024,
+		"2KB":     2048,
+		"1GiB":    1 << 30,
+		"1.5 GB":  3 << 29,
+		"2T":      2 << 40,
+		" 1PiB ":  1 << 50,
+		"0.5mib":  1 << 19,
+		"1024KiB": 1 << 20,
+	}
+	for value, want := range tests {
+		got, err := parseSize(value)
+		if err != nil {
+			t.Fatalf("parseSize(%q) returned error: %v", value, err)
+		}
+		if got != want {
+			t.Fatalf("parseSize(%q): expected %d, got %d", value, want, got)
+		}
+	}
+
+	for _, value := range []string{"", "GiB", "1XB", "-1GiB", "1.2.3G"} {
+		if _, err := parseSize(value); err == nil {
+			t.Fatalf("expected parseSize(%q) to fail", value)
+		}
+	}
+}
+
+func TestPercentOf(t *testing.T) {
+	summaries := parseMetrics(t, reportMetrics).GetSummary(DisplayOptions{})
+	buckets, objects, bytes := summaryTotals(summaries)
+	if buckets != 2 || objects != 30 || bytes != 5120 {
+		t.Fatalf("expected totals of 2 buckets, 30 objects and 5120 bytes, got %d, %d and %d", buckets, objects, bytes)
+	}
+
+	tests := []struct {
+		part, total int64
+		want        string
+	}{
+		{part: 4096, total: bytes, want: "80.0%"},
+		{part: 10, total: objects, want: "33.3%"},
+		{part: 0, total: 10, want: "0.0%"},
+		{part: 0, total: 0, want: "-"},
+	}
+	for _, tt := range tests {
+		if got := percentOf(tt.part, tt.total); got != tt.want {
+			t.Fatalf("percentOf(%d, %d): expected %s, got %s", tt.part, tt.total, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveBearerTokenPrecedence(t *testing.T) {
+	t.Setenv(envMetricsToken, "env-token")
+	t.Setenv(envAccessKey, "")
+	t.Setenv(envSecretKey, "")
+
+	token, err := resolveBearerToken("flag-token", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "flag-token" {
+		t.Fatalf("expected flag token to win, got %q", token)
+	}
+
+	token, err = resolveBearerToken("", "access", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token == "env-token" || strings.Count(token, ".") != 2 {
+		t.Fatalf("expected key flags to win over env token, got %q", token)
+	}
+
+	t.Setenv(envAccessKey, "env-access")
+	t.Setenv(envSecretKey, "env-secret")
+	token, err = resolveBearerToken("", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "env-token" {
+		t.Fatalf("expected env token to win over env keys, got %q", token)
+	}
+
+	t.Setenv(envAccessKey, "")
+	t.Setenv(envSecretKey, "")
+
+	t.Setenv(envMetricsToken, "")
+	token, err = resolveBearerToken("", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "" {
+		t.Fatalf("expected no token, got %q", token)
+	}
+
+	if _, err := resolveBearerToken("", "access", ""); err == nil {
+		t.Fatalf("expected error when only access key is set")
+	}
+}
+
+func TestGenerateMetricsToken(t *testing.T) {
+	token, err := generateMetricsToken("access", "secret", time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 token parts, got %d", len(parts))
+	}
+
+	mac := hmac.New(sha512.New, []byte("secret"))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if parts[2] != base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) {
+		t.Fatalf("token signature does not match HS512 of secret key")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatalf("unable to decode claims: %v", err)
+	}
+	var claims struct {
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+		Iss string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("unable to parse claims: %v", err)
+	}
+	if claims.Sub != "access" || claims.Iss != "prometheus" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+	if claims.Exp <= time.Now().Unix() {
+		t.Fatalf("expected expiry in the future, got %d", claims.Exp)
+	}
+}
+
+func TestScrapeURLSendsBearerToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprintln(w, `minio_bucket_usage_object_total{bucket="b1",server="s1"} 42`)
+	}))
+	defer server.Close()
+
+	mp := NewMetricParser()
+	if err := mp.ScrapeURL(server.URL, "wrong-token"); err == nil {
+		t.Fatalf("expected error for rejected token")
+	}
+
+	mp = NewMetricParser()
+	if err := mp.ScrapeURL(server.URL, "secret-token"); err != nil {
+		t.Fatalf("ScrapeURL returned error: %v", err)
+	}
+	if mp.buckets["b1"] == nil || mp.buckets["b1"].ObjectCount != 42 {
+		t.Fatalf("expected bucket b1 with 42 objects, got %+v", mp.buckets["b1"])
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+const serverMetrics = `minio_bucket_usage_object_total{bucket="skewed",server="n1"} 100
+minio_bucket_usage_total_bytes{bucket="skewed",server="n1"} 1000
+minio_bucket_usage_object_total{bucket="skewed",server="n2"} 300
+minio_bucket_usage_total_bytes{bucket="skewed",server="n2"} 3000
+minio_bucket_usage_total_bytes{bucket="skewed",server="n2"} 2000
+minio_bucket_usage_object_total{bucket="even",server="n1"} 5
+minio_bucket_usage_total_bytes{bucket="even",server="n1"} 50
+minio_bucket_usage_object_total{bucket="even",server="n2"} 5
+minio_bucket_usage_total_bytes{bucket="even",server="n2"} 50
+`
+
+func TestServerUsage(t *testing.T) {
+	mp := parseMetrics(t, serverMetrics)
+	usage := mp.buckets["skewed"].ServerUsage
+	if len(usage) != 2 || *usage["n1"] != (ServerUsage{ObjectCount: 100, SizeBytes: 1000}) || *usage["n2"] != (ServerUsage{ObjectCount: 300, SizeBytes: 3000}) {
+		t.Fatalf("unexpected per-server usage: n1=%+v n2=%+v", usage["n1"], usage["n2"])
+	}
+}
+
+func TestBuildServerBreakdown(t *testing.T) {
+	mp := parseMetrics(t, serverMetrics)
+	breakdown := mp.BuildServerBreakdown(DisplayOptions{SortBy: sortBySize})
+	if len(breakdown) != 2 || breakdown[0].Name != "skewed" || breakdown[1].Name != "even" {
+		t.Fatalf("expected the buckets largest first, got %+v", breakdown)
+	}
+
+	want := []ServerShare{
+		{Server: "n2", ObjectCount: 300, SizeBytes: 3000, ObjectsPercent: 75, BytesPercent: 75},
+		{Server: "n1", ObjectCount: 100, SizeBytes: 1000, ObjectsPercent: 25, BytesPercent: 25},
+	}
+	for i, share := range breakdown[0].Servers {
+		if share != want[i] {
+			t.Fatalf("server %d: got %+v, want %+v", i, share, want[i])
+		}
+	}
+	if servers := breakdown[1].Servers; servers[0].Server != "n1" || servers[0].BytesPercent != 50 {
+		t.Fatalf("expected equal servers ordered by name, got %+v", servers)
+	}
+
+	filtered := mp.BuildServerBreakdown(DisplayOptions{Filter: regexp.MustCompile("^even$")})
+	if len(filtered) != 1 || filtered[0].Name != "even" {
+		t.Fatalf("expected --filter to apply, got %+v", filtered)
+	}
+}
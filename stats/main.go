@@ -1,15 +1,23 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
 	"net"
 	"net/url"
 	"os"
+	"path/filepath"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/dustin/go-humanize"
@@ -17,6 +25,9 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/minio/madmin-go/v3"
 	"github.com/rivo/tview"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
 )
 
 // Wrap "Info" message together with fields "Status" and "Error"
@@ -27,277 +38,2133 @@ type clusterStruct struct {
 }
 
 type driveStatus struct {
-	SetIndex   int
-	DriveIndex int
-	Path       string
-	Status     string
-	UsedSpace  uint64
-	TotalSpace uint64
-	UsedInodes uint64
-	FreeInodes uint64
-	Metrics    *madmin.DiskMetrics
+	// Endpoint is the trimmed server name and drive path, e.g. node1:/mnt/drive1
+	Endpoint   string              `json:"endpoint"`
+	SetIndex   int                 `json:"setIndex"`
+	DriveIndex int                 `json:"driveIndex"`
+	Path       string              `json:"path"`
+	Status     string              `json:"status"`
+	UsedSpace  uint64              `json:"usedSpace"`
+	TotalSpace uint64              `json:"totalSpace"`
+	UsedInodes uint64              `json:"usedInodes"`
+	FreeInodes uint64              `json:"freeInodes"`
+	Metrics    *madmin.DiskMetrics `json:"metrics,omitempty"`
+}
+
+// report is the parsed cluster information the text and JSON output are
+// rendered from. Pool and set numbers are 1-based, as printed.
+type report struct {
+	Pools []poolReport `json:"pools"`
+	// ServerDrives counts the drives of every server by status, in natural
+	// endpoint order
+	ServerDrives []serverDrives `json:"serverDrives"`
+	Summary      summaryReport  `json:"summary"`
+}
+
+type serverDrives struct {
+	Endpoint    string         `json:"endpoint"`
+	Pool        int            `json:"pool"`
+	State       string         `json:"state"`
+	DriveStatus map[string]int `json:"driveStatus"`
+}
+
+type poolReport struct {
+	Pool    int            `json:"pool"`
+	Servers []serverReport `json:"servers"`
+	Sets    []setReport    `json:"sets"`
+	// DriveStatus counts the drives of the pool by status
+	DriveStatus map[string]int `json:"driveStatus"`
+	// MissingDrives counts the drives of the pool absent from the info
+	MissingDrives int          `json:"missingDrives,omitempty"`
+	Capacity      poolCapacity `json:"capacity"`
+}
+
+// poolCapacity is the raw capacity of the drives of a pool and the part of it
+// left for data once the parity of its erasure sets is taken off
+type poolCapacity struct {
+	RawTotalBytes uint64 `json:"rawTotalBytes"`
+	RawUsedBytes  uint64 `json:"rawUsedBytes"`
+	RawFreeBytes  uint64 `json:"rawFreeBytes"`
+	// DataDrives and ParityDrives are the layout of every erasure set of the
+	// pool, both 0 when the info doesn't tell it
+	DataDrives       int    `json:"dataDrives"`
+	ParityDrives     int    `json:"parityDrives"`
+	UsableTotalBytes uint64 `json:"usableTotalBytes"`
+	UsableFreeBytes  uint64 `json:"usableFreeBytes"`
+}
+
+type serverReport struct {
+	Endpoint            string `json:"endpoint"`
+	State               string `json:"state"`
+	Edition             string `json:"edition"`
+	Version             string `json:"version"`
+	CommitID            string `json:"commitID"`
+	MemStatsAlloc       uint64 `json:"memStatsAlloc"`
+	ILMExpiryInProgress bool   `json:"ilmExpiryInProgress"`
+	UptimeSeconds       int64  `json:"uptimeSeconds"`
+}
+
+type setReport struct {
+	Set    int           `json:"set"`
+	Drives []driveStatus `json:"drives"`
+	// Imbalance is set when the used space of the drives is spread wider
+	// than --imbalance-threshold
+	Imbalance *setImbalance `json:"imbalance,omitempty"`
+	// Missing counts the drives the set should have by the backend layout
+	// that are absent from the info altogether
+	Missing int `json:"missing,omitempty"`
+	// Tolerance is how many more drive failures the set survives, nil when
+	// the backend layout doesn't give its size and parity
+	Tolerance *setTolerance `json:"tolerance,omitempty"`
+}
+
+// setTolerance compares the online drives of an erasure set with its quorum
+type setTolerance struct {
+	OnlineDrives int `json:"onlineDrives"`
+	Drives       int `json:"drives"`
+	Parity       int `json:"parity"`
+	ReadQuorum   int `json:"readQuorum"`
+	WriteQuorum  int `json:"writeQuorum"`
+	// Failures is the count of further drive failures the set survives
+	// keeping write quorum, 0 at zero margin and negative once it is lost
+	Failures int `json:"failures"`
+}
+
+// setImbalance describes an erasure set whose drives are unevenly filled
+type setImbalance struct {
+	// SpreadPercent is the difference between the fullest and the emptiest
+	// drive in used percentage points
+	SpreadPercent float64 `json:"spreadPercent"`
+	// Drives are the drives more than half the threshold away from the
+	// median used percentage of the set, the farthest first
+	Drives []driveUsed `json:"drives"`
+}
+
+type driveUsed struct {
+	Endpoint    string  `json:"endpoint"`
+	UsedPercent float64 `json:"usedPercent"`
+}
+
+type summaryReport struct {
+	DeploymentID     string `json:"deploymentID"`
+	TotalSets        []int  `json:"totalSets"`
+	StandardSCParity int    `json:"standardSCParity"`
+	RRSCParity       int    `json:"rrSCParity"`
+	DrivesPerSet     []int  `json:"drivesPerSet"`
+	Buckets          uint64 `json:"buckets"`
+	Objects          uint64 `json:"objects"`
+	Versions         uint64 `json:"versions"`
+	DeleteMarkers    uint64 `json:"deleteMarkers"`
+	UsageBytes       uint64 `json:"usageBytes"`
+	Drives           int    `json:"drives"`
+	RawTotalBytes    uint64 `json:"rawTotalBytes"`
+	RawUsedBytes     uint64 `json:"rawUsedBytes"`
+	RawFreeBytes     uint64 `json:"rawFreeBytes"`
+	// UsedPercent is the raw used percentage, 0 when no drive reported its size
+	UsedPercent       float64 `json:"usedPercent"`
+	FullThreshold     float64 `json:"fullThreshold"`
+	GrowthBytesPerDay uint64  `json:"growthBytesPerDay,omitempty"`
+	// DaysToFull estimates the days until FullThreshold is reached, set with
+	// a growth rate or once it is reached
+	DaysToFull *float64 `json:"daysToFull,omitempty"`
+	// Pools lists the pool numbers the layout and raw capacity cover with
+	// --summary-scope filtered, empty for the whole cluster. The scanner
+	// counts always cover the whole cluster.
+	Pools []int `json:"pools,omitempty"`
+	// VersionSkew lists the servers not running the version most servers
+	// run, nil when they all agree
+	VersionSkew *versionSkew `json:"versionSkew,omitempty"`
+}
+
+type versionSkew struct {
+	Version  string          `json:"version"`
+	CommitID string          `json:"commitID"`
+	Servers  []serverVersion `json:"servers"`
+}
+
+type serverVersion struct {
+	Endpoint string `json:"endpoint"`
+	Version  string `json:"version"`
+	CommitID string `json:"commitID"`
+}
+
+// options holds the command line flags
+type options struct {
+	fullThreshold float64
+	// growth is the raw --growth-bytes-per-day, parsed into growthBytesPerDay
+	growth            string
+	growthBytesPerDay uint64
+	json              bool
+	yaml              bool
+	alias             string
+	// pools are the pool numbers to print, all when empty
+	pools         poolList
+	summaryScope  string
+	unhealthyOnly bool
+	markdown      bool
+	tui           bool
+	sortBy        string
+	sortPools     string
+	sortSets      string
+	// imbalanceThreshold is the spread of used percentage within a set
+	// above which it is reported, 0 to disable
+	imbalanceThreshold float64
+	// diff is an earlier info dump to compare the input against
+	diff string
+	// diffInterval is the time between the diff dump and the input, 0 to
+	// take it from the file modification times
+	diffInterval time.Duration
+	merge        bool
+	// mixedDeployments lets --merge add up dumps of different deployments
+	mixedDeployments bool
+	wide             bool
+	plain            bool
+	// usageBar is the width of the usage bar drawn next to each drive, 0
+	// for none
+	usageBar int
+	// domains are the --domain suffixes, and the ones of the deprecated
+	// domain argument
+	domains stringList
+	// inputs are the input files of the positional arguments, "-" for stdin
+	inputs []string
+}
+
+// Summary scopes of --summary-scope
+const (
+	scopeCluster  = "cluster"
+	scopeFiltered = "filtered"
+)
+
+// maxUsageBar bounds the width of --usage-bar
+const maxUsageBar = 100
+
+// Drive orders of --sort
+const (
+	sortEndpoint = "endpoint"
+	sortUsage    = "usage"
+	sortInode    = "inode"
+)
+
+// Pool orders of --sort-pools
+const (
+	sortPoolIndex    = "index"
+	sortPoolCapacity = "capacity"
+	sortPoolUsage    = "usage"
+)
+
+// Set orders of --sort-sets
+const (
+	sortSetIndex  = "index"
+	sortSetStatus = "status"
+)
+
+// stringList collects a repeatable string flag, each value may hold a comma
+// separated list
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, splitDomains(value)...)
+	return nil
+}
+
+func (l *stringList) Type() string {
+	return "strings"
+}
+
+// splitDomains splits a comma separated list of domain suffixes, dropping the
+// empty ones
+func splitDomains(value string) []string {
+	domains := []string{}
+	for _, domain := range strings.Split(value, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// poolList collects the repeatable --pool flag
+type poolList []int
+
+func (p *poolList) String() string {
+	pools := []string{}
+	for _, pool := range *p {
+		pools = append(pools, strconv.Itoa(pool))
+	}
+	return strings.Join(pools, ",")
+}
+
+func (p *poolList) Set(value string) error {
+	pool, err := strconv.Atoi(value)
+	if err != nil || pool < 1 {
+		return fmt.Errorf("pool must be a number of at least 1, got %q", value)
+	}
+	*p = append(*p, pool)
+	return nil
+}
+
+func (p *poolList) Type() string {
+	return "int"
+}
+
+var (
+	opts    options
+	rootCmd = &cobra.Command{
+		Use:   "stats [flags] <filename|->...",
+		Short: "Summarize the servers, drives and capacity of a MinIO cluster",
+		Long: `Parses the output of "mc admin info --json" (or a subnet diagnostics capture, plain or
+gzipped, from files or stdin) or fetches it live with --alias, and prints the servers, the
+drives of every erasure set, the drive status counts and the overall capacity of the cluster.`,
+		// main prints the returned error
+		SilenceErrors: true,
+		PreRunE:       prepareOptions,
+		Run:           runStats,
+	}
+)
+
+// registerFlags binds the command line flags to o and declares which flag
+// combinations contradict each other
+func registerFlags(cmd *cobra.Command, o *options) {
+	cmd.Flags().Float64Var(&o.fullThreshold, "full-threshold", 85, "Used percentage at which the cluster is considered full")
+	cmd.Flags().StringVar(&o.growth, "growth-bytes-per-day", "", "Expected raw growth per day (e.g. 500GiB), used to estimate time until full")
+	cmd.Flags().BoolVar(&o.json, "json", false, "Print the report as JSON instead of text")
+	cmd.Flags().BoolVar(&o.yaml, "yaml", false, "Print the report as YAML instead of text")
+	cmd.Flags().BoolVar(&o.markdown, "markdown", false, "Print the report as Markdown tables instead of text")
+	cmd.Flags().BoolVar(&o.tui, "tui", false, "Browse the drives in an interactive table instead of printing the report")
+	cmd.Flags().StringVar(&o.alias, "alias", "", "Fetch the info live from the server of this mc alias instead of a file")
+	cmd.Flags().Var(&o.domains, "domain", "Domain suffix to trim from the endpoint names, comma separated or repeatable")
+	cmd.Flags().Var(&o.pools, "pool", "Print only this pool number (repeatable)")
+	cmd.Flags().BoolVar(&o.unhealthyOnly, "unhealthy-only", false, "Print only the servers and drives that are not online/ok, and the sets holding such drives")
+	cmd.Flags().StringVar(&o.sortBy, "sort", sortEndpoint, "Order of the drives within a set: endpoint, usage or inode")
+	cmd.Flags().StringVar(&o.sortPools, "sort-pools", sortPoolIndex, "Order of the pools: index, capacity (largest raw capacity first) or usage (fullest first)")
+	cmd.Flags().StringVar(&o.sortSets, "sort-sets", sortSetIndex, "Order of the sets within a pool: index or status (most drives not ok or missing first)")
+	cmd.Flags().Float64Var(&o.imbalanceThreshold, "imbalance-threshold", 10, "Warn when the used percentage of the drives of a set is spread wider than this, 0 to disable")
+	cmd.Flags().StringVar(&o.diff, "diff", "", "Print only what changed since this earlier info dump")
+	cmd.Flags().DurationVar(&o.diffInterval, "diff-interval", 0, "Time between the --diff dump and the input (e.g. 24h) to derive the growth per day, by default from their file modification times")
+	cmd.Flags().BoolVar(&o.merge, "merge", false, "With several input files, also print the overall summary of all of them combined")
+	cmd.Flags().BoolVar(&o.mixedDeployments, "allow-mixed-deployments", false, "With --merge, add up dumps of different deployments with a warning instead of refusing to")
+	cmd.Flags().BoolVar(&o.wide, "wide", false, "Align the drive lines in columns without truncating them to the terminal width")
+	cmd.Flags().BoolVar(&o.plain, "plain", false, "Print the drive lines in the plain format scripts parse, the default when the output isn't a terminal")
+	cmd.Flags().IntVar(&o.usageBar, "usage-bar", 0, "Draw a usage bar this many characters wide next to each drive, e.g. [####----] (0 = none)")
+	cmd.Flags().StringVar(&o.summaryScope, "summary-scope", scopeCluster, "Overall summary of the whole cluster or of the --pool selection: cluster or filtered")
+
+	cmd.MarkFlagsMutuallyExclusive("json", "yaml", "markdown", "tui")
+	cmd.MarkFlagsMutuallyExclusive("wide", "plain")
+	for _, name := range []string{"markdown", "tui", "unhealthy-only", "growth-bytes-per-day"} {
+		cmd.MarkFlagsMutuallyExclusive("diff", name)
+	}
+}
+
+// prepareOptions validates the flags and sorts the positional arguments into
+// the input files and the domain before the command runs
+func prepareOptions(cmd *cobra.Command, args []string) error {
+	if opts.fullThreshold <= 0 || opts.fullThreshold > 100 {
+		return fmt.Errorf("--full-threshold must be within (0, 100], got %v", opts.fullThreshold)
+	}
+	if opts.growth != "" {
+		growthBytes, err := humanize.ParseBytes(opts.growth)
+		if err != nil {
+			return fmt.Errorf("invalid --growth-bytes-per-day %q: %v", opts.growth, err)
+		}
+		opts.growthBytesPerDay = growthBytes
+	}
+	if opts.usageBar < 0 || opts.usageBar > maxUsageBar {
+		return fmt.Errorf("--usage-bar must be within [0, %d], got %d", maxUsageBar, opts.usageBar)
+	}
+	if opts.imbalanceThreshold < 0 || opts.imbalanceThreshold > 100 {
+		return fmt.Errorf("--imbalance-threshold must be within [0, 100], got %v", opts.imbalanceThreshold)
+	}
+	if opts.sortBy != sortEndpoint && opts.sortBy != sortUsage && opts.sortBy != sortInode {
+		return fmt.Errorf("--sort must be %s, %s or %s, got %q", sortEndpoint, sortUsage, sortInode, opts.sortBy)
+	}
+	if opts.sortPools != sortPoolIndex && opts.sortPools != sortPoolCapacity && opts.sortPools != sortPoolUsage {
+		return fmt.Errorf("--sort-pools must be %s, %s or %s, got %q", sortPoolIndex, sortPoolCapacity, sortPoolUsage, opts.sortPools)
+	}
+	if opts.sortSets != sortSetIndex && opts.sortSets != sortSetStatus {
+		return fmt.Errorf("--sort-sets must be %s or %s, got %q", sortSetIndex, sortSetStatus, opts.sortSets)
+	}
+	if opts.summaryScope != scopeCluster && opts.summaryScope != scopeFiltered {
+		return fmt.Errorf("--summary-scope must be %s or %s, got %q", scopeCluster, scopeFiltered, opts.summaryScope)
+	}
+	if opts.diffInterval < 0 {
+		return fmt.Errorf("--diff-interval must be positive, got %v", opts.diffInterval)
+	}
+	if opts.diffInterval > 0 && opts.diff == "" {
+		return fmt.Errorf("--diff-interval only applies to --diff")
+	}
+	if opts.summaryScope == scopeFiltered && len(opts.pools) == 0 {
+		return fmt.Errorf("--summary-scope %s needs at least one --pool", scopeFiltered)
+	}
+
+	inputs, domainString, err := splitArgs(args, opts)
+	if err != nil {
+		return err
+	}
+	if domainString != "" {
+		fmt.Fprintln(os.Stderr, "warning: the domain argument is deprecated, use --domain instead")
+		opts.domains = append(splitDomains(domainString), opts.domains...)
+	}
+	if opts.alias != "" && len(inputs) > 0 {
+		return fmt.Errorf("--alias takes no input file, got %s", strings.Join(inputs, " "))
+	}
+	if opts.alias == "" && len(inputs) == 0 {
+		if !stdinIsPiped() {
+			return fmt.Errorf("please provide the filename, or - to read from stdin")
+		}
+		inputs = []string{"-"}
+	}
+	if len(inputs) > 1 && (opts.diff != "" || opts.tui) {
+		return fmt.Errorf("--diff and --tui take a single input file")
+	}
+	if opts.merge && len(inputs) < 2 {
+		return fmt.Errorf("--merge needs at least two input files")
+	}
+	if opts.mixedDeployments && !opts.merge {
+		return fmt.Errorf("--allow-mixed-deployments only applies to --merge")
+	}
+	opts.inputs = inputs
+	return nil
 }
 
 func main() {
-	if len(os.Args) == 1 {
-		fmt.Println("Please provide the filename")
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	registerFlags(rootCmd, &opts)
+}
+
+// runStats loads the info of the input or the alias and prints it in the
+// selected format
+func runStats(cmd *cobra.Command, args []string) {
+	domains := opts.domains
+	inputs := opts.inputs
+
+	var infoStruct clusterStruct
+	var err error
+	if opts.alias != "" {
+		infoStruct, err = fetchInfo(opts.alias)
+		if err != nil {
+			fmt.Printf("Error on fetching the info from alias:%s, err:%v\n", opts.alias, err)
+			os.Exit(1)
+		}
+	} else if len(inputs) > 1 {
+		printReports(inputs, domains, opts)
+		return
+	} else {
+		infoStruct, err = loadInfo(inputs[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	r, err := prepareReport(infoStruct, domains, opts)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if opts.diff != "" {
+		beforeStruct, err := loadInfo(opts.diff)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		before := buildReport(beforeStruct, domains, opts)
+		if len(opts.pools) > 0 {
+			// pools may have been added in between, keep whatever is there
+			before.Pools = slices.DeleteFunc(before.Pools, func(pool poolReport) bool { return !slices.Contains(opts.pools, pool.Pool) })
+		}
+		changes := diffReports(before, r)
+		interval, source := dumpInterval(inputs, opts)
+		diffGrowth(&changes, before, r, interval, source, opts)
+		if opts.json || opts.yaml {
+			printStructured(changes, opts)
+			return
+		}
+		printDiff(opts.diff, changes)
+		return
+	}
+	if opts.unhealthyOnly {
+		r = filterUnhealthy(r)
+	}
+	if opts.json || opts.yaml {
+		printStructured(r, opts)
+		return
+	}
+	if opts.markdown {
+		printMarkdown(r)
+		return
+	}
+	if opts.tui {
+		if err := drawTable(r); err != nil {
+			fmt.Printf("Error on running the interactive view, err:%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	printReport(r, newDriveLayout(opts))
+}
+
+// splitArgs separates the input files from the deprecated trailing domain
+// argument. Only the old "file domain" shape has one: exactly two arguments,
+// the last neither "-", an existing file nor looking like a path. With
+// --alias there is no input file and a single argument is the domain. Any
+// other input that doesn't exist is an error.
+func splitArgs(args []string, opts options) ([]string, string, error) {
+	if len(args) == 0 {
+		return nil, "", nil
+	}
+	last := args[len(args)-1]
+	if opts.alias != "" {
+		return args[:len(args)-1], strings.TrimSpace(last), nil
+	}
+	inputs, domain := args, ""
+	if len(args) == 2 && last != "-" && !fileExists(last) && !looksLikePath(last) {
+		inputs, domain = args[:1], strings.TrimSpace(last)
+	}
+	for _, input := range inputs {
+		if input != "-" && !fileExists(input) {
+			return nil, "", fmt.Errorf("file not found: %s", input)
+		}
+	}
+	return inputs, domain, nil
+}
+
+// fileExists reports whether name is an existing file
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// looksLikePath reports whether arg is meant as a file rather than a domain
+func looksLikePath(arg string) bool {
+	return strings.Contains(arg, "/") || strings.HasSuffix(arg, ".json") || strings.HasSuffix(arg, ".gz")
+}
+
+// prepareReport builds the report of infoStruct, keeps the --pool
+// selection and puts the pools and sets in the --sort-pools and --sort-sets
+// order
+func prepareReport(infoStruct clusterStruct, domains []string, opts options) (report, error) {
+	r := buildReport(infoStruct, domains, opts)
+	if len(opts.pools) > 0 {
+		var err error
+		if r, err = filterPools(r, infoStruct, opts); err != nil {
+			return r, err
+		}
+	}
+	sortPools(r.Pools, opts.sortPools)
+	for _, pool := range r.Pools {
+		sortSets(pool.Sets, opts.sortSets)
+	}
+	return r, nil
+}
+
+// printStructured prints value as indented JSON, or as YAML with --yaml
+func printStructured(value any, opts options) {
+	encode := printJSON
+	if opts.yaml {
+		encode = printYAML
+	}
+	if err := encode(value); err != nil {
+		fmt.Printf("Error on encoding the report, err:%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// printJSON prints value as indented JSON
+func printJSON(value any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// printYAML prints value as YAML with the same field names and order as the
+// JSON output, going through JSON so the json tags apply
+func printYAML(value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	node := yaml.Node{}
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// blockStyle drops the flow style and quoting the JSON input left on node
+// and its children
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// fileReport is the report of one of several input files
+type fileReport struct {
+	File         string `json:"file"`
+	DeploymentID string `json:"deploymentID"`
+	Report       report `json:"report"`
+}
+
+// multiReport is the JSON output for several input files, Merged is the
+// combined summary with --merge
+type multiReport struct {
+	Files  []fileReport   `json:"files"`
+	Merged *summaryReport `json:"merged,omitempty"`
+}
+
+// printReports prints the report of every input file under its name and,
+// with --merge, the combined overall summary of all of them
+func printReports(inputs []string, domains []string, opts options) {
+	multi := multiReport{}
+	for _, filename := range inputs {
+		infoStruct, err := loadInfo(filename)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		r, err := prepareReport(infoStruct, domains, opts)
+		if err != nil {
+			fmt.Printf("%s: %v\n", filename, err)
+			os.Exit(1)
+		}
+		if opts.unhealthyOnly {
+			r = filterUnhealthy(r)
+		}
+		multi.Files = append(multi.Files, fileReport{File: filename, DeploymentID: r.Summary.DeploymentID, Report: r})
+	}
+	if opts.merge {
+		if err := checkDeployments(multi.Files); err != nil {
+			if !opts.mixedDeployments {
+				fmt.Printf("refusing to merge %v, pass --allow-mixed-deployments to merge them anyway\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "warning: merging %v\n", err)
+		}
+		merged := mergeSummaries(multi.Files, opts)
+		multi.Merged = &merged
+	}
+
+	if opts.json || opts.yaml {
+		printStructured(multi, opts)
+		return
+	}
+	for index, file := range multi.Files {
+		if opts.markdown {
+			fmt.Printf("# %s (deployment %s)\n\n", markdownCell(file.File), markdownCell(file.DeploymentID))
+			printMarkdown(file.Report)
+			fmt.Println()
+			continue
+		}
+		if index > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("==> %s (deployment %s) <==\n", file.File, file.DeploymentID)
+		printReport(file.Report, newDriveLayout(opts))
+	}
+	if multi.Merged == nil {
+		return
+	}
+	if opts.markdown {
+		fmt.Println("# Merged")
+		fmt.Println()
+		printMarkdownSummary(*multi.Merged)
+		return
+	}
+	fmt.Println()
+	fmt.Println("==> merged <==")
+	printOverall(*multi.Merged)
+}
+
+// checkDeployments returns an error listing the deployment ID of every file
+// when the files don't all come from the same deployment
+func checkDeployments(files []fileReport) error {
+	for _, file := range files {
+		if file.DeploymentID == files[0].DeploymentID {
+			continue
+		}
+		parts := []string{}
+		for _, file := range files {
+			parts = append(parts, fmt.Sprintf("%s (%s)", file.DeploymentID, file.File))
+		}
+		return fmt.Errorf("dumps of different deployments: %s", strings.Join(parts, ", "))
+	}
+	return nil
+}
+
+// mergeSummaries adds up the overall summaries of several reports. The set
+// layouts are concatenated in file order and the deployment IDs are listed
+// side by side.
+func mergeSummaries(files []fileReport, opts options) summaryReport {
+	merged := summaryReport{FullThreshold: opts.fullThreshold, GrowthBytesPerDay: opts.growthBytesPerDay}
+	deployments := []string{}
+	for _, file := range files {
+		summary := file.Report.Summary
+		if !slices.Contains(deployments, summary.DeploymentID) {
+			deployments = append(deployments, summary.DeploymentID)
+		}
+		merged.TotalSets = append(merged.TotalSets, summary.TotalSets...)
+		merged.DrivesPerSet = append(merged.DrivesPerSet, summary.DrivesPerSet...)
+		merged.StandardSCParity = max(merged.StandardSCParity, summary.StandardSCParity)
+		merged.RRSCParity = max(merged.RRSCParity, summary.RRSCParity)
+		merged.Buckets += summary.Buckets
+		merged.Objects += summary.Objects
+		merged.Versions += summary.Versions
+		merged.DeleteMarkers += summary.DeleteMarkers
+		merged.UsageBytes += summary.UsageBytes
+		merged.Drives += summary.Drives
+		merged.RawTotalBytes += summary.RawTotalBytes
+		merged.RawUsedBytes += summary.RawUsedBytes
+		merged.Pools = summary.Pools
+	}
+	merged.DeploymentID = strings.Join(deployments, ",")
+	merged.updateCapacity(opts)
+	return merged
+}
+
+// loadInfo reads the info dump from filename, "-" for stdin, in the format of
+// "mc admin info --json" or of a subnet diagnostics capture
+func loadInfo(filename string) (clusterStruct, error) {
+	infoStruct := clusterStruct{}
+	data, err := readInput(filename)
+	if err != nil {
+		return infoStruct, fmt.Errorf("Error on reading the file:%s, err:%v", filename, err)
+	}
+
+	// check raw prefix before unmarshaling
+	data = []byte(strings.Replace(string(data), `{"version":"3"}`, "", 1))
+
+	err = json.Unmarshal(data, &infoStruct)
+	if err != nil {
+		return infoStruct, fmt.Errorf("Error on unmarshal, filename:%s\n, err:%v", filename, err)
+	}
+
+	// if there is no server found on the first try, trying with different format
+	// data could be from subnet diagnostics page
+	if len(infoStruct.Info.Servers) == 0 {
+		anotherFormat := struct {
+			InfoStruct clusterStruct `json:"minio"`
+		}{}
+		err = json.Unmarshal(data, &anotherFormat)
+		if err != nil {
+			fmt.Printf("Error on unmarshal, filename:%s\n, err:%v\n", filename, err)
+		}
+		infoStruct = anotherFormat.InfoStruct
+	}
+	return infoStruct, nil
+}
+
+// serverInfoTimeout bounds the admin info request of --alias
+const serverInfoTimeout = 30 * time.Second
+
+// fetchInfo asks the server behind the mc alias for its info, including the
+// drive metrics, the same data "mc admin info --json" prints
+func fetchInfo(alias string) (clusterStruct, error) {
+	mcConfig, err := readMCConfig(alias)
+	if err != nil {
+		return clusterStruct{}, err
+	}
+	u, err := url.Parse(mcConfig.URL)
+	if err != nil || u.Host == "" {
+		return clusterStruct{}, fmt.Errorf("alias '%s' has an invalid URL %q", alias, mcConfig.URL)
+	}
+
+	client, err := madmin.New(u.Host, mcConfig.AccessKey, mcConfig.SecretKey, u.Scheme == "https")
+	if err != nil {
+		return clusterStruct{}, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), serverInfoTimeout)
+	defer cancel()
+	info, err := client.ServerInfo(ctx, madmin.WithDriveMetrics(true))
+	if err != nil {
+		return clusterStruct{}, err
+	}
+	return clusterStruct{Status: "success", Info: info}, nil
+}
+
+type MCConfig struct {
+	URL       string `json:"url"`
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+	API       string `json:"api"`
+	Path      string `json:"path"`
+}
+
+type MCConfigFile struct {
+	Version string               `json:"version"`
+	Aliases map[string]*MCConfig `json:"aliases"`
+}
+
+// readMCConfig looks up alias in ~/.mc/config.json, like generate-s3-data does
+func readMCConfig(alias string) (*MCConfig, error) {
+	// Get user's home directory
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user home directory: %v", err)
+	}
+
+	// Path to MC config file
+	mcConfigPath := filepath.Join(homeDir, ".mc", "config.json")
+
+	// Check if config file exists
+	if _, err := os.Stat(mcConfigPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("MC config file not found at %s. Run 'mc alias set %s <url> <access-key> <secret-key>' first", mcConfigPath, alias)
+	}
+
+	// Read the config file
+	configData, err := os.ReadFile(mcConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MC config file: %v", err)
+	}
+
+	// Parse JSON
+	var mcConfigFile MCConfigFile
+	if err := json.Unmarshal(configData, &mcConfigFile); err != nil {
+		return nil, fmt.Errorf("failed to parse MC config JSON: %v", err)
+	}
+
+	// Find the alias
+	aliasConfig, exists := mcConfigFile.Aliases[alias]
+	if !exists {
+		return nil, fmt.Errorf("alias '%s' not found in MC config. Available aliases: %v", alias, getAvailableAliases(mcConfigFile.Aliases))
+	}
+
+	// Validate required fields
+	if aliasConfig.URL == "" || aliasConfig.AccessKey == "" || aliasConfig.SecretKey == "" {
+		return nil, fmt.Errorf("alias '%s' has incomplete configuration (missing URL, access key, or secret key)", alias)
+	}
+
+	return aliasConfig, nil
+}
+
+func getAvailableAliases(aliases map[string]*MCConfig) []string {
+	var keys []string
+	for k := range aliases {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stdinIsPiped reports whether stdin is a pipe or file rather than a terminal
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// gzipMagic are the leading bytes of gzip compressed data
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// readInput reads the info dump from filename, or from stdin when it is "-".
+// A gzip compressed dump, a .gz file or data starting with the gzip magic
+// bytes, is decompressed.
+func readInput(filename string) ([]byte, error) {
+	var data []byte
+	var err error
+	if filename == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(data, gzipMagic) && !strings.HasSuffix(filename, ".gz") {
+		return data, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip data: %v", err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// buildReport groups the drives of infoStruct by pool and erasure set and
+// computes the overall summary
+func buildReport(infoStruct clusterStruct, domains []string, opts options) report {
+	// ec set index => endpoint => disk status
+	pools := map[int]map[int]map[string]driveStatus{}
+	servers := []serverDrives{}
+	for _, server := range infoStruct.Info.Servers {
+		endpointName := trimDomainData(server.Endpoint, domains)
+		drives := serverDrives{Endpoint: endpointName, Pool: server.PoolNumber, State: server.State, DriveStatus: map[string]int{}}
+		for _, disk := range server.Disks {
+			drives.DriveStatus[disk.State]++
+
+			// update endpoint name with drive path
+			endpointNameWithDrive := fmt.Sprintf("%s:%s", endpointName, disk.DrivePath)
+			if disk.DrivePath == "" {
+				u, err := url.Parse(disk.Endpoint)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error parsing disk endpoint[%s]: %v\n", disk.Endpoint, err)
+				} else {
+					endpointNameWithDrive = fmt.Sprintf("%s:%s", endpointName, u.Path)
+				}
+			}
+
+			ds := driveStatus{
+				Endpoint:   endpointNameWithDrive,
+				SetIndex:   disk.SetIndex,
+				Path:       disk.DrivePath,
+				DriveIndex: disk.DiskIndex,
+				UsedSpace:  disk.UsedSpace,
+				TotalSpace: disk.TotalSpace,
+				UsedInodes: disk.UsedInodes,
+				FreeInodes: disk.FreeInodes,
+				Status:     disk.State,
+				Metrics:    disk.Metrics,
+			}
+			poolIndex := disk.PoolIndex
+			setIndex := disk.SetIndex
+
+			ecStatus, ok := pools[poolIndex]
+			if !ok {
+				ecStatus = make(map[int]map[string]driveStatus)
+			}
+
+			diskStatus, ok := ecStatus[setIndex]
+			if !ok {
+				diskStatus = map[string]driveStatus{}
+			}
+			diskStatus[endpointNameWithDrive] = ds
+			ecStatus[setIndex] = diskStatus
+
+			pools[poolIndex] = ecStatus
+		}
+		servers = append(servers, drives)
+	}
+	slices.SortStableFunc(servers, func(a, b serverDrives) int {
+		switch {
+		case sortorder.NaturalLess(a.Endpoint, b.Endpoint):
+			return -1
+		case sortorder.NaturalLess(b.Endpoint, a.Endpoint):
+			return 1
+		}
+		return 0
+	})
+
+	// every pool and set of the backend layout, even the ones none of
+	// the drives reported for
+	backend := infoStruct.Info.Backend
+	for poolIndex, totalSets := range backend.TotalSets {
+		if _, ok := pools[poolIndex]; !ok {
+			pools[poolIndex] = map[int]map[string]driveStatus{}
+		}
+		for setIndex := 0; setIndex < totalSets; setIndex++ {
+			if _, ok := pools[poolIndex][setIndex]; !ok {
+				pools[poolIndex][setIndex] = map[string]driveStatus{}
+			}
+		}
+	}
+
+	poolIndices := []int{}
+	for poolIndex := range pools {
+		poolIndices = append(poolIndices, poolIndex)
+	}
+	sort.Ints(poolIndices)
+
+	r := report{ServerDrives: servers, Summary: buildSummary(infoStruct, opts)}
+	r.Summary.VersionSkew = findVersionSkew(infoStruct, domains)
+	for _, poolIndex := range poolIndices {
+		ecStatus := pools[poolIndex]
+		pool := poolReport{Pool: poolIndex + 1, DriveStatus: map[string]int{}}
+
+		for _, server := range infoStruct.Info.Servers {
+			if server.PoolNumber != poolIndex+1 {
+				continue
+			}
+			pool.Servers = append(pool.Servers, serverReport{
+				Endpoint:            trimDomainData(server.Endpoint, domains),
+				State:               server.State,
+				Edition:             server.Edition,
+				Version:             server.Version,
+				CommitID:            server.CommitID,
+				MemStatsAlloc:       server.MemStats.Alloc,
+				ILMExpiryInProgress: server.ILMExpiryInProgress,
+				UptimeSeconds:       server.Uptime,
+			})
+		}
+		// sort server names
+		slices.SortStableFunc(pool.Servers, func(a, b serverReport) int { return strings.Compare(a.Endpoint, b.Endpoint) })
+
+		setIndices := []int{}
+		for setIndex := range ecStatus {
+			setIndices = append(setIndices, setIndex)
+		}
+		sort.Ints(setIndices)
+
+		for _, setIndex := range setIndices {
+			diskStatus := ecStatus[setIndex]
+			endpoints := []string{}
+			for endpoint := range diskStatus {
+				endpoints = append(endpoints, endpoint)
+			}
+			sort.Sort(sortorder.Natural(endpoints))
+
+			set := setReport{Set: setIndex + 1}
+			for _, endpoint := range endpoints {
+				disk := diskStatus[endpoint]
+				set.Drives = append(set.Drives, disk)
+				pool.DriveStatus[disk.Status]++
+			}
+			sortDrives(set.Drives, opts.sortBy)
+			set.Imbalance = driveImbalance(set.Drives, opts.imbalanceThreshold)
+			if poolIndex < len(backend.DrivesPerSet) && backend.DrivesPerSet[poolIndex] > len(set.Drives) {
+				set.Missing = backend.DrivesPerSet[poolIndex] - len(set.Drives)
+				pool.MissingDrives += set.Missing
+			}
+			set.Tolerance = buildSetTolerance(set, backend, poolIndex)
+			pool.Sets = append(pool.Sets, set)
+		}
+		pool.Capacity = buildPoolCapacity(pool, backend, poolIndex)
+		r.Pools = append(r.Pools, pool)
+	}
+	return r
+}
+
+// sortDrives orders the drives of a set, already in natural endpoint order,
+// by the used space or inode percentage, the fullest first. Drives that
+// didn't report their usage go last, ties keep the endpoint order
+func sortDrives(drives []driveStatus, sortBy string) {
+	if sortBy == sortEndpoint {
 		return
 	}
+	usage := func(disk driveStatus) float64 {
+		percent, ok := driveUsage(disk)
+		if sortBy == sortInode {
+			percent, ok = driveInodes(disk)
+		}
+		if !ok {
+			return -1
+		}
+		return percent
+	}
+	slices.SortStableFunc(drives, func(a, b driveStatus) int {
+		return cmp.Compare(usage(b), usage(a))
+	})
+}
+
+// sortPools orders the pools, already in pool order, by their raw capacity or
+// raw used percentage, the largest first. Ties keep the pool order
+func sortPools(pools []poolReport, sortBy string) {
+	if sortBy == sortPoolIndex {
+		return
+	}
+	metric := func(pool poolReport) float64 {
+		if sortBy == sortPoolCapacity {
+			return float64(pool.Capacity.RawTotalBytes)
+		}
+		if pool.Capacity.RawTotalBytes == 0 {
+			return 0
+		}
+		return float64(pool.Capacity.RawUsedBytes) / float64(pool.Capacity.RawTotalBytes)
+	}
+	slices.SortStableFunc(pools, func(a, b poolReport) int {
+		return cmp.Compare(metric(b), metric(a))
+	})
+}
+
+// sortSets orders the sets of a pool, already in set order, by their count of
+// drives that are not ok or missing, the worst first. Ties keep the set order
+func sortSets(sets []setReport, sortBy string) {
+	if sortBy == sortSetIndex {
+		return
+	}
+	unhealthy := func(set setReport) int {
+		count := set.Missing
+		for _, disk := range set.Drives {
+			if !healthyStatus(disk.Status) {
+				count++
+			}
+		}
+		return count
+	}
+	slices.SortStableFunc(sets, func(a, b setReport) int {
+		return cmp.Compare(unhealthy(b), unhealthy(a))
+	})
+}
+
+// driveImbalance compares the used percentage of the drives of a set, nil when
+// the spread between the fullest and the emptiest drive is within threshold.
+// Drives that didn't report their usage are left out.
+func driveImbalance(drives []driveStatus, threshold float64) *setImbalance {
+	if threshold <= 0 {
+		return nil
+	}
+	used := []driveUsed{}
+	for _, disk := range drives {
+		if usedPercent, ok := driveUsage(disk); ok {
+			used = append(used, driveUsed{Endpoint: disk.Endpoint, UsedPercent: usedPercent})
+		}
+	}
+	if len(used) < 2 {
+		return nil
+	}
+
+	percents := []float64{}
+	for _, drive := range used {
+		percents = append(percents, drive.UsedPercent)
+	}
+	sort.Float64s(percents)
+	spread := percents[len(percents)-1] - percents[0]
+	if spread <= threshold {
+		return nil
+	}
+	median := percents[len(percents)/2]
+	if len(percents)%2 == 0 {
+		median = (percents[len(percents)/2-1] + median) / 2
+	}
+
+	// with a spread above threshold the fullest or the emptiest drive is
+	// always more than half of it away from the median
+	imbalance := &setImbalance{SpreadPercent: spread}
+	for _, drive := range used {
+		if math.Abs(drive.UsedPercent-median) > threshold/2 {
+			imbalance.Drives = append(imbalance.Drives, drive)
+		}
+	}
+	slices.SortStableFunc(imbalance.Drives, func(a, b driveUsed) int {
+		return cmp.Compare(math.Abs(b.UsedPercent-median), math.Abs(a.UsedPercent-median))
+	})
+	return imbalance
+}
+
+// buildSetTolerance counts the online drives of a set against its read quorum,
+// the data drives, and its write quorum, one more when data and parity are
+// even so that two halves can't both be written
+func buildSetTolerance(set setReport, backend madmin.ErasureBackend, poolIndex int) *setTolerance {
+	if poolIndex >= len(backend.DrivesPerSet) {
+		return nil
+	}
+	drives, parity := backend.DrivesPerSet[poolIndex], backend.StandardSCParity
+	if drives <= 0 || parity < 0 || parity >= drives {
+		return nil
+	}
+	tolerance := &setTolerance{Drives: drives, Parity: parity, ReadQuorum: drives - parity, WriteQuorum: drives - parity}
+	if tolerance.WriteQuorum == parity {
+		tolerance.WriteQuorum++
+	}
+	for _, disk := range set.Drives {
+		if healthyStatus(disk.Status) {
+			tolerance.OnlineDrives++
+		}
+	}
+	tolerance.Failures = tolerance.OnlineDrives - tolerance.WriteQuorum
+	return tolerance
+}
+
+// toleranceLine formats the failure tolerance of a set, as a warning at zero
+// margin or once quorum is lost
+func toleranceLine(tolerance *setTolerance) string {
+	layout := fmt.Sprintf("online=%d/%d, parity=%d, write_quorum=%d", tolerance.OnlineDrives, tolerance.Drives, tolerance.Parity, tolerance.WriteQuorum)
+	switch {
+	case tolerance.OnlineDrives < tolerance.ReadQuorum:
+		return fmt.Sprintf("warning: read and write quorum lost, %d drive(s) short of read quorum (%s)", tolerance.ReadQuorum-tolerance.OnlineDrives, layout)
+	case tolerance.Failures < 0:
+		return fmt.Sprintf("warning: write quorum lost, %d drive(s) short, read quorum holds (%s)", -tolerance.Failures, layout)
+	case tolerance.Failures == 0:
+		return fmt.Sprintf("warning: zero margin, the next drive failure loses write quorum (%s)", layout)
+	}
+	return fmt.Sprintf("tolerates %d more drive failure(s) (%s)", tolerance.Failures, layout)
+}
+
+// atRisk reports whether the set is at zero margin or has lost quorum
+func atRisk(tolerance *setTolerance) bool {
+	return tolerance != nil && tolerance.Failures <= 0
+}
+
+// missingWarning formats the count of drives of a set absent from the info
+func missingWarning(missing int) string {
+	return fmt.Sprintf("warning: %d drive(s) missing from the info, they didn't report at all", missing)
+}
+
+// imbalanceWarning formats the imbalance of a set with the drives causing it
+func imbalanceWarning(imbalance *setImbalance) string {
+	drives := []string{}
+	for _, drive := range imbalance.Drives {
+		drives = append(drives, fmt.Sprintf("%s=%.0f%%", drive.Endpoint, drive.UsedPercent))
+	}
+	return fmt.Sprintf("warning: drive fill imbalance, used spread=%.0f%%: %s", imbalance.SpreadPercent, strings.Join(drives, ", "))
+}
+
+// findVersionSkew compares the version and commit of every server with the
+// ones most servers run, the newer version winning a tie. Servers that don't
+// report a version, like offline ones, are left out.
+func findVersionSkew(infoStruct clusterStruct, domains []string) *versionSkew {
+	counts := map[serverVersion]int{}
+	for _, server := range infoStruct.Info.Servers {
+		if server.Version != "" {
+			counts[serverVersion{Version: server.Version, CommitID: server.CommitID}]++
+		}
+	}
+	if len(counts) < 2 {
+		return nil
+	}
+
+	majority, majorityCount := serverVersion{}, 0
+	for version, count := range counts {
+		if count > majorityCount || (count == majorityCount && version.Version > majority.Version) ||
+			(count == majorityCount && version.Version == majority.Version && version.CommitID > majority.CommitID) {
+			majority, majorityCount = version, count
+		}
+	}
+
+	skew := &versionSkew{Version: majority.Version, CommitID: majority.CommitID}
+	for _, server := range infoStruct.Info.Servers {
+		if server.Version == "" || (server.Version == majority.Version && server.CommitID == majority.CommitID) {
+			continue
+		}
+		skew.Servers = append(skew.Servers, serverVersion{
+			Endpoint: trimDomainData(server.Endpoint, domains),
+			Version:  server.Version,
+			CommitID: server.CommitID,
+		})
+	}
+	slices.SortStableFunc(skew.Servers, func(a, b serverVersion) int { return strings.Compare(a.Endpoint, b.Endpoint) })
+	return skew
+}
+
+// versionSkewWarning formats the servers off the majority version
+func versionSkewWarning(skew *versionSkew) string {
+	servers := []string{}
+	for _, server := range skew.Servers {
+		servers = append(servers, fmt.Sprintf("%s (version=%s, commit_id=%s)", server.Endpoint, server.Version, server.CommitID))
+	}
+	return fmt.Sprintf("warning: version skew, most servers run version=%s, commit_id=%s, except: %s",
+		skew.Version, skew.CommitID, strings.Join(servers, ", "))
+}
+
+// buildPoolCapacity sums the raw capacity of the drives of pool and derives
+// the usable capacity from the drives per set of the pool and the standard
+// storage class parity, usable = raw * data / (data + parity)
+func buildPoolCapacity(pool poolReport, backend madmin.ErasureBackend, poolIndex int) poolCapacity {
+	capacity := poolCapacity{}
+	for _, set := range pool.Sets {
+		for _, disk := range set.Drives {
+			capacity.RawTotalBytes += disk.TotalSpace
+			capacity.RawUsedBytes += disk.UsedSpace
+		}
+	}
+	capacity.RawFreeBytes = capacity.RawTotalBytes - capacity.RawUsedBytes
+
+	if poolIndex >= len(backend.DrivesPerSet) {
+		return capacity
+	}
+	drivesPerSet, parity := backend.DrivesPerSet[poolIndex], backend.StandardSCParity
+	if drivesPerSet <= 0 || parity < 0 || parity >= drivesPerSet {
+		return capacity
+	}
+	capacity.DataDrives, capacity.ParityDrives = drivesPerSet-parity, parity
+	ratio := float64(capacity.DataDrives) / float64(drivesPerSet)
+	capacity.UsableTotalBytes = uint64(float64(capacity.RawTotalBytes) * ratio)
+	capacity.UsableFreeBytes = uint64(float64(capacity.RawFreeBytes) * ratio)
+	return capacity
+}
+
+// buildSummary computes the cluster wide counts and raw capacity
+func buildSummary(infoStruct clusterStruct, opts options) summaryReport {
+	info := infoStruct.Info
+	summary := summaryReport{
+		DeploymentID:      info.DeploymentID,
+		TotalSets:         info.Backend.TotalSets,
+		StandardSCParity:  info.Backend.StandardSCParity,
+		RRSCParity:        info.Backend.RRSCParity,
+		DrivesPerSet:      info.Backend.DrivesPerSet,
+		Buckets:           info.Buckets.Count,
+		Objects:           info.Objects.Count,
+		Versions:          info.Versions.Count,
+		DeleteMarkers:     info.DeleteMarkers.Count,
+		UsageBytes:        info.Usage.Size,
+		FullThreshold:     opts.fullThreshold,
+		GrowthBytesPerDay: opts.growthBytesPerDay,
+	}
+
+	// disk raw details
+	for _, server := range info.Servers {
+		for _, disk := range server.Disks {
+			summary.RawTotalBytes += disk.TotalSpace
+			summary.RawUsedBytes += disk.UsedSpace
+			summary.Drives++
+		}
+	}
+	summary.updateCapacity(opts)
+	return summary
+}
+
+// updateCapacity derives the free space, used percentage and time to full
+// from the raw totals
+func (summary *summaryReport) updateCapacity(opts options) {
+	summary.RawFreeBytes = summary.RawTotalBytes - summary.RawUsedBytes
+	summary.UsedPercent = 0
+	summary.DaysToFull = nil
+
+	if summary.RawTotalBytes > 0 {
+		summary.UsedPercent = float64(summary.RawUsedBytes) / float64(summary.RawTotalBytes) * 100.0
+		thresholdBytes := float64(summary.RawTotalBytes) * opts.fullThreshold / 100.0
+		switch {
+		case float64(summary.RawUsedBytes) >= thresholdBytes:
+			days := 0.0
+			summary.DaysToFull = &days
+		case opts.growthBytesPerDay > 0:
+			days := (thresholdBytes - float64(summary.RawUsedBytes)) / float64(opts.growthBytesPerDay)
+			summary.DaysToFull = &days
+		}
+	}
+}
+
+// filterPools keeps the pools selected with --pool in r. With the filtered
+// summary scope the set layout and raw capacity of the summary are
+// recomputed for them.
+func filterPools(r report, infoStruct clusterStruct, opts options) (report, error) {
+	available := map[int]poolReport{}
+	for _, pool := range r.Pools {
+		available[pool.Pool] = pool
+	}
+	for _, number := range opts.pools {
+		if _, ok := available[number]; !ok {
+			if len(r.Pools) == 0 {
+				return r, fmt.Errorf("pool %d not found, the info has no pools", number)
+			}
+			return r, fmt.Errorf("pool %d not found, available pools: %d-%d", number, r.Pools[0].Pool, r.Pools[len(r.Pools)-1].Pool)
+		}
+	}
+
+	filtered := []poolReport{}
+	for _, pool := range r.Pools {
+		if slices.Contains(opts.pools, pool.Pool) {
+			filtered = append(filtered, pool)
+		}
+	}
+	r.Pools = filtered
+	r.ServerDrives = slices.DeleteFunc(r.ServerDrives, func(server serverDrives) bool { return !slices.Contains(opts.pools, server.Pool) })
+	if opts.summaryScope != scopeFiltered {
+		return r, nil
+	}
+
+	summary := r.Summary
+	summary.TotalSets, summary.DrivesPerSet = nil, nil
+	summary.RawTotalBytes, summary.RawUsedBytes, summary.Drives = 0, 0, 0
+	backend := infoStruct.Info.Backend
+	for _, pool := range r.Pools {
+		summary.Pools = append(summary.Pools, pool.Pool)
+		if pool.Pool <= len(backend.TotalSets) {
+			summary.TotalSets = append(summary.TotalSets, backend.TotalSets[pool.Pool-1])
+		}
+		if pool.Pool <= len(backend.DrivesPerSet) {
+			summary.DrivesPerSet = append(summary.DrivesPerSet, backend.DrivesPerSet[pool.Pool-1])
+		}
+		for _, set := range pool.Sets {
+			for _, disk := range set.Drives {
+				summary.RawTotalBytes += disk.TotalSpace
+				summary.RawUsedBytes += disk.UsedSpace
+				summary.Drives++
+			}
+		}
+	}
+	summary.updateCapacity(opts)
+	r.Summary = summary
+	return r, nil
+}
+
+// diffUsageMinPercent is the change of the used percentage of a drive below
+// which --diff doesn't report it
+const diffUsageMinPercent = 1.0
+
+// diffReport lists what changed between two info dumps
+type diffReport struct {
+	Servers []serverChange `json:"servers"`
+	Drives  []driveChange  `json:"drives"`
+	// RawUsedDeltaBytes is the change of the raw used capacity of the drives
+	RawUsedDeltaBytes int64 `json:"rawUsedDeltaBytes"`
+	// IntervalSeconds is the time between the dumps, from --diff-interval or
+	// the file modification times, 0 when unknown
+	IntervalSeconds float64 `json:"intervalSeconds,omitempty"`
+	IntervalSource  string  `json:"intervalSource,omitempty"`
+	// GrowthBytesPerDay is the raw used growth per day between the dumps,
+	// negative when it shrank, nil without an interval
+	GrowthBytesPerDay *int64 `json:"growthBytesPerDay,omitempty"`
+	// UsedPercent, FullThreshold and DaysToFull are the capacity of the
+	// input, the days to full estimated at GrowthBytesPerDay
+	UsedPercent   float64  `json:"usedPercent,omitempty"`
+	FullThreshold float64  `json:"fullThreshold,omitempty"`
+	DaysToFull    *float64 `json:"daysToFull,omitempty"`
+}
+
+// serverChange is a server whose state changed, Before or After is empty
+// when the server is missing from that dump
+type serverChange struct {
+	Endpoint string `json:"endpoint"`
+	Before   string `json:"before"`
+	After    string `json:"after"`
+}
+
+// driveChange is a drive whose status or used space changed, Before or After
+// is empty when the drive is missing from that dump. The used space is only
+// compared when the drive reported it in both dumps, an offline drive doesn't.
+type driveChange struct {
+	Endpoint          string  `json:"endpoint"`
+	Pool              int     `json:"pool"`
+	Set               int     `json:"set"`
+	Before            string  `json:"before"`
+	After             string  `json:"after"`
+	UsedPercentBefore float64 `json:"usedPercentBefore"`
+	UsedPercentAfter  float64 `json:"usedPercentAfter"`
+	UsedDeltaBytes    int64   `json:"usedDeltaBytes"`
+}
+
+// diffReports compares the servers and drives of two reports, keyed by their
+// endpoint, in the order of after followed by the ones that disappeared
+func diffReports(before, after report) diffReport {
+	changes := diffReport{Servers: []serverChange{}, Drives: []driveChange{}}
+
+	type located struct {
+		pool, set int
+		disk      driveStatus
+	}
+	drivesOf := func(r report) ([]located, map[string]located) {
+		list, byEndpoint := []located{}, map[string]located{}
+		for _, pool := range r.Pools {
+			for _, set := range pool.Sets {
+				for _, disk := range set.Drives {
+					drive := located{pool: pool.Pool, set: set.Set, disk: disk}
+					list = append(list, drive)
+					byEndpoint[disk.Endpoint] = drive
+				}
+			}
+		}
+		return list, byEndpoint
+	}
+	serversOf := func(r report) ([]serverReport, map[string]serverReport) {
+		list, byEndpoint := []serverReport{}, map[string]serverReport{}
+		for _, pool := range r.Pools {
+			for _, server := range pool.Servers {
+				list = append(list, server)
+				byEndpoint[server.Endpoint] = server
+			}
+		}
+		return list, byEndpoint
+	}
+
+	beforeServers, beforeServer := serversOf(before)
+	afterServers, afterServer := serversOf(after)
+	for _, server := range afterServers {
+		previous, ok := beforeServer[server.Endpoint]
+		if !ok || previous.State != server.State {
+			changes.Servers = append(changes.Servers, serverChange{Endpoint: server.Endpoint, Before: previous.State, After: server.State})
+		}
+	}
+	for _, server := range beforeServers {
+		if _, ok := afterServer[server.Endpoint]; !ok {
+			changes.Servers = append(changes.Servers, serverChange{Endpoint: server.Endpoint, Before: server.State})
+		}
+	}
+
+	beforeDrives, beforeDrive := drivesOf(before)
+	afterDrives, afterDrive := drivesOf(after)
+	for _, drive := range afterDrives {
+		disk := drive.disk
+		change := driveChange{Endpoint: disk.Endpoint, Pool: drive.pool, Set: drive.set, After: disk.Status}
+		usedAfter, reportedAfter := driveUsage(disk)
+		change.UsedPercentAfter = usedAfter
+
+		previous, ok := beforeDrive[disk.Endpoint]
+		if !ok {
+			change.UsedDeltaBytes = int64(disk.UsedSpace)
+			changes.RawUsedDeltaBytes += change.UsedDeltaBytes
+			changes.Drives = append(changes.Drives, change)
+			continue
+		}
+		change.Before = previous.disk.Status
+		usedBefore, reportedBefore := driveUsage(previous.disk)
+		change.UsedPercentBefore = usedBefore
+		if reportedBefore && reportedAfter {
+			change.UsedDeltaBytes = int64(disk.UsedSpace) - int64(previous.disk.UsedSpace)
+			changes.RawUsedDeltaBytes += change.UsedDeltaBytes
+		}
+		if change.Before != change.After || usageChanged(change) {
+			changes.Drives = append(changes.Drives, change)
+		}
+	}
+	for _, drive := range beforeDrives {
+		disk := drive.disk
+		if _, ok := afterDrive[disk.Endpoint]; !ok {
+			usedBefore, _ := driveUsage(disk)
+			changes.RawUsedDeltaBytes -= int64(disk.UsedSpace)
+			changes.Drives = append(changes.Drives, driveChange{Endpoint: disk.Endpoint, Pool: drive.pool, Set: drive.set, Before: disk.Status,
+				UsedPercentBefore: usedBefore, UsedDeltaBytes: -int64(disk.UsedSpace)})
+		}
+	}
+	return changes
+}
 
-	domainString := ""
-	if len(os.Args) >= 3 {
-		domainString = strings.TrimSpace(os.Args[2])
+// dumpInterval returns the time between the --diff dump and the input and
+// where it comes from: --diff-interval, else the modification times of the
+// files, the input fetched with --alias being taken now. It is 0 when unknown,
+// e.g. for stdin.
+func dumpInterval(inputs []string, opts options) (time.Duration, string) {
+	if opts.diffInterval > 0 {
+		return opts.diffInterval, "--diff-interval"
 	}
+	modTime := func(filename string) time.Time {
+		if filename == "-" {
+			return time.Time{}
+		}
+		info, err := os.Stat(filename)
+		if err != nil {
+			return time.Time{}
+		}
+		return info.ModTime()
+	}
+	before, after := modTime(opts.diff), time.Now()
+	if opts.alias == "" {
+		after = modTime(inputs[0])
+	}
+	if before.IsZero() || after.IsZero() || !after.After(before) {
+		return 0, ""
+	}
+	return after.Sub(before), "file modification time"
+}
 
-	filename := os.Args[1]
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		fmt.Printf("Error on reading the file:%s, err:%v\n", filename, err)
+// diffGrowth derives the raw growth per day from the raw used capacity of
+// the dumps taken interval apart, and the days until the input reaches
+// --full-threshold at that rate
+func diffGrowth(changes *diffReport, before, after report, interval time.Duration, source string, opts options) {
+	if interval <= 0 {
 		return
 	}
+	delta := changes.RawUsedDeltaBytes
+	if len(opts.pools) > 0 && opts.summaryScope != scopeFiltered {
+		// the summary covers the whole cluster, not only the drives of the
+		// --pool selection the changes compare
+		delta = int64(after.Summary.RawUsedBytes) - int64(before.Summary.RawUsedBytes)
+	}
+	growth := int64(float64(delta) / interval.Hours() * 24)
+	changes.IntervalSeconds = interval.Seconds()
+	changes.IntervalSource = source
+	changes.GrowthBytesPerDay = &growth
 
-	_driveStatus := map[int]map[string]int{}
+	summary := after.Summary
+	growthOpts := opts
+	growthOpts.growthBytesPerDay = uint64(max(growth, 0))
+	summary.updateCapacity(growthOpts)
+	changes.UsedPercent, changes.FullThreshold, changes.DaysToFull = summary.UsedPercent, summary.FullThreshold, summary.DaysToFull
+}
 
-	// check raw prefix before unmarshaling
-	data = []byte(strings.Replace(string(data), `{"version":"3"}`, "", 1))
+// growthLine describes the growth rate of diffGrowth and the time to full
+func growthLine(changes diffReport) string {
+	if changes.GrowthBytesPerDay == nil {
+		return "growth: N/A (the time between the dumps is unknown, see --diff-interval)"
+	}
+	line := fmt.Sprintf("growth: %s/day over %.1f days (%s), used=%.1f%%, full_threshold=%.0f%%",
+		signedBytes(*changes.GrowthBytesPerDay), changes.IntervalSeconds/86400, changes.IntervalSource, changes.UsedPercent, changes.FullThreshold)
+	switch {
+	case changes.DaysToFull == nil && *changes.GrowthBytesPerDay <= 0:
+		line += ", eta_to_full=N/A (not growing)"
+	case changes.DaysToFull == nil:
+		line += ", eta_to_full=N/A"
+	case *changes.DaysToFull == 0:
+		line += ", eta_to_full=already reached"
+	default:
+		line += fmt.Sprintf(", eta_to_full=~%.1f days", *changes.DaysToFull)
+	}
+	return line
+}
 
-	infoStruct := clusterStruct{}
-	err = json.Unmarshal(data, &infoStruct)
-	if err != nil {
-		fmt.Printf("Error on unmarshal, filename:%s\n, err:%v\n", filename, err)
-		return
+// usageChanged reports whether the used percentage of a drive present in both
+// dumps moved by at least diffUsageMinPercent. Drives that didn't report their
+// usage in one of the dumps have no delta.
+func usageChanged(change driveChange) bool {
+	if change.UsedDeltaBytes == 0 {
+		return false
 	}
+	return math.Abs(change.UsedPercentAfter-change.UsedPercentBefore) >= diffUsageMinPercent
+}
 
-	// if there is no server found on the first try, trying with different format
-	// data could be from subnet diagnostics page
-	if len(infoStruct.Info.Servers) == 0 {
-		anotherFormat := struct {
-			InfoStruct clusterStruct `json:"minio"`
-		}{}
-		err = json.Unmarshal(data, &anotherFormat)
-		if err != nil {
-			fmt.Printf("Error on unmarshal, filename:%s\n, err:%v\n", filename, err)
-		}
-		infoStruct = anotherFormat.InfoStruct
+// signedBytes formats a byte delta with its sign
+func signedBytes(delta int64) string {
+	if delta < 0 {
+		return "-" + humanize.IBytes(uint64(-delta))
 	}
+	return "+" + humanize.IBytes(uint64(delta))
+}
 
-	// ec set index => endpoint => disk status
-	pools := map[int]map[int]map[string]driveStatus{}
-	for _, server := range infoStruct.Info.Servers {
-		endpointName := trimDomainData(server.Endpoint, domainString)
-		for _, disk := range server.Disks {
-			ds := driveStatus{
-				SetIndex:   disk.SetIndex,
-				Path:       disk.DrivePath,
-				DriveIndex: disk.DiskIndex,
-				UsedSpace:  disk.UsedSpace,
-				TotalSpace: disk.TotalSpace,
-				UsedInodes: disk.UsedInodes,
-				FreeInodes: disk.FreeInodes,
-				Status:     disk.State,
-				Metrics:    disk.Metrics,
+// printDiff prints the changes since the dump in filename
+func printDiff(filename string, changes diffReport) {
+	fmt.Printf("Changes since %s:\n", filename)
+	if len(changes.Servers) == 0 && len(changes.Drives) == 0 {
+		fmt.Println("no changes")
+	}
+	if len(changes.Servers) > 0 {
+		fmt.Println()
+		fmt.Println("Servers:")
+		for _, server := range changes.Servers {
+			switch {
+			case server.Before == "":
+				fmt.Printf("%s: appeared (%s)\n", server.Endpoint, server.After)
+			case server.After == "":
+				fmt.Printf("%s: disappeared (was %s)\n", server.Endpoint, server.Before)
+			default:
+				fmt.Printf("%s: %s -> %s\n", server.Endpoint, server.Before, server.After)
 			}
-
-			// update endpoint name with drive path
-			endpointNameWithDrive := fmt.Sprintf("%s:%s", endpointName, disk.DrivePath)
-			if disk.DrivePath == "" {
-				u, err := url.Parse(disk.Endpoint)
-				if err != nil {
-					fmt.Printf("Error parsing disk endpoint[%s]: %v\n", disk.Endpoint, err)
-				} else {
-					endpointNameWithDrive = fmt.Sprintf("%s:%s", endpointName, u.Path)
-				}
+		}
+	}
+	if len(changes.Drives) > 0 {
+		fmt.Println()
+		fmt.Println("Drives:")
+		for _, drive := range changes.Drives {
+			location := fmt.Sprintf("Pool=%d, ES=%d, %s", drive.Pool, drive.Set, drive.Endpoint)
+			switch {
+			case drive.Before == "":
+				fmt.Printf("%s: appeared (%s)\n", location, drive.After)
+				continue
+			case drive.After == "":
+				fmt.Printf("%s: disappeared (was %s)\n", location, drive.Before)
+				continue
 			}
-			poolIndex := disk.PoolIndex
-			setIndex := disk.SetIndex
-
-			ecStatus, ok := pools[poolIndex]
-
-			if !ok {
-				// pools = append(pools, make(map[int]map[string]driveStatus))
-				ecStatus = make(map[int]map[string]driveStatus)
+			parts := []string{}
+			if drive.Before != drive.After {
+				parts = append(parts, fmt.Sprintf("%s -> %s", drive.Before, drive.After))
 			}
-
-			// fmt.Println("pool index:", poolIndex)
-			// ecStatus := pools[poolIndex]
-
-			diskStatus, ok := ecStatus[setIndex]
-			if !ok {
-				diskStatus = map[string]driveStatus{}
+			if usageChanged(drive) {
+				parts = append(parts, fmt.Sprintf("used %.0f%% -> %.0f%% (%s)", drive.UsedPercentBefore, drive.UsedPercentAfter, signedBytes(drive.UsedDeltaBytes)))
 			}
-			diskStatus[endpointNameWithDrive] = ds
-			ecStatus[setIndex] = diskStatus
-
-			pools[poolIndex] = ecStatus
+			fmt.Printf("%s: %s\n", location, strings.Join(parts, ", "))
 		}
 	}
+	fmt.Println()
+	fmt.Printf("raw_used: %s\n", signedBytes(changes.RawUsedDeltaBytes))
+	fmt.Println(growthLine(changes))
+}
 
-	for poolIndex, ecStatus := range pools {
-		// print server information
-		fmt.Printf("\nPool=%d, Servers\n", poolIndex+1)
-		serverNames := []string{}
-		serversData := map[string]madmin.ServerProperties{}
-		for _, server := range infoStruct.Info.Servers {
-			endpointName := trimDomainData(server.Endpoint, domainString)
-			serverNames = append(serverNames, endpointName)
-			serversData[endpointName] = server
-		}
-
-		// sort server names
-		slices.Sort(serverNames)
+// healthyStatus reports whether a drive or server state needs no attention
+func healthyStatus(status string) bool {
+	return strings.EqualFold(status, "ok") || strings.EqualFold(status, "online")
+}
 
-		for _, serverName := range serverNames {
-			server, found := serversData[serverName]
-			if !found {
-				fmt.Printf("%s: (data_not_available)\n", serverName)
-				continue
+// filterUnhealthy drops the online servers, the ok drives and the sets left
+// without drives, missing ones or quorum risk from r, and the online servers whose drives are all ok from the server
+// drive counts. The drive status counts still cover every drive.
+func filterUnhealthy(r report) report {
+	pools := []poolReport{}
+	for _, pool := range r.Pools {
+		servers := []serverReport{}
+		for _, server := range pool.Servers {
+			if !healthyStatus(server.State) {
+				servers = append(servers, server)
 			}
-			if server.PoolNumber == poolIndex+1 {
-				fmt.Printf("%s: (%s)\n", serverName, server.State)
-				if server.State == "offline" {
-					fmt.Println()
-					continue
+		}
+		pool.Servers = servers
+
+		sets := []setReport{}
+		for _, set := range pool.Sets {
+			drives := []driveStatus{}
+			for _, disk := range set.Drives {
+				if !healthyStatus(disk.Status) {
+					drives = append(drives, disk)
 				}
-				fmt.Printf("edition=%s, version=%s, commit_id=%s\n", server.Edition, server.Version, server.CommitID)
-				fmt.Printf("mem_stats_alloc=%s, ilm_expiry_in_progress=%v, uptime=%s\n", humanize.IBytes(server.MemStats.Alloc), server.ILMExpiryInProgress, humanizeDuration(time.Duration(server.Uptime)*time.Second))
-				fmt.Println()
+			}
+			if len(drives) > 0 || set.Missing > 0 || atRisk(set.Tolerance) {
+				set.Drives = drives
+				sets = append(sets, set)
+			}
+		}
+		pool.Sets = sets
+		pools = append(pools, pool)
+	}
+	r.Pools = pools
+	r.ServerDrives = slices.DeleteFunc(r.ServerDrives, func(server serverDrives) bool {
+		if !healthyStatus(server.State) {
+			return false
+		}
+		for status := range server.DriveStatus {
+			if !healthyStatus(status) {
+				return false
 			}
 		}
+		return true
+	})
+	return r
+}
 
-		// print state
-		setIndices := []int{}
-		for setIndex := range ecStatus {
-			setIndices = append(setIndices, setIndex)
+// driveMetrics lists the non-zero drive metrics as key=value pairs, empty
+// without metrics
+func driveMetrics(metrics *madmin.DiskMetrics) string {
+	if metrics == nil {
+		return ""
+	}
+	metricBuilder := strings.Builder{}
+	builderFn := func(key string, value uint64) {
+		if value == 0 {
+			return
 		}
-		sort.Ints(setIndices)
+		if metricBuilder.Len() > 0 {
+			metricBuilder.WriteString(", ")
+		}
+		metricBuilder.WriteString(fmt.Sprintf("%s=%d", key, value))
+	}
+	builderFn("tokens", uint64(metrics.TotalTokens))
+	builderFn("write", metrics.TotalWrites)
+	builderFn("del", metrics.TotalDeletes)
+	builderFn("waiting", uint64(metrics.TotalWaiting))
+	builderFn("tout", metrics.TotalErrorsTimeout)
+	if metrics.TotalErrorsTimeout != metrics.TotalErrorsAvailability {
+		builderFn("err", metrics.TotalErrorsAvailability)
+	}
 
-		for _, setIndex := range setIndices {
-			diskStatus := ecStatus[setIndex]
-			fmt.Printf("\nPool=%d, ES=%d\n", poolIndex+1, setIndex+1)
-			endpoints := []string{}
+	// latency of all the drive operations of the last minute
+	lastMinute := madmin.TimedAction{}
+	for _, action := range metrics.LastMinute {
+		lastMinute.Count += action.Count
+		lastMinute.AccTime += action.AccTime
+		lastMinute.MaxTime = max(lastMinute.MaxTime, action.MaxTime)
+	}
+	if lastMinute.Count > 0 && lastMinute.AccTime > 0 {
+		if metricBuilder.Len() > 0 {
+			metricBuilder.WriteString(", ")
+		}
+		metricBuilder.WriteString(fmt.Sprintf("ops_1m=%d, lat_avg=%s", lastMinute.Count, lastMinute.Avg().Truncate(time.Microsecond)))
+		if lastMinute.MaxTime > 0 {
+			metricBuilder.WriteString(fmt.Sprintf(", lat_max=%s", time.Duration(lastMinute.MaxTime).Truncate(time.Microsecond)))
+		}
+	}
+	return metricBuilder.String()
+}
 
-			for endpoint := range diskStatus {
-				endpoints = append(endpoints, endpoint)
-			}
-			sort.Sort(sortorder.Natural(endpoints))
+// driveUsage returns the used space percentage of disk, ok is false when the
+// drive didn't report its size
+func driveUsage(disk driveStatus) (usedPercent float64, ok bool) {
+	if disk.TotalSpace == 0 {
+		return 0, false
+	}
+	return float64(disk.UsedSpace) / float64(disk.TotalSpace) * 100.0, true
+}
 
-			for _, endpoint := range endpoints {
-				disk := diskStatus[endpoint]
+// driveInodes returns the used inode percentage of disk, ok is false when the
+// drive reported no inode counts (e.g. btrfs). A drive without free inodes is
+// reported, it is full.
+func driveInodes(disk driveStatus) (inodePercent float64, ok bool) {
+	totalInodes := disk.UsedInodes + disk.FreeInodes
+	if totalInodes == 0 {
+		return 0, false
+	}
+	return float64(disk.UsedInodes) / float64(totalInodes) * 100.0, true
+}
 
-				metricBuilder := strings.Builder{}
-				builderFn := func(key string, value uint64) {
-					if value == 0 {
-						return
-					}
-					if metricBuilder.Len() > 0 {
-						metricBuilder.WriteString(", ")
-					}
-					metricBuilder.WriteString(fmt.Sprintf("%s=%d", key, value))
-				}
-				metricData := ""
-				if disk.Metrics != nil {
-					metrics := disk.Metrics
-					builderFn("tokens", uint64(metrics.TotalTokens))
-					builderFn("write", metrics.TotalWrites)
-					builderFn("del", metrics.TotalDeletes)
-					builderFn("waiting", uint64(metrics.TotalWaiting))
-					builderFn("tout", metrics.TotalErrorsTimeout)
-					if metrics.TotalErrorsTimeout != metrics.TotalErrorsAvailability {
-						builderFn("err", metrics.TotalErrorsAvailability)
-					}
+// driveLayout is how printReport lays out the drive lines of a set
+type driveLayout struct {
+	// aligned prints the drives as a table, otherwise as the plain
+	// "endpoint = status disk=..%[size], inode=..% [metrics]" lines
+	aligned bool
+	// width truncates the table lines, 0 for no limit
+	width int
+	// barWidth is the width of the usage bar next to each drive, 0 for none
+	barWidth int
+}
 
-					// if metrics.TotalErrorsTimeout > 0 || metrics.TotalErrorsAvailability > 0 {
-					// 	metricData = fmt.Sprintf("wait=%+v, writes=%d", metrics.LastMinute, metrics.TotalWrites)
-					// 	if metrics.TotalErrorsTimeout == metrics.TotalErrorsAvailability {
-					// 		metricData = fmt.Sprintf("%s, tout=%d", metricData, metrics.TotalErrorsTimeout)
-					// 	} else {
-					// 		metricData = fmt.Sprintf("%s, tout=%d, err=%d", metricData, metrics.TotalErrorsTimeout, metrics.TotalErrorsAvailability)
-					// 	}
-					// }
-					if metricBuilder.Len() > 0 {
-						metricData = fmt.Sprintf("[%s]", metricBuilder.String())
-					}
-				}
+// newDriveLayout aligns the drive lines when the output is a terminal, cut to
+// its width, or when $COLUMNS tells the width; --wide aligns them without a
+// limit and --plain keeps the plain lines
+func newDriveLayout(opts options) driveLayout {
+	layout := driveLayout{barWidth: opts.usageBar}
+	switch {
+	case opts.plain:
+		return layout
+	case opts.wide:
+		layout.aligned = true
+		return layout
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		layout.aligned, layout.width = true, columns
+		return layout
+	}
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return layout
+	}
+	layout.aligned = true
+	if width, _, err := term.GetSize(fd); err == nil {
+		layout.width = width
+	}
+	return layout
+}
 
-				// disk usage
-				diskUsage := ""
-				if disk.TotalSpace != 0 && disk.FreeInodes != 0 {
-					totalInodes := disk.UsedInodes + disk.FreeInodes
-					diskUsage = fmt.Sprintf("disk=%.0f%%[%s], inode=%.0f%% ",
-						float64(disk.UsedSpace)/float64(disk.TotalSpace)*100.0,
-						humanize.IBytes(disk.TotalSpace),
-						float64(disk.UsedInodes)/float64(totalInodes)*100.0,
-					)
-				}
+// usageBar draws usedPercent as a bar of width characters, "[####----]"
+func usageBar(usedPercent float64, width int) string {
+	filled := int(math.Round(usedPercent / 100 * float64(width)))
+	filled = min(max(filled, 0), width)
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
+}
 
-				fmt.Printf("%s = %s %s%s\n", endpoint, disk.Status, diskUsage, metricData)
-				poolStatus, ok := _driveStatus[poolIndex]
-				if !ok {
-					poolStatus = make(map[string]int)
-				}
+// printDriveLines prints the drives of a set in the plain format, one
+// "endpoint = status disk=..%[size], inode=..% [metrics]" line per drive,
+// with the usage bar after the status when enabled
+func printDriveLines(drives []driveStatus, layout driveLayout) {
+	for _, disk := range drives {
+		metricData := ""
+		if metrics := driveMetrics(disk.Metrics); metrics != "" {
+			metricData = fmt.Sprintf("[%s]", metrics)
+		}
 
-				_status, ok := poolStatus[disk.Status]
-				if !ok {
-					_status = 0
-				}
+		// disk usage
+		diskUsage := ""
+		if usedPercent, ok := driveUsage(disk); ok {
+			diskUsage = fmt.Sprintf("disk=%.0f%%[%s], ", usedPercent, humanize.IBytes(disk.TotalSpace))
+			if layout.barWidth > 0 {
+				diskUsage = usageBar(usedPercent, layout.barWidth) + " " + diskUsage
+			}
+		}
+		if inodePercent, ok := driveInodes(disk); ok {
+			diskUsage += fmt.Sprintf("inode=%.0f%% ", inodePercent)
+		} else if diskUsage != "" {
+			diskUsage = strings.TrimSuffix(diskUsage, ", ") + " "
+		}
+
+		fmt.Printf("%s = %s %s%s\n", disk.Endpoint, disk.Status, diskUsage, metricData)
+	}
+}
+
+// printDriveTable prints the drives of a set in aligned columns, with a
+// usage bar column when enabled, the lines longer than the layout width cut
+// with an ellipsis
+func printDriveTable(drives []driveStatus, layout driveLayout) {
+	buffer := &bytes.Buffer{}
+	writer := tabwriter.NewWriter(buffer, 0, 0, 2, ' ', 0)
+	header := []string{"drive", "status", "used", "size", "inodes", "metrics"}
+	if layout.barWidth > 0 {
+		header = slices.Insert(header, 3, "")
+	}
+	fmt.Fprintln(writer, strings.Join(header, "\t"))
+	for _, disk := range drives {
+		used, size, inodes, bar := "-", "-", "-", "-"
+		if usedPercent, ok := driveUsage(disk); ok {
+			used = fmt.Sprintf("%.0f%%", usedPercent)
+			size = humanize.IBytes(disk.TotalSpace)
+			bar = usageBar(usedPercent, layout.barWidth)
+		}
+		if inodePercent, ok := driveInodes(disk); ok {
+			inodes = fmt.Sprintf("%.0f%%", inodePercent)
+		}
+		columns := []string{disk.Endpoint, disk.Status, used, size, inodes, driveMetrics(disk.Metrics)}
+		if layout.barWidth > 0 {
+			columns = slices.Insert(columns, 3, bar)
+		}
+		fmt.Fprintln(writer, strings.Join(columns, "\t"))
+	}
+	writer.Flush()
+
+	for _, line := range strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n") {
+		line = strings.TrimRight(line, " ")
+		if runes := []rune(line); layout.width > 0 && len(runes) > layout.width {
+			line = string(runes[:layout.width-1]) + "…"
+		}
+		fmt.Println(line)
+	}
+}
 
-				poolStatus[disk.Status] = _status + 1
+// printReport prints the servers and drives of every pool followed by the
+// drive status counts and the overall summary
+func printReport(r report, layout driveLayout) {
+	for _, pool := range r.Pools {
+		// nothing left to show with --unhealthy-only
+		if len(pool.Servers) == 0 && len(pool.Sets) == 0 {
+			continue
+		}
+		// print server information
+		if len(pool.Servers) > 0 {
+			fmt.Printf("\nPool=%d, Servers\n", pool.Pool)
+		}
+		for _, server := range pool.Servers {
+			fmt.Printf("%s: (%s)\n", server.Endpoint, server.State)
+			if server.State == "offline" {
+				fmt.Println()
+				continue
+			}
+			fmt.Printf("edition=%s, version=%s, commit_id=%s\n", server.Edition, server.Version, server.CommitID)
+			fmt.Printf("mem_stats_alloc=%s, ilm_expiry_in_progress=%v, uptime=%s\n", humanize.IBytes(server.MemStatsAlloc), server.ILMExpiryInProgress, humanizeDuration(time.Duration(server.UptimeSeconds)*time.Second))
+			fmt.Println()
+		}
 
-				_driveStatus[poolIndex] = poolStatus
+		// print state
+		for _, set := range pool.Sets {
+			fmt.Printf("\nPool=%d, ES=%d\n", pool.Pool, set.Set)
+			if layout.aligned {
+				printDriveTable(set.Drives, layout)
+			} else {
+				printDriveLines(set.Drives, layout)
+			}
+			if set.Imbalance != nil {
+				fmt.Println(imbalanceWarning(set.Imbalance))
+			}
+			if set.Missing > 0 {
+				fmt.Println(missingWarning(set.Missing))
+			}
+			if set.Tolerance != nil {
+				fmt.Println(toleranceLine(set.Tolerance))
 			}
 		}
 	}
-	// print drive status
-	// fmt.Printf("\n%+v\n", _driveStatus)
+
 	// print pool status
 	fmt.Println()
 	fmt.Println("Drive status:")
-	for poolIndex, status := range _driveStatus {
-		fmt.Printf("Pool=%d: ", poolIndex+1)
+	for _, pool := range r.Pools {
+		fmt.Printf("Pool=%d: ", pool.Pool)
 		statusKeys := []string{}
-		for statusKey := range status {
+		for statusKey := range pool.DriveStatus {
 			statusKeys = append(statusKeys, statusKey)
 		}
 		sort.Strings(statusKeys)
 		statusParts := []string{}
 		for _, statusKey := range statusKeys {
-			statusParts = append(statusParts, fmt.Sprintf("%s=%d", statusKey, status[statusKey]))
+			statusParts = append(statusParts, fmt.Sprintf("%s=%d", statusKey, pool.DriveStatus[statusKey]))
+		}
+		if pool.MissingDrives > 0 {
+			statusParts = append(statusParts, fmt.Sprintf("missing=%d", pool.MissingDrives))
 		}
 		fmt.Println(strings.Join(statusParts, ", "))
 	}
-	printOverall(infoStruct)
 
-	// drawTable()
+	printServerDrives(r.ServerDrives)
 
+	fmt.Println()
+	fmt.Println("Pool capacity:")
+	for _, pool := range r.Pools {
+		fmt.Printf("Pool=%d: %s\n", pool.Pool, poolCapacityLine(pool.Capacity))
+	}
+	printOverall(r.Summary)
 }
 
-func printOverall(infoStruct clusterStruct) {
-	// disk raw details
-	var rawTotalSize uint64 = 0
-	var rawUsedSize uint64 = 0
+// serverDriveStatuses lists the drive states seen on any of the servers
+func serverDriveStatuses(servers []serverDrives) []string {
+	statuses := []string{}
+	for _, server := range servers {
+		for status := range server.DriveStatus {
+			if !slices.Contains(statuses, status) {
+				statuses = append(statuses, status)
+			}
+		}
+	}
+	sort.Strings(statuses)
+	return statuses
+}
+
+// printServerDrives prints a table of the drive counts by status, one row
+// per server, to spot a single bad node
+func printServerDrives(servers []serverDrives) {
+	if len(servers) == 0 {
+		return
+	}
+	statuses := serverDriveStatuses(servers)
+	fmt.Println()
+	fmt.Println("Server drive status:")
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "server\tpool\tstate\t%s\n", strings.Join(statuses, "\t"))
+	for _, server := range servers {
+		counts := []string{}
+		for _, status := range statuses {
+			counts = append(counts, strconv.Itoa(server.DriveStatus[status]))
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%s\t%s\n", server.Endpoint, server.Pool, server.State, strings.Join(counts, "\t"))
+	}
+	writer.Flush()
+}
 
-	noDrives := 0
+// markdownCell escapes the characters that would break a Markdown table cell
+func markdownCell(value string) string {
+	return strings.ReplaceAll(value, "|", "\\|")
+}
 
-	for _, server := range infoStruct.Info.Servers {
-		for _, disk := range server.Disks {
-			// update size
-			rawTotalSize += disk.TotalSpace
-			rawUsedSize += disk.UsedSpace
-			noDrives++
+// printMarkdown prints the report as Markdown, a table of servers and one of
+// drives per erasure set for every pool, followed by the drive status counts
+// per pool and the overall summary
+func printMarkdown(r report) {
+	for _, pool := range r.Pools {
+		if len(pool.Servers) == 0 && len(pool.Sets) == 0 {
+			continue
+		}
+		fmt.Printf("## Pool %d\n\n", pool.Pool)
+		if len(pool.Servers) > 0 {
+			fmt.Println("| Server | State | Version | Commit | Memory | Uptime |")
+			fmt.Println("|---|---|---|---|---|---|")
+			for _, server := range pool.Servers {
+				fmt.Printf("| %s | %s | %s | %s | %s | %s |\n", markdownCell(server.Endpoint), server.State, server.Version, server.CommitID,
+					humanize.IBytes(server.MemStatsAlloc), humanizeDuration(time.Duration(server.UptimeSeconds)*time.Second))
+			}
+			fmt.Println()
+		}
+
+		for _, set := range pool.Sets {
+			fmt.Printf("### Pool %d, Erasure Set %d\n\n", pool.Pool, set.Set)
+			fmt.Println("| Drive | Status | Used | Size | Inodes | Metrics |")
+			fmt.Println("|---|---|---:|---:|---:|---|")
+			for _, disk := range set.Drives {
+				used, size, inodes := "", "", ""
+				if usedPercent, ok := driveUsage(disk); ok {
+					used = fmt.Sprintf("%.0f%%", usedPercent)
+					size = humanize.IBytes(disk.TotalSpace)
+				}
+				if inodePercent, ok := driveInodes(disk); ok {
+					inodes = fmt.Sprintf("%.0f%%", inodePercent)
+				}
+				fmt.Printf("| %s | %s | %s | %s | %s | %s |\n", markdownCell(disk.Endpoint), disk.Status, used, size, inodes, driveMetrics(disk.Metrics))
+			}
+			fmt.Println()
+			if set.Imbalance != nil {
+				fmt.Printf("> **%s**\n\n", markdownCell(imbalanceWarning(set.Imbalance)))
+			}
+			if set.Missing > 0 {
+				fmt.Printf("> **%s**\n\n", missingWarning(set.Missing))
+			}
+			if atRisk(set.Tolerance) {
+				fmt.Printf("> **%s**\n\n", toleranceLine(set.Tolerance))
+			} else if set.Tolerance != nil {
+				fmt.Printf("> %s\n\n", toleranceLine(set.Tolerance))
+			}
+		}
+	}
+
+	// one column per status seen in any pool
+	statuses := []string{}
+	for _, pool := range r.Pools {
+		for status := range pool.DriveStatus {
+			if !slices.Contains(statuses, status) {
+				statuses = append(statuses, status)
+			}
+		}
+	}
+	sort.Strings(statuses)
+	fmt.Println("## Drive Status")
+	fmt.Println()
+	fmt.Printf("| Pool | %s |\n", strings.Join(statuses, " | "))
+	fmt.Printf("|---|%s\n", strings.Repeat("---:|", len(statuses)))
+	for _, pool := range r.Pools {
+		counts := []string{}
+		for _, status := range statuses {
+			counts = append(counts, strconv.Itoa(pool.DriveStatus[status]))
+		}
+		fmt.Printf("| %d | %s |\n", pool.Pool, strings.Join(counts, " | "))
+	}
+
+	if len(r.ServerDrives) > 0 {
+		statuses := serverDriveStatuses(r.ServerDrives)
+		fmt.Println()
+		fmt.Println("## Server Drive Status")
+		fmt.Println()
+		fmt.Printf("| Server | Pool | State | %s |\n", strings.Join(statuses, " | "))
+		fmt.Printf("|---|---:|---|%s\n", strings.Repeat("---:|", len(statuses)))
+		for _, server := range r.ServerDrives {
+			counts := []string{}
+			for _, status := range statuses {
+				counts = append(counts, strconv.Itoa(server.DriveStatus[status]))
+			}
+			fmt.Printf("| %s | %d | %s | %s |\n", markdownCell(server.Endpoint), server.Pool, server.State, strings.Join(counts, " | "))
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("## Capacity")
+	fmt.Println()
+	fmt.Println("| Pool | Layout | Raw | Raw free | Usable | Usable free |")
+	fmt.Println("|---|---|---:|---:|---:|---:|")
+	for _, pool := range r.Pools {
+		capacity := pool.Capacity
+		layout, usable, usableFree := "", "", ""
+		if capacity.DataDrives > 0 {
+			layout = fmt.Sprintf("%d+%d", capacity.DataDrives, capacity.ParityDrives)
+			usable, usableFree = humanize.IBytes(capacity.UsableTotalBytes), humanize.IBytes(capacity.UsableFreeBytes)
 		}
+		fmt.Printf("| %d | %s | %s | %s | %s | %s |\n", pool.Pool, layout, humanize.IBytes(capacity.RawTotalBytes),
+			humanize.IBytes(capacity.RawFreeBytes), usable, usableFree)
+	}
+	fmt.Println()
+	printMarkdownSummary(r.Summary)
+}
+
+// printMarkdownSummary prints the overall summary as a Markdown list
+func printMarkdownSummary(summary summaryReport) {
+	fmt.Println("## Summary")
+	fmt.Println()
+	fmt.Printf("- Deployment ID: `%s`\n", summary.DeploymentID)
+	if len(summary.Pools) > 0 {
+		fmt.Printf("- Scope: pools %v (scanner counts cover the whole cluster)\n", summary.Pools)
 	}
+	if summary.VersionSkew != nil {
+		fmt.Printf("- **%s**\n", versionSkewWarning(summary.VersionSkew))
+	}
+	fmt.Printf("- Sets: %v, drives per set: %v, parity: EC:%d (RRS EC:%d)\n", summary.TotalSets, summary.DrivesPerSet, summary.StandardSCParity, summary.RRSCParity)
+	fmt.Printf("- Buckets: %d, objects: %d, versions: %d, delete markers: %d, usage: %s\n",
+		summary.Buckets, summary.Objects, summary.Versions, summary.DeleteMarkers, humanize.IBytes(summary.UsageBytes))
+	fmt.Printf("- Drives: %d, raw total: %s, used: %s, free: %s\n", summary.Drives, humanize.IBytes(summary.RawTotalBytes), humanize.IBytes(summary.RawUsedBytes), humanize.IBytes(summary.RawFreeBytes))
+	fmt.Printf("- %s\n", capacityForecast(summary))
+}
+
+// poolCapacityLine formats the raw and usable capacity of a pool
+func poolCapacityLine(capacity poolCapacity) string {
+	raw := fmt.Sprintf("raw=%s, raw_free=%s", humanize.IBytes(capacity.RawTotalBytes), humanize.IBytes(capacity.RawFreeBytes))
+	if capacity.DataDrives == 0 {
+		return raw + ", usable=N/A"
+	}
+	return fmt.Sprintf("ec=%d+%d, %s, usable=%s, usable_free=%s", capacity.DataDrives, capacity.ParityDrives, raw,
+		humanize.IBytes(capacity.UsableTotalBytes), humanize.IBytes(capacity.UsableFreeBytes))
+}
 
+func printOverall(summary summaryReport) {
 	fmt.Println()
-	fmt.Printf("deploymentID=%s\n", infoStruct.Info.DeploymentID)
+	fmt.Printf("deploymentID=%s\n", summary.DeploymentID)
+	if len(summary.Pools) > 0 {
+		fmt.Printf("summary_scope: pools=%v (scanner_status covers the whole cluster)\n", summary.Pools)
+	}
+	if summary.VersionSkew != nil {
+		fmt.Println(versionSkewWarning(summary.VersionSkew))
+	}
 	fmt.Printf("totalSets=%v, standardSCParity=%d, rrSCParity=%d, totalDriversPerSet=%v\n",
-		infoStruct.Info.Backend.TotalSets, infoStruct.Info.Backend.StandardSCParity, infoStruct.Info.Backend.RRSCParity, infoStruct.Info.Backend.DrivesPerSet)
+		summary.TotalSets, summary.StandardSCParity, summary.RRSCParity, summary.DrivesPerSet)
 	// print buckets, objects, versions, and deletemarkers
 	fmt.Printf("scanner_status: buckets=%d, objects=%d, versions=%d, deletemarkers=%d, usage=%s\n",
-		infoStruct.Info.Buckets.Count, infoStruct.Info.Objects.Count, infoStruct.Info.Versions.Count, infoStruct.Info.DeleteMarkers.Count, humanize.IBytes(infoStruct.Info.Usage.Size))
-	fmt.Printf("drive_raw_stats: drives=%d, total=%s, used=%s, free=%s\n", noDrives, humanize.IBytes(rawTotalSize), humanize.IBytes(rawUsedSize), humanize.IBytes(rawTotalSize-rawUsedSize))
+		summary.Buckets, summary.Objects, summary.Versions, summary.DeleteMarkers, humanize.IBytes(summary.UsageBytes))
+	fmt.Printf("drive_raw_stats: drives=%d, total=%s, used=%s, free=%s\n", summary.Drives, humanize.IBytes(summary.RawTotalBytes), humanize.IBytes(summary.RawUsedBytes), humanize.IBytes(summary.RawFreeBytes))
+	fmt.Println(capacityForecast(summary))
+}
+
+// capacityForecast reports the used percentage and, when a growth rate is known,
+// a rough estimate of the time until usage reaches the full threshold
+func capacityForecast(summary summaryReport) string {
+	if summary.RawTotalBytes == 0 {
+		return "capacity: used=N/A"
+	}
+
+	line := fmt.Sprintf("capacity: used=%.1f%%, full_threshold=%.0f%%", summary.UsedPercent, summary.FullThreshold)
+	switch {
+	case summary.DaysToFull == nil:
+	case *summary.DaysToFull == 0:
+		line += ", eta_to_full=already reached"
+	default:
+		line += fmt.Sprintf(", growth=%s/day, eta_to_full=~%.1f days", humanize.IBytes(summary.GrowthBytesPerDay), *summary.DaysToFull)
+	}
+	return line
 }
 
-func trimDomainData(endpoint, domainString string) string {
+// trimDomainData shortens the host of endpoint by the longest of the domain
+// suffixes it ends with
+func trimDomainData(endpoint string, domains []string) string {
 	// Normalize endpoint to extract host (remove scheme, path and port)
 	host := endpoint
 
@@ -323,54 +2190,127 @@ func trimDomainData(endpoint, domainString string) string {
 		return ip.String()
 	}
 
-	// Fallback to previous behaviour for domain names
-	if domainString == "" {
+	// Trim the longest domain suffix matching whole labels, so example.com
+	// doesn't cut node1.myexample.com, the first label when none matches
+	longest := ""
+	for _, domain := range domains {
+		suffix := "." + strings.TrimPrefix(domain, ".")
+		if len(suffix) > len(longest) && len(suffix) < len(host) && strings.HasSuffix(host, suffix) {
+			longest = suffix
+		}
+	}
+	if longest == "" {
 		return strings.SplitN(host, ".", 2)[0]
 	}
-	return strings.TrimSuffix(strings.TrimSuffix(host, domainString), ".")
+	return strings.TrimSuffix(host, longest)
 }
 
-func drawTable() {
-	app := tview.NewApplication()
-	dropdown := tview.NewDropDown().
-		SetLabel("Select an option (hit Enter): ").
-		SetOptions([]string{"First", "Second", "Third", "Fourth", "Fifth"}, nil)
-	if err := app.SetRoot(dropdown, true).SetFocus(dropdown).Run(); err != nil {
-		panic(err)
+// statusColor is the color of a drive or server status in the interactive view
+func statusColor(status string) tcell.Color {
+	switch status {
+	case madmin.DriveStateOk, "online":
+		return tcell.ColorGreen
+	case madmin.DriveStateOffline:
+		return tcell.ColorRed
+	default:
+		return tcell.ColorYellow
 	}
+}
 
+// drawTable shows the drives of the report in an interactive table, one row
+// per drive in pool and set order, colored by status. The pool dropdown on
+// top limits the rows to a single pool; Tab switches between the dropdown and
+// the table, Escape or q quits
+func drawTable(r report) error {
+	app := tview.NewApplication()
 	table := tview.NewTable().
-		SetBorders(true)
-	lorem := strings.Split("Lorem ipsum dolor sit amet, consetetur sadipscing elitr, sed diam nonumy eirmod tempor invidunt ut labore et dolore magna aliquyam erat, sed diam voluptua. At vero eos et accusam et justo duo dolores et ea rebum. Stet clita kasd gubergren, no sea takimata sanctus est Lorem ipsum dolor sit amet. Lorem ipsum dolor sit amet, consetetur sadipscing elitr, sed diam nonumy eirmod tempor invidunt ut labore et dolore magna aliquyam erat, sed diam voluptua. At vero eos et accusam et justo duo dolores et ea rebum. Stet clita kasd gubergren, no sea takimata sanctus est Lorem ipsum dolor sit amet.", " ")
-	cols, rows := 10, 40
-	word := 0
-	for r := 0; r < rows; r++ {
-		for c := 0; c < cols; c++ {
-			color := tcell.ColorWhite
-			if c < 1 || r < 1 {
-				color = tcell.ColorYellow
-			}
-			table.SetCell(r, c,
-				tview.NewTableCell(lorem[word]).
-					SetTextColor(color).
-					SetAlign(tview.AlignCenter))
-			word = (word + 1) % len(lorem)
-		}
-	}
-	table.Select(0, 0).SetFixed(1, 1).SetDoneFunc(func(key tcell.Key) {
+		SetBorders(false).
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+
+	headers := []string{"Pool", "Set", "Drive", "Status", "Used", "Size", "Inodes", "Metrics"}
+	fill := func(pool int) {
+		table.Clear()
+		for column, header := range headers {
+			table.SetCell(0, column, tview.NewTableCell(header).
+				SetTextColor(tcell.ColorYellow).
+				SetSelectable(false))
+		}
+		row := 1
+		for _, p := range r.Pools {
+			if pool != 0 && p.Pool != pool {
+				continue
+			}
+			for _, set := range p.Sets {
+				for _, disk := range set.Drives {
+					used, size, inodes := "", "", ""
+					if usedPercent, ok := driveUsage(disk); ok {
+						used = fmt.Sprintf("%.0f%%", usedPercent)
+						size = humanize.IBytes(disk.TotalSpace)
+					}
+					if inodePercent, ok := driveInodes(disk); ok {
+						inodes = fmt.Sprintf("%.0f%%", inodePercent)
+					}
+					color := statusColor(disk.Status)
+					for column, value := range []string{strconv.Itoa(p.Pool), strconv.Itoa(set.Set), disk.Endpoint, disk.Status, used, size, inodes, driveMetrics(disk.Metrics)} {
+						align := tview.AlignLeft
+						if column != 2 && column != 3 && column != 7 {
+							align = tview.AlignRight
+						}
+						table.SetCell(row, column, tview.NewTableCell(tview.Escape(value)).
+							SetTextColor(color).
+							SetAlign(align))
+					}
+					row++
+				}
+			}
+		}
+		table.ScrollToBeginning().Select(1, 0)
+	}
+
+	options := []string{"All"}
+	for _, p := range r.Pools {
+		options = append(options, fmt.Sprintf("Pool %d", p.Pool))
+	}
+	dropdown := tview.NewDropDown().
+		SetLabel("Pool: ").
+		SetOptions(options, func(_ string, index int) {
+			if index <= 0 {
+				fill(0)
+			} else {
+				fill(r.Pools[index-1].Pool)
+			}
+			app.SetFocus(table)
+		})
+	dropdown.SetCurrentOption(0)
+
+	table.SetDoneFunc(func(key tcell.Key) {
 		if key == tcell.KeyEscape {
 			app.Stop()
 		}
-		if key == tcell.KeyEnter {
-			table.SetSelectable(true, true)
+	})
+
+	layout := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(dropdown, 1, 0, false).
+		AddItem(table, 0, 1, true)
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyTab:
+			if table.HasFocus() {
+				app.SetFocus(dropdown)
+			} else {
+				app.SetFocus(table)
+			}
+			return nil
+		case event.Rune() == 'q' && table.HasFocus():
+			app.Stop()
+			return nil
 		}
-	}).SetSelectedFunc(func(row int, column int) {
-		table.GetCell(row, column).SetTextColor(tcell.ColorRed)
-		table.SetSelectable(false, false)
+		return event
 	})
-	if err := app.SetRoot(table, true).SetFocus(table).Run(); err != nil {
-		panic(err)
-	}
+
+	return app.SetRoot(layout, true).SetFocus(table).Run()
 }
 
 // Source: https://gist.github.com/harshavardhana/327e0577c4fed9211f65
@@ -0,0 +1,490 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go/v3"
+)
+
+// testDrive is a drive of size 1000 with used bytes and inode counts
+func testDrive(endpoint string, used, usedInodes, freeInodes uint64) driveStatus {
+	return driveStatus{Endpoint: endpoint, Status: "ok", UsedSpace: used, TotalSpace: 1000, UsedInodes: usedInodes, FreeInodes: freeInodes}
+}
+
+func TestDriveImbalance(t *testing.T) {
+	tests := []struct {
+		name      string
+		drives    []driveStatus
+		threshold float64
+		spread    float64
+		flagged   []string
+	}{
+		{
+			name:      "even",
+			drives:    []driveStatus{testDrive("n1:/d1", 100, 10, 90), testDrive("n2:/d1", 120, 10, 90)},
+			threshold: 10,
+		},
+		{
+			name: "one full drive",
+			drives: []driveStatus{
+				testDrive("n1:/d1", 100, 10, 90), testDrive("n2:/d1", 120, 10, 90),
+				testDrive("n3:/d1", 110, 10, 90), testDrive("n4:/d1", 950, 10, 90),
+			},
+			threshold: 10,
+			spread:    85,
+			flagged:   []string{"n4:/d1"},
+		},
+		{
+			// inodes run out, or aren't reported at all (btrfs), the space still counts
+			name: "zero free inodes",
+			drives: []driveStatus{
+				testDrive("n1:/d1", 100, 10, 90), testDrive("n2:/d1", 120, 0, 0),
+				testDrive("n3:/d1", 110, 10, 90), testDrive("n4:/d1", 950, 100, 0),
+			},
+			threshold: 10,
+			spread:    85,
+			flagged:   []string{"n4:/d1"},
+		},
+		{
+			name:      "drive without size left out",
+			drives:    []driveStatus{testDrive("n1:/d1", 100, 10, 90), {Endpoint: "n2:/d1", Status: "offline"}},
+			threshold: 10,
+		},
+		{
+			name:      "disabled",
+			drives:    []driveStatus{testDrive("n1:/d1", 100, 10, 90), testDrive("n2:/d1", 950, 10, 90)},
+			threshold: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imbalance := driveImbalance(tt.drives, tt.threshold)
+			if tt.flagged == nil {
+				if imbalance != nil {
+					t.Fatalf("expected no imbalance, got %+v", imbalance)
+				}
+				return
+			}
+			if imbalance == nil {
+				t.Fatalf("expected an imbalance of %v", tt.flagged)
+			}
+			if imbalance.SpreadPercent != tt.spread {
+				t.Fatalf("expected a spread of %v, got %v", tt.spread, imbalance.SpreadPercent)
+			}
+			var flagged []string
+			for _, drive := range imbalance.Drives {
+				flagged = append(flagged, drive.Endpoint)
+			}
+			if !slices.Equal(flagged, tt.flagged) {
+				t.Fatalf("expected %v flagged, got %v", tt.flagged, flagged)
+			}
+		})
+	}
+}
+
+func TestTrimDomainData(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		domains  []string
+		want     string
+	}{
+		{endpoint: "http://node1.dc1.example.com:9000/mnt/drive1", domains: []string{"example.com"}, want: "node1.dc1"},
+		{endpoint: "http://node1.dc1.example.com:9000/mnt/drive1", domains: []string{"example.com", "dc1.example.com"}, want: "node1"},
+		{endpoint: "node1.dc1.example.com:9000", domains: []string{".example.com"}, want: "node1.dc1"},
+		// the suffix has to match whole labels
+		{endpoint: "node1.myexample.com:9000", domains: []string{"example.com"}, want: "node1"},
+		{endpoint: "node1.example.com:9000", domains: nil, want: "node1"},
+		{endpoint: "node1.example.com", domains: []string{"node1.example.com"}, want: "node1"},
+		{endpoint: "http://10.0.0.1:9000/mnt/drive1", domains: []string{"example.com"}, want: "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		if got := trimDomainData(tt.endpoint, tt.domains); got != tt.want {
+			t.Errorf("trimDomainData(%q, %v): expected %q, got %q", tt.endpoint, tt.domains, tt.want, got)
+		}
+	}
+}
+
+func TestDiffGrowth(t *testing.T) {
+	opts := options{fullThreshold: 85, summaryScope: scopeCluster}
+	before := report{Summary: summaryReport{RawTotalBytes: 1000, RawUsedBytes: 500, FullThreshold: 85}}
+	after := report{Summary: summaryReport{RawTotalBytes: 1000, RawUsedBytes: 600, FullThreshold: 85}}
+
+	changes := diffReport{RawUsedDeltaBytes: 100}
+	diffGrowth(&changes, before, after, 48*time.Hour, "--diff-interval", opts)
+	if changes.GrowthBytesPerDay == nil || *changes.GrowthBytesPerDay != 50 {
+		t.Fatalf("expected a growth of 50 bytes/day, got %v", changes.GrowthBytesPerDay)
+	}
+	// 250 bytes left to the 85% threshold at 50 bytes/day
+	if changes.DaysToFull == nil || *changes.DaysToFull != 5 || changes.UsedPercent != 60 {
+		t.Fatalf("expected 5 days to full at 60%% used, got %v at %v%%", changes.DaysToFull, changes.UsedPercent)
+	}
+
+	changes = diffReport{RawUsedDeltaBytes: -100}
+	diffGrowth(&changes, before, after, 24*time.Hour, "file modification time", opts)
+	if changes.GrowthBytesPerDay == nil || *changes.GrowthBytesPerDay != -100 || changes.DaysToFull != nil {
+		t.Fatalf("expected a shrinking cluster without days to full, got %+v", changes)
+	}
+
+	changes = diffReport{RawUsedDeltaBytes: 100}
+	diffGrowth(&changes, before, after, 0, "", opts)
+	if changes.GrowthBytesPerDay != nil || changes.IntervalSeconds != 0 {
+		t.Fatalf("expected no growth without an interval, got %+v", changes)
+	}
+}
+
+func TestBuildPoolCapacity(t *testing.T) {
+	pool := poolReport{Sets: []setReport{
+		{Drives: []driveStatus{testDrive("n1:/d1", 250, 0, 0), testDrive("n2:/d1", 250, 0, 0)}},
+		{Drives: []driveStatus{testDrive("n1:/d2", 250, 0, 0), testDrive("n2:/d2", 250, 0, 0)}},
+	}}
+	tests := []struct {
+		name    string
+		backend madmin.ErasureBackend
+		want    poolCapacity
+	}{
+		{
+			name:    "one parity drive of four",
+			backend: madmin.ErasureBackend{DrivesPerSet: []int{4}, StandardSCParity: 1},
+			want: poolCapacity{RawTotalBytes: 4000, RawUsedBytes: 1000, RawFreeBytes: 3000,
+				DataDrives: 3, ParityDrives: 1, UsableTotalBytes: 3000, UsableFreeBytes: 2250},
+		},
+		{
+			name:    "half parity",
+			backend: madmin.ErasureBackend{DrivesPerSet: []int{4}, StandardSCParity: 2},
+			want: poolCapacity{RawTotalBytes: 4000, RawUsedBytes: 1000, RawFreeBytes: 3000,
+				DataDrives: 2, ParityDrives: 2, UsableTotalBytes: 2000, UsableFreeBytes: 1500},
+		},
+		{
+			name:    "unknown layout",
+			backend: madmin.ErasureBackend{},
+			want:    poolCapacity{RawTotalBytes: 4000, RawUsedBytes: 1000, RawFreeBytes: 3000},
+		},
+		{
+			name:    "parity not below the set size",
+			backend: madmin.ErasureBackend{DrivesPerSet: []int{4}, StandardSCParity: 4},
+			want:    poolCapacity{RawTotalBytes: 4000, RawUsedBytes: 1000, RawFreeBytes: 3000},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := buildPoolCapacity(pool, tt.backend, 0); got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestDiffReports(t *testing.T) {
+	offline := testDrive("n2:/d1", 0, 0, 0)
+	offline.Status, offline.TotalSpace = "offline", 0
+	before := report{Pools: []poolReport{{
+		Pool:    1,
+		Servers: []serverReport{{Endpoint: "n1", State: "online"}, {Endpoint: "n2", State: "online"}, {Endpoint: "n3", State: "online"}},
+		Sets: []setReport{{Set: 1, Drives: []driveStatus{
+			testDrive("n1:/d1", 100, 0, 0), testDrive("n2:/d1", 200, 0, 0), testDrive("n3:/d1", 300, 0, 0), testDrive("n1:/d2", 400, 0, 0),
+		}}},
+	}}}
+	after := report{Pools: []poolReport{{
+		Pool:    1,
+		Servers: []serverReport{{Endpoint: "n1", State: "online"}, {Endpoint: "n2", State: "offline"}, {Endpoint: "n4", State: "online"}},
+		Sets: []setReport{{Set: 1, Drives: []driveStatus{
+			// n1:/d1 grew by 5%, n1:/d2 by less than 1%, n2:/d1 went offline
+			testDrive("n1:/d1", 150, 0, 0), offline, testDrive("n4:/d1", 50, 0, 0), testDrive("n1:/d2", 405, 0, 0),
+		}}},
+	}}}
+
+	changes := diffReports(before, after)
+	wantServers := []serverChange{
+		{Endpoint: "n2", Before: "online", After: "offline"},
+		{Endpoint: "n4", After: "online"},
+		{Endpoint: "n3", Before: "online"},
+	}
+	if !slices.Equal(changes.Servers, wantServers) {
+		t.Fatalf("expected server changes %+v, got %+v", wantServers, changes.Servers)
+	}
+	wantDrives := []driveChange{
+		{Endpoint: "n1:/d1", Pool: 1, Set: 1, Before: "ok", After: "ok", UsedPercentBefore: 10, UsedPercentAfter: 15, UsedDeltaBytes: 50},
+		{Endpoint: "n2:/d1", Pool: 1, Set: 1, Before: "ok", After: "offline", UsedPercentBefore: 20},
+		{Endpoint: "n4:/d1", Pool: 1, Set: 1, After: "ok", UsedPercentAfter: 5, UsedDeltaBytes: 50},
+		{Endpoint: "n3:/d1", Pool: 1, Set: 1, Before: "ok", UsedPercentBefore: 30, UsedDeltaBytes: -300},
+	}
+	if !slices.Equal(changes.Drives, wantDrives) {
+		t.Fatalf("expected drive changes %+v, got %+v", wantDrives, changes.Drives)
+	}
+	// the offline drive reports no usage, so it isn't counted as freed
+	if changes.RawUsedDeltaBytes != 50+5+50-300 {
+		t.Fatalf("expected a raw used delta of %d, got %d", 50+5+50-300, changes.RawUsedDeltaBytes)
+	}
+}
+
+func TestReadInput(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte(`{"status":"success"}`)
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write(content)
+	writer.Close()
+
+	files := map[string][]byte{
+		"plain.json":    content,
+		"info.json.gz":  compressed.Bytes(),
+		"info.json":     compressed.Bytes(), // sniffed by the gzip magic
+		"broken.gz":     content,            // the suffix asks for gzip
+		"truncated.bin": compressed.Bytes()[:4],
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"plain.json", "info.json.gz", "info.json"} {
+		data, err := readInput(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("%s: readInput returned error: %v", name, err)
+		}
+		if !bytes.Equal(data, content) {
+			t.Fatalf("%s: expected %s, got %s", name, content, data)
+		}
+	}
+	for _, name := range []string{"broken.gz", "truncated.bin", "missing.json"} {
+		if _, err := readInput(filepath.Join(dir, name)); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCheckDeployments(t *testing.T) {
+	same := []fileReport{{File: "a.json", DeploymentID: "dep-1"}, {File: "b.json", DeploymentID: "dep-1"}}
+	if err := checkDeployments(same); err != nil {
+		t.Fatalf("expected the same deployment to pass, got %v", err)
+	}
+
+	mixed := []fileReport{{File: "a.json", DeploymentID: "dep-1"}, {File: "b.json", DeploymentID: "dep-2"}}
+	err := checkDeployments(mixed)
+	if err == nil || err.Error() != "dumps of different deployments: dep-1 (a.json), dep-2 (b.json)" {
+		t.Fatalf("expected the deployments to be listed, got %v", err)
+	}
+}
+
+func TestMergeSummaries(t *testing.T) {
+	files := []fileReport{
+		{File: "a.json", Report: report{Summary: summaryReport{DeploymentID: "dep-1", TotalSets: []int{2}, DrivesPerSet: []int{4},
+			StandardSCParity: 2, Buckets: 1, Objects: 10, Drives: 8, RawTotalBytes: 1000, RawUsedBytes: 300}}},
+		{File: "b.json", Report: report{Summary: summaryReport{DeploymentID: "dep-2", TotalSets: []int{1}, DrivesPerSet: []int{8},
+			StandardSCParity: 3, Buckets: 2, Objects: 20, Drives: 8, RawTotalBytes: 1000, RawUsedBytes: 700}}},
+		{File: "c.json", Report: report{Summary: summaryReport{DeploymentID: "dep-1"}}},
+	}
+	merged := mergeSummaries(files, options{fullThreshold: 85})
+	if merged.DeploymentID != "dep-1,dep-2" {
+		t.Fatalf("expected each deployment listed once, got %q", merged.DeploymentID)
+	}
+	if !slices.Equal(merged.TotalSets, []int{2, 1}) || !slices.Equal(merged.DrivesPerSet, []int{4, 8}) || merged.StandardSCParity != 3 {
+		t.Fatalf("expected the layouts in file order and the highest parity, got %+v", merged)
+	}
+	if merged.Buckets != 3 || merged.Objects != 30 || merged.Drives != 16 || merged.RawFreeBytes != 1000 || merged.UsedPercent != 50 {
+		t.Fatalf("expected the counts and capacity added up, got %+v", merged)
+	}
+}
+
+func TestFindVersionSkew(t *testing.T) {
+	server := func(endpoint, version, commit string) madmin.ServerProperties {
+		return madmin.ServerProperties{Endpoint: endpoint, Version: version, CommitID: commit}
+	}
+	info := func(servers ...madmin.ServerProperties) clusterStruct {
+		return clusterStruct{Info: madmin.InfoMessage{Servers: servers}}
+	}
+
+	if skew := findVersionSkew(info(server("n1:9000", "v2", "b"), server("n2:9000", "v2", "b"), server("n3:9000", "", "")), nil); skew != nil {
+		t.Fatalf("expected no skew, got %+v", skew)
+	}
+
+	skew := findVersionSkew(info(server("n3:9000", "v1", "a"), server("n1:9000", "v2", "b"), server("n2:9000", "v2", "b"), server("n4:9000", "v2", "c")), nil)
+	want := &versionSkew{Version: "v2", CommitID: "b", Servers: []serverVersion{
+		{Endpoint: "n3", Version: "v1", CommitID: "a"},
+		{Endpoint: "n4", Version: "v2", CommitID: "c"},
+	}}
+	if skew == nil || skew.Version != want.Version || skew.CommitID != want.CommitID || !slices.Equal(skew.Servers, want.Servers) {
+		t.Fatalf("expected %+v, got %+v", want, skew)
+	}
+
+	// a tie goes to the newest version
+	skew = findVersionSkew(info(server("n1:9000", "v1", "a"), server("n2:9000", "v2", "b")), nil)
+	if skew == nil || skew.Version != "v2" || len(skew.Servers) != 1 || skew.Servers[0].Endpoint != "n1" {
+		t.Fatalf("expected v2 to win the tie, got %+v", skew)
+	}
+}
+
+func TestBuildReportMissing(t *testing.T) {
+	disk := func(path string, set int) madmin.Disk {
+		return madmin.Disk{Endpoint: "http://n1:9000" + path, DrivePath: path, State: "ok", TotalSpace: 1000, PoolIndex: 0, SetIndex: set}
+	}
+	infoStruct := clusterStruct{Info: madmin.InfoMessage{
+		Backend: madmin.ErasureBackend{TotalSets: []int{3}, DrivesPerSet: []int{4}, StandardSCParity: 2},
+		Servers: []madmin.ServerProperties{{Endpoint: "n1:9000", State: "online", PoolNumber: 1, Disks: []madmin.Disk{
+			disk("/d1", 0), disk("/d2", 0), disk("/d3", 0), disk("/d4", 0),
+			disk("/d5", 1), disk("/d6", 1), disk("/d7", 1),
+		}}},
+	}}
+
+	r := buildReport(infoStruct, nil, options{fullThreshold: 85, sortBy: sortEndpoint})
+	if len(r.Pools) != 1 || len(r.Pools[0].Sets) != 3 {
+		t.Fatalf("expected one pool of three sets, got %+v", r.Pools)
+	}
+	for i, want := range []int{0, 1, 4} {
+		if got := r.Pools[0].Sets[i].Missing; got != want {
+			t.Errorf("set %d: expected %d missing drives, got %d", i+1, want, got)
+		}
+	}
+	if r.Pools[0].MissingDrives != 5 {
+		t.Fatalf("expected 5 missing drives in the pool, got %d", r.Pools[0].MissingDrives)
+	}
+}
+
+func TestBuildSetTolerance(t *testing.T) {
+	drives := func(online, offline int) setReport {
+		set := setReport{}
+		for i := 0; i < online; i++ {
+			set.Drives = append(set.Drives, driveStatus{Status: "ok"})
+		}
+		for i := 0; i < offline; i++ {
+			set.Drives = append(set.Drives, driveStatus{Status: "offline"})
+		}
+		return set
+	}
+	tests := []struct {
+		name    string
+		set     setReport
+		backend madmin.ErasureBackend
+		want    *setTolerance
+	}{
+		{
+			name:    "parity below half",
+			set:     drives(8, 0),
+			backend: madmin.ErasureBackend{DrivesPerSet: []int{8}, StandardSCParity: 2},
+			want:    &setTolerance{OnlineDrives: 8, Drives: 8, Parity: 2, ReadQuorum: 6, WriteQuorum: 6, Failures: 2},
+		},
+		{
+			// with as many data as parity drives the write quorum is one more
+			name:    "even data and parity",
+			set:     drives(3, 1),
+			backend: madmin.ErasureBackend{DrivesPerSet: []int{4}, StandardSCParity: 2},
+			want:    &setTolerance{OnlineDrives: 3, Drives: 4, Parity: 2, ReadQuorum: 2, WriteQuorum: 3, Failures: 0},
+		},
+		{
+			name:    "write quorum lost",
+			set:     drives(2, 2),
+			backend: madmin.ErasureBackend{DrivesPerSet: []int{4}, StandardSCParity: 2},
+			want:    &setTolerance{OnlineDrives: 2, Drives: 4, Parity: 2, ReadQuorum: 2, WriteQuorum: 3, Failures: -1},
+		},
+		{
+			name:    "unknown layout",
+			set:     drives(4, 0),
+			backend: madmin.ErasureBackend{},
+		},
+		{
+			name:    "parity not below the set size",
+			set:     drives(4, 0),
+			backend: madmin.ErasureBackend{DrivesPerSet: []int{4}, StandardSCParity: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		got := buildSetTolerance(tt.set, tt.backend, 0)
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestSortPools(t *testing.T) {
+	pools := []poolReport{
+		{Pool: 1, Capacity: poolCapacity{RawTotalBytes: 1000, RawUsedBytes: 500}},
+		{Pool: 2, Capacity: poolCapacity{RawTotalBytes: 4000, RawUsedBytes: 400}},
+		{Pool: 3, Capacity: poolCapacity{RawTotalBytes: 1000, RawUsedBytes: 900}},
+		{Pool: 4, Capacity: poolCapacity{RawTotalBytes: 4000, RawUsedBytes: 2000}},
+	}
+	tests := []struct {
+		sortBy string
+		want   []int
+	}{
+		{sortBy: sortPoolIndex, want: []int{1, 2, 3, 4}},
+		{sortBy: sortPoolCapacity, want: []int{2, 4, 1, 3}},
+		{sortBy: sortPoolUsage, want: []int{3, 1, 4, 2}},
+	}
+
+	for _, tt := range tests {
+		sorted := slices.Clone(pools)
+		sortPools(sorted, tt.sortBy)
+		var got []int
+		for _, pool := range sorted {
+			got = append(got, pool.Pool)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("--sort-pools %s: expected %v, got %v", tt.sortBy, tt.want, got)
+		}
+	}
+}
+
+func TestSortSets(t *testing.T) {
+	offline := driveStatus{Status: "offline"}
+	ok := driveStatus{Status: "ok"}
+	sets := []setReport{
+		{Set: 1, Drives: []driveStatus{ok, ok}},
+		{Set: 2, Drives: []driveStatus{offline, ok}},
+		{Set: 3, Drives: []driveStatus{ok}, Missing: 1},
+		{Set: 4, Drives: []driveStatus{offline, offline}},
+	}
+	tests := []struct {
+		sortBy string
+		want   []int
+	}{
+		{sortBy: sortSetIndex, want: []int{1, 2, 3, 4}},
+		// a missing drive counts like one that is not ok, ties keep the set order
+		{sortBy: sortSetStatus, want: []int{4, 2, 3, 1}},
+	}
+
+	for _, tt := range tests {
+		sorted := slices.Clone(sets)
+		sortSets(sorted, tt.sortBy)
+		var got []int
+		for _, set := range sorted {
+			got = append(got, set.Set)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("--sort-sets %s: expected %v, got %v", tt.sortBy, tt.want, got)
+		}
+	}
+}
+
+func TestFilterUnhealthy(t *testing.T) {
+	full := testDrive("n1:/d1", 950, 10, 90)
+	offline := driveStatus{Endpoint: "n2:/d1", Status: "offline"}
+	r := report{Pools: []poolReport{{
+		Pool:    1,
+		Servers: []serverReport{{Endpoint: "n1", State: "online"}, {Endpoint: "n2", State: "online"}},
+		Sets: []setReport{
+			// an imbalance of ok drives alone doesn't need attention
+			{Set: 1, Drives: []driveStatus{full, testDrive("n2:/d2", 100, 10, 90)}, Imbalance: &setImbalance{Drives: []driveUsed{{Endpoint: full.Endpoint}}}},
+			{Set: 2, Drives: []driveStatus{testDrive("n1:/d3", 100, 10, 90), offline}},
+		},
+	}}}
+
+	pool := filterUnhealthy(r).Pools[0]
+	if len(pool.Servers) != 0 {
+		t.Errorf("expected the online servers to be dropped, got %v", pool.Servers)
+	}
+	if len(pool.Sets) != 1 || pool.Sets[0].Set != 2 {
+		t.Fatalf("expected only set 2 to be kept, got %+v", pool.Sets)
+	}
+	if drives := pool.Sets[0].Drives; len(drives) != 1 || drives[0].Endpoint != offline.Endpoint {
+		t.Errorf("expected only the offline drive to be kept, got %+v", drives)
+	}
+}
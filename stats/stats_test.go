@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// runOptions parses args like rootCmd and runs its option checks, without
+// printing anything. The flags are registered on a fresh command so no
+// value leaks from one run into the next.
+func runOptions(args []string) error {
+	opts = options{}
+	cmd := &cobra.Command{
+		Use:           rootCmd.Use,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PreRunE:       rootCmd.PreRunE,
+		Run:           func(cmd *cobra.Command, args []string) {},
+	}
+	registerFlags(cmd, &opts)
+	cmd.SetArgs(args)
+	return cmd.Execute()
+}
+
+func TestRejectedCombinations(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+	for _, name := range []string{a, b} {
+		if err := os.WriteFile(name, []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "json and yaml", args: []string{"--json", "--yaml", a}, want: "[json yaml] were all set"},
+		{name: "markdown and tui", args: []string{"--markdown", "--tui", a}, want: "[markdown tui] were all set"},
+		{name: "wide and plain", args: []string{"--wide", "--plain", a}, want: "[plain wide] were all set"},
+		{name: "diff and tui", args: []string{"--diff", b, "--tui", a}, want: "[diff tui] were all set"},
+		{name: "diff and markdown", args: []string{"--diff", b, "--markdown", a}, want: "[diff markdown] were all set"},
+		{name: "diff and unhealthy only", args: []string{"--diff", b, "--unhealthy-only", a}, want: "[diff unhealthy-only] were all set"},
+		{name: "diff and growth", args: []string{"--diff", b, "--growth-bytes-per-day", "1TiB", a}, want: "[diff growth-bytes-per-day] were all set"},
+		{name: "diff with several inputs", args: []string{"--diff", b, a, b}, want: "--diff and --tui take a single input file"},
+		{name: "tui with several inputs", args: []string{"--tui", a, b}, want: "--diff and --tui take a single input file"},
+		{name: "merge with one input", args: []string{"--merge", a}, want: "--merge needs at least two input files"},
+		{name: "mixed deployments without merge", args: []string{"--allow-mixed-deployments", a, b}, want: "--allow-mixed-deployments only applies to --merge"},
+		{name: "filtered scope without pool", args: []string{"--summary-scope", "filtered", a}, want: "needs at least one --pool"},
+		{name: "alias and input", args: []string{"--alias", "myminio", a, b}, want: "--alias takes no input file"},
+		{name: "diff interval without diff", args: []string{"--diff-interval", "24h", a}, want: "--diff-interval only applies to --diff"},
+		{name: "negative diff interval", args: []string{"--diff", b, "--diff-interval", "-1h", a}, want: "--diff-interval must be positive"},
+		{name: "full threshold out of range", args: []string{"--full-threshold", "0", a}, want: "--full-threshold must be within"},
+		{name: "invalid growth", args: []string{"--growth-bytes-per-day", "lots", a}, want: "invalid --growth-bytes-per-day"},
+		{name: "usage bar too wide", args: []string{"--usage-bar", "500", a}, want: "--usage-bar must be within"},
+		{name: "imbalance threshold out of range", args: []string{"--imbalance-threshold", "200", a}, want: "--imbalance-threshold must be within"},
+		{name: "unknown sort", args: []string{"--sort", "size", a}, want: "--sort must be"},
+		{name: "unknown pool sort", args: []string{"--sort-pools", "name", a}, want: "--sort-pools must be"},
+		{name: "unknown set sort", args: []string{"--sort-sets", "name", a}, want: "--sort-sets must be"},
+		{name: "unknown summary scope", args: []string{"--summary-scope", "pools", a}, want: "--summary-scope must be"},
+		{name: "invalid pool", args: []string{"--pool", "0", a}, want: "pool must be a number of at least 1"},
+		{name: "missing input", args: []string{a, filepath.Join(dir, "typo.json")}, want: "file not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runOptions(tt.args)
+			if err == nil {
+				t.Fatalf("expected %v to be rejected", tt.args)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %q", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestAcceptedCombinations(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+	for _, name := range []string{a, b} {
+		if err := os.WriteFile(name, []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"--merge", "--json", a, b},
+		{"--diff", b, "--diff-interval", "24h", "--json", a},
+		{"--pool", "1", "--summary-scope", "filtered", "--wide", a},
+		{"--sort", "inode", "--sort-pools", "usage", "--sort-sets", "status", a},
+	} {
+		if err := runOptions(args); err != nil {
+			t.Fatalf("expected %v to be accepted, got %v", args, err)
+		}
+	}
+}